@@ -3,14 +3,19 @@ package main
 import (
 	"context"
 	"log"
+	"time"
+	_ "time/tzdata" // База часовых поясов встроена в бинарник: в alpine-образе её нет
 
 	"exchange_point/backend/internal/api/router"
 	"exchange_point/backend/internal/config"
 	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	// _ "github.com/lib/pq" // Драйвер регистрируется в db.go
 )
 
@@ -28,15 +33,34 @@ func main() {
 
 	log.Println("Successfully connected to the database!")
 
+	// Фоновые задачи: отметка просроченных черновиков, очистка ключей идемпотентности,
+	// плановые изменения курсов и загрузка курсов ЦБ
+	queries := sqlcgen.New(dbConn)
+	service.StartDraftExpiry(context.Background(), queries, time.Minute)
+	service.StartIdempotencyKeyCleanup(context.Background(), queries, time.Hour, cfg.IdempotencyKeyTTL)
+	// Изменения курсов рассылаются подписчикам GET /currencies/stream
+	rateBroadcaster := service.NewRateBroadcaster()
+	rateScheduler := service.NewRateScheduler(cfg.RateChangePolicy, cfg.ReferenceRatePolicy, cfg.BusinessLocation, rateBroadcaster)
+	rateScheduler.Start(context.Background(), postgresql.NewStore(dbConn), 10*time.Second)
+	if cfg.CBRImportInterval > 0 {
+		service.StartReferenceRateImport(context.Background(), queries, service.NewCBRClient(cfg.CBRRatesURL), cfg.CBRImportInterval)
+	}
+
+	if err := service.EnsureAdminUser(context.Background(), queries, cfg.AdminUsername, cfg.AdminPassword); err != nil {
+		log.Fatalf("Could not create admin user: %v", err)
+	}
+
 	app := fiber.New()
 
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*", // Для разработки, в production лучше указать конкретные домены
-		AllowHeaders: "Origin, Content-Type, Accept",
+		AllowOrigins: cfg.CORSAllowOrigins,
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, Idempotency-Key",
 	}))
 	app.Use(logger.New())
+	// Идентификатор запроса (X-Request-ID) попадает в журнал аудита
+	app.Use(requestid.New())
 
-	router.SetupRoutes(app, dbConn)
+	router.SetupRoutes(app, dbConn, cfg, rateScheduler, rateBroadcaster)
 
 	log.Printf("Starting server on port %s", cfg.AppPort)
 	err = app.Listen(":" + cfg.AppPort)
@@ -8,6 +8,18 @@ require (
 	github.com/lib/pq v1.10.9
 )
 
+require (
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/shopspring/decimal v1.4.0
+	github.com/sqlc-dev/pqtype v0.3.0
+	golang.org/x/crypto v0.21.0
+)
+
+require (
+	github.com/philhofer/fwd v1.1.2 // indirect
+	github.com/tinylib/msgp v1.1.8 // indirect
+)
+
 require (
 	github.com/andybalholm/brotli v1.0.5 // indirect
 	github.com/google/uuid v1.5.0 // indirect
@@ -20,7 +32,7 @@ require (
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasthttp v1.51.0 // indirect
 	github.com/valyala/tcplisten v1.0.0 // indirect
-	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
 )
 
 // Запустите `go mod tidy` для автоматического добавления indirect зависимостей
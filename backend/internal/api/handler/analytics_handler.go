@@ -1,13 +1,15 @@
 package handler
 
 import (
+	"exchange_point/backend/internal/money"
 	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
 	"log"
-	"math/big"
 	"net/http"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
 )
 
 type AnalyticsHandler struct {
@@ -20,32 +22,55 @@ func NewAnalyticsHandler(q sqlcgen.Querier) *AnalyticsHandler {
 
 // Структуры для данных аналитики
 type OperationsByDateItem struct {
-	Date              string  `json:"date"`
-	Count             int     `json:"count"`
-	AmountRub         float64 `json:"amount_rub"`
-	ClientSellsCount  int     `json:"client_sells_count"`
-	ClientBuysCount   int     `json:"client_buys_count"`
-	ClientSellsVolume float64 `json:"client_sells_volume"`
-	ClientBuysVolume  float64 `json:"client_buys_volume"`
+	Date              string          `json:"date"`
+	Count             int             `json:"count"`
+	AmountRub         decimal.Decimal `json:"amount_rub"`
+	ClientSellsCount  int             `json:"client_sells_count"`
+	ClientBuysCount   int             `json:"client_buys_count"`
+	ClientSellsVolume decimal.Decimal `json:"client_sells_volume"`
+	ClientBuysVolume  decimal.Decimal `json:"client_buys_volume"`
+	FeeIncomeRub      decimal.Decimal `json:"fee_income_rub"`
+	SpreadIncomeRub   decimal.Decimal `json:"spread_income_rub"`
 }
 
 type CurrencyVolumeItem struct {
-	CurrencyCode string  `json:"currency_code"`
-	CurrencyName string  `json:"currency_name"`
-	Volume       float64 `json:"volume"`
-	RubVolume    float64 `json:"rub_volume"`
+	CurrencyCode string          `json:"currency_code"`
+	CurrencyName string          `json:"currency_name"`
+	Volume       decimal.Decimal `json:"volume"`
+	RubVolume    decimal.Decimal `json:"rub_volume"`
+	FeeIncome    decimal.Decimal `json:"fee_income_rub"`
+	SpreadIncome decimal.Decimal `json:"spread_income_rub"`
+}
+
+// RateDeviationItem сравнивает курсы операций по валюте с курсами, объявленными на момент операции.
+// Операции старше истории курсов в сравнение не входят.
+type RateDeviationItem struct {
+	CurrencyCode string `json:"currency_code"`
+	// Число операций, для которых известен объявленный курс
+	ComparedCount int `json:"compared_count"`
+	// Число операций, курс которых отличается от объявленного
+	DeviatingCount int `json:"deviating_count"`
+	// Среднее и максимальное по модулю отклонение курса операции от объявленного, в процентах
+	AverageDeviationPercent decimal.Decimal `json:"average_deviation_percent"`
+	MaxDeviationPercent     decimal.Decimal `json:"max_deviation_percent"`
 }
 
 type OperationSummary struct {
-	TotalOperations     int                    `json:"total_operations"`
-	TotalAmountRub      float64                `json:"total_amount_rub"`
-	CurrencyVolumes     []CurrencyVolumeItem   `json:"currency_volumes"`
-	AverageRates        map[string]float64     `json:"average_rates"`
-	ClientSellsCount    int                    `json:"client_sells_count"`
-	ClientBuysCount     int                    `json:"client_buys_count"`
-	DailyOperations     []OperationsByDateItem `json:"daily_operations"`
-	ClientSellsRubTotal float64                `json:"client_sells_rub_total"`
-	ClientBuysRubTotal  float64                `json:"client_buys_rub_total"`
+	TotalOperations     int                        `json:"total_operations"`
+	TotalAmountRub      decimal.Decimal            `json:"total_amount_rub"`
+	CurrencyVolumes     []CurrencyVolumeItem       `json:"currency_volumes"`
+	AverageRates        map[string]decimal.Decimal `json:"average_rates"`
+	ClientSellsCount    int                        `json:"client_sells_count"`
+	ClientBuysCount     int                        `json:"client_buys_count"`
+	DailyOperations     []OperationsByDateItem     `json:"daily_operations"`
+	ClientSellsRubTotal decimal.Decimal            `json:"client_sells_rub_total"`
+	ClientBuysRubTotal  decimal.Decimal            `json:"client_buys_rub_total"`
+	// Доход пункта обмена: комиссии и спред (разница курса операции и среднего курса) учитываются раздельно
+	FeeIncomeRub    decimal.Decimal `json:"fee_income_rub"`
+	SpreadIncomeRub decimal.Decimal `json:"spread_income_rub"`
+	TotalIncomeRub  decimal.Decimal `json:"total_income_rub"`
+	// Сравнение курсов операций с объявленными курсами по валютам
+	RateDeviations []RateDeviationItem `json:"rate_deviations"`
 }
 
 // Параметры запроса аналитики
@@ -125,21 +150,21 @@ func (h *AnalyticsHandler) GetOperationsAnalytics(c *fiber.Ctx) error {
 // Обработка данных для аналитики
 func processAnalyticsData(operations []sqlcgen.GetOperationsForAnalyticsRow, startDate, endDate time.Time) OperationSummary {
 	summary := OperationSummary{
-		TotalOperations:     len(operations),
-		TotalAmountRub:      0,
-		CurrencyVolumes:     []CurrencyVolumeItem{},
-		AverageRates:        make(map[string]float64),
-		ClientSellsCount:    0,
-		ClientBuysCount:     0,
-		DailyOperations:     []OperationsByDateItem{},
-		ClientSellsRubTotal: 0,
-		ClientBuysRubTotal:  0,
+		TotalOperations:  len(operations),
+		CurrencyVolumes:  []CurrencyVolumeItem{},
+		AverageRates:     make(map[string]decimal.Decimal),
+		ClientSellsCount: 0,
+		ClientBuysCount:  0,
+		DailyOperations:  []OperationsByDateItem{},
+		RateDeviations:   []RateDeviationItem{},
 	}
 
 	// Подготовка вспомогательных структур для расчетов
 	currencyVolumes := make(map[string]*CurrencyVolumeItem)
-	ratesSum := make(map[string]*big.Float)
+	ratesSum := make(map[string]decimal.Decimal)
 	ratesCount := make(map[string]int)
+	rateDeviations := make(map[string]*RateDeviationItem)
+	deviationSum := make(map[string]decimal.Decimal)
 
 	operationsByDate := make(map[string]*OperationsByDateItem)
 
@@ -148,77 +173,89 @@ func processAnalyticsData(operations []sqlcgen.GetOperationsForAnalyticsRow, sta
 	for !current.After(endDate) {
 		dateStr := current.Format("2006-01-02")
 		operationsByDate[dateStr] = &OperationsByDateItem{
-			Date:              dateStr,
-			Count:             0,
-			AmountRub:         0,
-			ClientSellsCount:  0,
-			ClientBuysCount:   0,
-			ClientSellsVolume: 0,
-			ClientBuysVolume:  0,
+			Date:             dateStr,
+			Count:            0,
+			ClientSellsCount: 0,
+			ClientBuysCount:  0,
 		}
 		current = current.AddDate(0, 0, 1)
 	}
 
 	// Обработка каждой операции
 	for _, op := range operations {
-		// Преобразуем строковые значения в числа
-		amountRub, _ := new(big.Float).SetString(op.AmountRub)
-		amountCurrency, _ := new(big.Float).SetString(op.AmountCurrency)
-		effectiveRate, _ := new(big.Float).SetString(op.EffectiveRate)
-
-		// Преобразуем big.Float в float64 для удобства в JSON
-		amountRubF64, _ := amountRub.Float64()
-		amountCurrencyF64, _ := amountCurrency.Float64()
-
 		// Общая сумма в рублях
-		summary.TotalAmountRub += amountRubF64
+		summary.TotalAmountRub = summary.TotalAmountRub.Add(op.AmountRub)
+
+		// Доход: комиссии и спред
+		summary.FeeIncomeRub = summary.FeeIncomeRub.Add(op.FeeRub)
+		summary.SpreadIncomeRub = summary.SpreadIncomeRub.Add(op.SpreadRub)
 
 		// Расчет по валютам
 		if _, exists := currencyVolumes[op.CurrencyCode]; !exists {
 			currencyVolumes[op.CurrencyCode] = &CurrencyVolumeItem{
 				CurrencyCode: op.CurrencyCode,
 				CurrencyName: op.CurrencyName,
-				Volume:       0,
-				RubVolume:    0,
 			}
 		}
-		currencyVolumes[op.CurrencyCode].Volume += amountCurrencyF64
-		currencyVolumes[op.CurrencyCode].RubVolume += amountRubF64
+		currencyVolumes[op.CurrencyCode].Volume = currencyVolumes[op.CurrencyCode].Volume.Add(op.AmountCurrency)
+		currencyVolumes[op.CurrencyCode].RubVolume = currencyVolumes[op.CurrencyCode].RubVolume.Add(op.AmountRub)
+		currencyVolumes[op.CurrencyCode].FeeIncome = currencyVolumes[op.CurrencyCode].FeeIncome.Add(op.FeeRub)
+		currencyVolumes[op.CurrencyCode].SpreadIncome = currencyVolumes[op.CurrencyCode].SpreadIncome.Add(op.SpreadRub)
 
 		// Накопление данных для средних курсов
-		if _, exists := ratesSum[op.CurrencyCode]; !exists {
-			ratesSum[op.CurrencyCode] = new(big.Float)
-			ratesCount[op.CurrencyCode] = 0
-		}
-		ratesSum[op.CurrencyCode] = new(big.Float).Add(ratesSum[op.CurrencyCode], effectiveRate)
+		ratesSum[op.CurrencyCode] = ratesSum[op.CurrencyCode].Add(op.EffectiveRate)
 		ratesCount[op.CurrencyCode]++
 
+		// Сравнение с курсом, объявленным на момент операции
+		if op.PostedBuyRate.Valid && op.PostedSellRate.Valid {
+			posted := service.OperationRate(op.OperationType, op.PostedBuyRate.Decimal, op.PostedSellRate.Decimal)
+			if posted.IsPositive() {
+				if _, exists := rateDeviations[op.CurrencyCode]; !exists {
+					rateDeviations[op.CurrencyCode] = &RateDeviationItem{CurrencyCode: op.CurrencyCode}
+				}
+				item := rateDeviations[op.CurrencyCode]
+				item.ComparedCount++
+				deviation := money.Quo(op.EffectiveRate.Sub(posted), posted).Mul(decimal.NewFromInt(100)).Abs()
+				if !deviation.IsZero() {
+					item.DeviatingCount++
+				}
+				if deviation.GreaterThan(item.MaxDeviationPercent) {
+					item.MaxDeviationPercent = deviation
+				}
+				deviationSum[op.CurrencyCode] = deviationSum[op.CurrencyCode].Add(deviation)
+			}
+		}
+
 		// Учет типа операции
 		if op.OperationType == "CLIENT_SELLS_TO_EXCHANGE" {
 			summary.ClientSellsCount++
-			summary.ClientSellsRubTotal += amountRubF64
+			summary.ClientSellsRubTotal = summary.ClientSellsRubTotal.Add(op.AmountRub)
 		} else if op.OperationType == "CLIENT_BUYS_FROM_EXCHANGE" {
 			summary.ClientBuysCount++
-			summary.ClientBuysRubTotal += amountRubF64
+			summary.ClientBuysRubTotal = summary.ClientBuysRubTotal.Add(op.AmountRub)
 		}
 
 		// Группировка по дням
 		opDate := op.OperationTimestamp.Time.Format("2006-01-02")
 		if _, exists := operationsByDate[opDate]; exists {
 			operationsByDate[opDate].Count++
-			operationsByDate[opDate].AmountRub += amountRubF64
+			operationsByDate[opDate].AmountRub = operationsByDate[opDate].AmountRub.Add(op.AmountRub)
+			operationsByDate[opDate].FeeIncomeRub = operationsByDate[opDate].FeeIncomeRub.Add(op.FeeRub)
+			operationsByDate[opDate].SpreadIncomeRub = operationsByDate[opDate].SpreadIncomeRub.Add(op.SpreadRub)
 
 			if op.OperationType == "CLIENT_SELLS_TO_EXCHANGE" {
 				operationsByDate[opDate].ClientSellsCount++
-				operationsByDate[opDate].ClientSellsVolume += amountCurrencyF64
+				operationsByDate[opDate].ClientSellsVolume = operationsByDate[opDate].ClientSellsVolume.Add(op.AmountCurrency)
 			} else if op.OperationType == "CLIENT_BUYS_FROM_EXCHANGE" {
 				operationsByDate[opDate].ClientBuysCount++
-				operationsByDate[opDate].ClientBuysVolume += amountCurrencyF64
+				operationsByDate[opDate].ClientBuysVolume = operationsByDate[opDate].ClientBuysVolume.Add(op.AmountCurrency)
 			}
 		}
 	}
 
 	// Формирование итоговых данных
+	summary.TotalIncomeRub = summary.FeeIncomeRub.Add(summary.SpreadIncomeRub)
+
 	// Добавляем объемы валют
 	for _, v := range currencyVolumes {
 		summary.CurrencyVolumes = append(summary.CurrencyVolumes, *v)
@@ -228,12 +265,17 @@ func processAnalyticsData(operations []sqlcgen.GetOperationsForAnalyticsRow, sta
 	for currency, sum := range ratesSum {
 		count := ratesCount[currency]
 		if count > 0 {
-			avg := new(big.Float).Quo(sum, new(big.Float).SetInt64(int64(count)))
-			avgF64, _ := avg.Float64()
-			summary.AverageRates[currency] = avgF64
+			summary.AverageRates[currency] = money.RoundRate(money.Quo(sum, decimal.NewFromInt(int64(count))))
 		}
 	}
 
+	// Рассчитываем отклонения от объявленных курсов
+	for currency, item := range rateDeviations {
+		item.AverageDeviationPercent = money.RoundRate(money.Quo(deviationSum[currency], decimal.NewFromInt(int64(item.ComparedCount))))
+		item.MaxDeviationPercent = money.RoundRate(item.MaxDeviationPercent)
+		summary.RateDeviations = append(summary.RateDeviations, *item)
+	}
+
 	// Добавляем данные по дням в хронологическом порядке
 	current = startDate
 	for !current.After(endDate) {
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Максимальная длина идентификатора запроса (audit_events.request_id)
+const maxAuditRequestIDLength = 100
+
+// audit записывает изменение сущности в журнал аудита от имени аутентифицированного пользователя.
+// Вызывается в транзакции изменения: если запись в журнал не удалась, изменение откатывается.
+// Заодно к ключу идемпотентности запроса привязывается изменённая сущность.
+// before — состояние до изменения (nil при создании), after — после (nil при удалении).
+func audit(c *fiber.Ctx, q sqlcgen.Querier, action, entityType string, entityID interface{}, before, after interface{}) error {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return auditAs(c, q, nil, action, entityType, entityID, before, after)
+	}
+	return auditAs(c, q, &user, action, entityType, entityID, before, after)
+}
+
+// auditAs записывает изменение от имени actor: нужен там, где пользователь ещё не аутентифицирован middleware, — при входе
+func auditAs(c *fiber.Ctx, q sqlcgen.Querier, actor *sqlcgen.User, action, entityType string, entityID interface{}, before, after interface{}) error {
+	rec := service.AuditRecord{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   fmt.Sprint(entityID),
+		Before:     before,
+		After:      after,
+		IPAddress:  c.IP(),
+		RequestID:  c.GetRespHeader(fiber.HeaderXRequestID),
+	}
+	// Идентификатор запроса может прийти от клиента: длина ограничена audit_events.request_id
+	if len(rec.RequestID) > maxAuditRequestIDLength {
+		rec.RequestID = strings.ToValidUTF8(rec.RequestID[:maxAuditRequestIDLength], "")
+	}
+	if actor != nil {
+		rec.ActorID = sql.NullInt32{Int32: actor.ID, Valid: true}
+		rec.ActorUsername = sql.NullString{String: actor.Username, Valid: true}
+	}
+	if _, err := service.AppendAuditEvent(c.Context(), q, rec); err != nil {
+		return fmt.Errorf("could not write audit event: %w", err)
+	}
+	// Изменение и ссылка на него в ключе идемпотентности фиксируются одной транзакцией
+	if err := middleware.BindIdempotencyKey(c, q, entityType, rec.EntityID); err != nil {
+		return fmt.Errorf("could not bind idempotency key: %w", err)
+	}
+	return nil
+}
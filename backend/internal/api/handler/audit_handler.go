@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandler struct {
+	store    postgresql.Store
+	location *time.Location // Часовой пояс пункта обмена: в нём задаются фильтры по дате
+}
+
+func NewAuditHandler(store postgresql.Store, location *time.Location) *AuditHandler {
+	return &AuditHandler{store: store, location: location}
+}
+
+// GetEvents возвращает записи журнала аудита постранично, новые первыми.
+// Фильтры: actor_id, action, entity_type, entity_id, from и to.
+func (h *AuditHandler) GetEvents(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	} else if pageSize > 100 {
+		pageSize = 100
+	}
+
+	params := sqlcgen.ListAuditEventsParams{
+		Limit:      int32(pageSize),
+		Offset:     int32((page - 1) * pageSize),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+	}
+	if v := c.Query("actor_id"); v != "" {
+		actorID, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid actor_id format"})
+		}
+		params.ActorID = sql.NullInt32{Int32: int32(actorID), Valid: true}
+	}
+	var err error
+	if params.OccurredFrom, err = parsePeriodBound(c.Query("from"), h.location, false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid from", "data": err.Error()})
+	}
+	if params.OccurredTo, err = parsePeriodBound(c.Query("to"), h.location, true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid to", "data": err.Error()})
+	}
+
+	events, err := h.store.ListAuditEvents(c.Context(), params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve audit events", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Audit events retrieved successfully", "data": events})
+}
+
+// VerifyChain проверяет цепочку хешей журнала аудита и возвращает хеш последней записи,
+// который аудитор сохраняет, чтобы при следующей проверке обнаружить удаление записей с конца
+func (h *AuditHandler) VerifyChain(c *fiber.Ctx) error {
+	report, err := service.VerifyAuditChain(c.Context(), h.store)
+	if err != nil {
+		log.Printf("Error verifying audit chain: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not verify audit chain", "data": err.Error()})
+	}
+	message := "Audit chain is intact"
+	if !report.Valid {
+		log.Printf("Audit chain is broken at event %d: %s", report.BrokenEventID, report.Problem)
+		message = "Audit chain is broken"
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": message, "data": report})
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuthHandler struct {
+	store postgresql.Store
+	auth  *service.AuthService
+}
+
+func NewAuthHandler(store postgresql.Store, auth *service.AuthService) *AuthHandler {
+	return &AuthHandler{store: store, auth: auth}
+}
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// userIDParam возвращает идентификатор аутентифицированного пользователя для записи в операцию или историю
+func userIDParam(c *fiber.Ctx) sql.NullInt32 {
+	user, ok := middleware.CurrentUser(c)
+	return sql.NullInt32{Int32: user.ID, Valid: ok}
+}
+
+// Login проверяет имя и пароль и выдаёт JWT
+func (h *AuthHandler) Login(c *fiber.Ctx) error {
+	req := new(LoginRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" || req.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "username and password are required"})
+	}
+
+	user, err := service.Authenticate(c.Context(), h.store, req.Username, req.Password)
+	if err == service.ErrInvalidCredentials {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid username or password"})
+	}
+	if err != nil {
+		log.Printf("Error authenticating user '%s': %v", req.Username, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not log in", "data": err.Error()})
+	}
+
+	token, expiresAt, err := h.auth.IssueToken(user)
+	if err != nil {
+		log.Printf("Error issuing token for user %d: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not log in", "data": err.Error()})
+	}
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		if err := q.RecordUserLogin(c.Context(), user.ID); err != nil {
+			return err
+		}
+		return auditAs(c, q, &user, service.AuditAuthLogin, service.AuditEntityUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		log.Printf("Error recording login of user %d: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not log in", "data": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Logged in successfully",
+		"data": fiber.Map{
+			"token":      token,
+			"token_type": "Bearer",
+			"expires_at": expiresAt,
+			"user":       user,
+		},
+	})
+}
+
+// Me возвращает аутентифицированного пользователя
+func (h *AuthHandler) Me(c *fiber.Ctx) error {
+	user, _ := middleware.CurrentUser(c)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "User retrieved successfully", "data": user})
+}
+
+// IssueStreamTicket выдаёт короткоживущий билет для подключения к потокам Server-Sent Events
+func (h *AuthHandler) IssueStreamTicket(c *fiber.Ctx) error {
+	user, _ := middleware.CurrentUser(c)
+	ticket, expiresAt, err := h.auth.IssueStreamTicket(user)
+	if err != nil {
+		log.Printf("Error issuing stream ticket for user %d: %v", user.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not issue stream ticket", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Stream ticket issued successfully",
+		"data":    fiber.Map{"ticket": ticket, "expires_at": expiresAt},
+	})
+}
+
+// ChangePassword меняет пароль аутентифицированного пользователя; нужен текущий пароль
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	user, _ := middleware.CurrentUser(c)
+	req := new(ChangePasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	if !service.CheckPassword(user.PasswordHash, req.CurrentPassword) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Current password is incorrect"})
+	}
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		if err := setUserPassword(c, q, user.ID, req.NewPassword); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditAuthPasswordChange, service.AuditEntityUser, user.ID, nil, nil)
+	})
+	if err != nil {
+		return respondError(c, err, "Could not change password")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Password changed successfully"})
+}
+
+// setUserPassword проверяет и сохраняет новый пароль пользователя
+func setUserPassword(c *fiber.Ctx, q sqlcgen.Querier, userID int32, password string) error {
+	if err := service.ValidatePassword(password); err != nil {
+		return newRequestError(fiber.StatusBadRequest, "Invalid password: %v", err)
+	}
+	hash, err := service.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return q.UpdateUserPassword(c.Context(), sqlcgen.UpdateUserPasswordParams{ID: userID, PasswordHash: hash})
+}
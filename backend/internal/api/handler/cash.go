@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// cashMovement — движение наличных, которое нужно провести по кассе
+type cashMovement struct {
+	Flow         service.CashFlow
+	MovementType string
+	OperationID  sql.NullInt64
+	Reason       sql.NullString
+	Comment      sql.NullString
+}
+
+// operationCashMovements возвращает движения наличных по операции обмена
+func operationCashMovements(op sqlcgen.Operation, currencyCode string) []cashMovement {
+	flows := service.OperationCashFlows(op.OperationType, currencyCode, op.AmountCurrency, op.AmountRub, op.FeeRub)
+	return cashMovementsFor(flows, service.CashMovementOperation, op.ID)
+}
+
+// reversalCashMovements возвращает движения наличных, компенсирующие операцию original, для сторно reversal
+func reversalCashMovements(original, reversal sqlcgen.Operation, currencyCode string) []cashMovement {
+	flows := service.OperationCashFlows(original.OperationType, currencyCode, original.AmountCurrency, original.AmountRub, original.FeeRub)
+	return cashMovementsFor(service.ReverseCashFlows(flows), service.CashMovementReversal, reversal.ID)
+}
+
+func cashMovementsFor(flows []service.CashFlow, movementType string, operationID int64) []cashMovement {
+	movements := make([]cashMovement, len(flows))
+	for i, flow := range flows {
+		movements[i] = cashMovement{
+			Flow:         flow,
+			MovementType: movementType,
+			OperationID:  sql.NullInt64{Int64: operationID, Valid: true},
+		}
+	}
+	return movements
+}
+
+// applyCashMovements проводит движения по остаткам кассы внутри транзакции.
+// Остатки проверяются после всех движений, поэтому промежуточный расход рублей
+// в кросс-конвертации не мешает, если итог укладывается в остаток.
+// Валюта, остаток которой уменьшился, не может уйти ниже нуля, а при enforceReserve — ниже резерва.
+// Движения записываются в смену shift.
+func applyCashMovements(ctx context.Context, q sqlcgen.Querier, shift sqlcgen.Shift, movements []cashMovement, enforceReserve bool) error {
+	// Остатки блокируются в порядке кода валюты, чтобы параллельные операции не блокировали друг друга
+	sort.SliceStable(movements, func(i, j int) bool {
+		return movements[i].Flow.CurrencyCode < movements[j].Flow.CurrencyCode
+	})
+
+	var codes []string
+	balances := make(map[string]sqlcgen.CashBalance)
+	netAmounts := make(map[string]decimal.Decimal)
+	for _, m := range movements {
+		balance, err := q.ChangeCashBalance(ctx, sqlcgen.ChangeCashBalanceParams{
+			CurrencyCode: m.Flow.CurrencyCode,
+			Amount:       m.Flow.Amount,
+		})
+		if err != nil {
+			return fmt.Errorf("could not update cash balance: %w", err)
+		}
+		_, err = q.CreateCashMovement(ctx, sqlcgen.CreateCashMovementParams{
+			CurrencyCode: m.Flow.CurrencyCode,
+			MovementType: m.MovementType,
+			Amount:       m.Flow.Amount,
+			BalanceAfter: balance.Balance,
+			OperationID:  m.OperationID,
+			Reason:       m.Reason,
+			Comment:      m.Comment,
+			ShiftID:      shiftIDParam(shift),
+		})
+		if err != nil {
+			return fmt.Errorf("could not record cash movement: %w", err)
+		}
+		if _, seen := balances[m.Flow.CurrencyCode]; !seen {
+			codes = append(codes, m.Flow.CurrencyCode)
+		}
+		balances[m.Flow.CurrencyCode] = balance
+		netAmounts[m.Flow.CurrencyCode] = netAmounts[m.Flow.CurrencyCode].Add(m.Flow.Amount)
+	}
+
+	for _, code := range codes {
+		if netAmounts[code].Sign() >= 0 {
+			continue
+		}
+		balance := balances[code]
+		if balance.Balance.Sign() < 0 {
+			return newRequestError(fiber.StatusConflict, "Not enough %s cash: available %s, required %s",
+				code, balance.Balance.Sub(netAmounts[code]).StringFixed(2), netAmounts[code].Neg().StringFixed(2))
+		}
+		if enforceReserve && balance.Balance.Cmp(balance.Reserve) < 0 {
+			return newRequestError(fiber.StatusConflict, "Operation would take %s cash below the reserve of %s: balance after operation %s",
+				code, balance.Reserve.StringFixed(2), balance.Balance.StringFixed(2))
+		}
+	}
+	return nil
+}
+
+// currentCashBalance возвращает остаток наличных валюты или nil, если по валюте ещё не было движений
+func currentCashBalance(ctx context.Context, q sqlcgen.Querier, currencyCode string) (*sqlcgen.CashBalance, error) {
+	balance, err := q.GetCashBalance(ctx, currencyCode)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+// checkCashAvailable проверяет без изменения остатков, что касса может выдать наличные по операции.
+// Используется при подтверждении черновика, до фактической выдачи.
+func checkCashAvailable(ctx context.Context, q sqlcgen.Querier, flows []service.CashFlow) error {
+	for _, flow := range flows {
+		if flow.Amount.Sign() >= 0 {
+			continue
+		}
+		balance, err := q.GetCashBalance(ctx, flow.CurrencyCode)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("could not load cash balance: %w", err)
+		}
+		if balance.Balance.Add(flow.Amount).Cmp(balance.Reserve) < 0 {
+			return newRequestError(fiber.StatusConflict, "Not enough %s cash above the reserve of %s: available %s, required %s",
+				flow.CurrencyCode, balance.Reserve.StringFixed(2), balance.Balance.StringFixed(2), flow.Amount.Neg().StringFixed(2))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"exchange_point/backend/internal/money"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+type CashHandler struct {
+	store postgresql.Store
+}
+
+func NewCashHandler(store postgresql.Store) *CashHandler {
+	return &CashHandler{store: store}
+}
+
+type CashMovementRequest struct {
+	CurrencyCode string          `json:"currency_code" validate:"required"`
+	Amount       decimal.Decimal `json:"amount" validate:"required,gt=0"`
+	ReasonCode   string          `json:"reason_code" validate:"required"`
+	Comment      string          `json:"comment"`
+}
+
+type CashReserveRequest struct {
+	Reserve decimal.Decimal `json:"reserve"`
+}
+
+// cashPrecision возвращает правила округления наличных валюты; неизвестная валюта — ошибка 400
+func (h *CashHandler) cashPrecision(ctx context.Context, currencyCode string) (money.Precision, error) {
+	if currencyCode == service.CashCurrencyRUB {
+		return money.RUB, nil
+	}
+	currency, err := h.store.GetCurrencyByCode(ctx, currencyCode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return money.Precision{}, newRequestError(fiber.StatusBadRequest, "Unknown currency '%s'", currencyCode)
+		}
+		return money.Precision{}, err
+	}
+	return currencyPrecision(currency)
+}
+
+// GetBalances возвращает текущие остатки наличных по валютам
+func (h *CashHandler) GetBalances(c *fiber.Ctx) error {
+	balances, err := h.store.ListCashBalances(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve cash balances",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cash balances retrieved successfully",
+		"data":    balances,
+	})
+}
+
+// UpdateReserve задаёт неснижаемый остаток валюты, ниже которого операции с клиентами отклоняются
+func (h *CashHandler) UpdateReserve(c *fiber.Ctx) error {
+	currencyCode := strings.ToUpper(c.Params("code"))
+	req := new(CashReserveRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body: " + err.Error(),
+		})
+	}
+	if req.Reserve.Sign() < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Reserve must not be negative",
+		})
+	}
+	if _, err := h.cashPrecision(c.Context(), currencyCode); err != nil {
+		return respondError(c, err, "Could not update cash reserve")
+	}
+
+	var balance sqlcgen.CashBalance
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := currentCashBalance(c.Context(), q, currencyCode)
+		if err != nil {
+			return err
+		}
+		balance, err = q.SetCashReserve(c.Context(), sqlcgen.SetCashReserveParams{
+			CurrencyCode: currencyCode,
+			Reserve:      req.Reserve,
+		})
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditCashReserveUpdate, service.AuditEntityCashBalance, currencyCode, before, balance)
+	})
+	if err != nil {
+		log.Printf("Error updating cash reserve of %s: %v", currencyCode, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not update cash reserve",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cash reserve updated successfully",
+		"data":    balance,
+	})
+}
+
+// CashIn проводит поступление наличных в кассу (подкрепление из банка, начальный остаток)
+func (h *CashHandler) CashIn(c *fiber.Ctx) error {
+	return h.moveCash(c, service.CashMovementIn, service.CashInReasons)
+}
+
+// CashOut проводит изъятие наличных из кассы (инкассация, сдача в банк).
+// Резерв на изъятие не распространяется: кассу можно сдать до нуля.
+func (h *CashHandler) CashOut(c *fiber.Ctx) error {
+	return h.moveCash(c, service.CashMovementOut, service.CashOutReasons)
+}
+
+func (h *CashHandler) moveCash(c *fiber.Ctx, movementType string, reasons map[string]string) error {
+	req := new(CashMovementRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	req.CurrencyCode = strings.ToUpper(req.CurrencyCode)
+	if _, ok := reasons[req.ReasonCode]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Unknown reason code", "data": req.ReasonCode})
+	}
+	if req.ReasonCode == "ADJUSTMENT" && req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Comment is required for reason code ADJUSTMENT"})
+	}
+	if req.Amount.Sign() <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Amount must be greater than zero"})
+	}
+	precision, err := h.cashPrecision(c.Context(), req.CurrencyCode)
+	if err != nil {
+		return respondError(c, err, "Could not record cash movement")
+	}
+	if !precision.IsRounded(req.Amount) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Amount in " + req.CurrencyCode + " must have " + precision.Describe()})
+	}
+
+	amount := req.Amount
+	if movementType == service.CashMovementOut {
+		amount = amount.Neg()
+	}
+	movement := cashMovement{
+		Flow:         service.CashFlow{CurrencyCode: req.CurrencyCode, Amount: amount},
+		MovementType: movementType,
+		Reason:       sql.NullString{String: req.ReasonCode, Valid: true},
+	}
+	if req.Comment != "" {
+		movement.Comment = sql.NullString{String: req.Comment, Valid: true}
+	}
+
+	var balance sqlcgen.CashBalance
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		shift, err := lockOpenShift(c, q)
+		if err != nil {
+			return err
+		}
+		before, err := currentCashBalance(c.Context(), q, req.CurrencyCode)
+		if err != nil {
+			return err
+		}
+		if err := applyCashMovements(c.Context(), q, shift, []cashMovement{movement}, false); err != nil {
+			return err
+		}
+		balance, err = q.GetCashBalance(c.Context(), req.CurrencyCode)
+		if err != nil {
+			return err
+		}
+		action := service.AuditCashIn
+		if movementType == service.CashMovementOut {
+			action = service.AuditCashOut
+		}
+		return audit(c, q, action, service.AuditEntityCashBalance, req.CurrencyCode, before, balance)
+	})
+	if err != nil {
+		log.Printf("Error recording %s of %s %s: %v", movementType, req.Amount, req.CurrencyCode, err)
+		return respondError(c, err, "Could not record cash movement")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cash movement recorded successfully",
+		"data":    balance,
+	})
+}
+
+// GetMovements возвращает движения наличных постранично, новые первыми; currency_code фильтрует по валюте
+func (h *CashHandler) GetMovements(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	} else if pageSize > 100 {
+		pageSize = 100
+	}
+
+	movements, err := h.store.ListCashMovements(c.Context(), sqlcgen.ListCashMovementsParams{
+		Limit:        int32(pageSize),
+		Offset:       int32((page - 1) * pageSize),
+		CurrencyCode: strings.ToUpper(c.Query("currency_code")),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve cash movements",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cash movements retrieved successfully",
+		"data":    movements,
+	})
+}
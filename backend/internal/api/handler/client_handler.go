@@ -2,7 +2,10 @@ package handler
 
 import (
 	"database/sql"
+	"exchange_point/backend/internal/repository/postgresql"
 	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,15 +13,15 @@ import (
 )
 
 type ClientHandler struct {
-	queries sqlcgen.Querier
+	store postgresql.Store
 }
 
-func NewClientHandler(q sqlcgen.Querier) *ClientHandler {
-	return &ClientHandler{queries: q}
+func NewClientHandler(store postgresql.Store) *ClientHandler {
+	return &ClientHandler{store: store}
 }
 
 func (h *ClientHandler) GetClients(c *fiber.Ctx) error {
-	clients, err := h.queries.ListClients(c.Context())
+	clients, err := h.store.ListClients(c.Context())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve clients", "data": err.Error()})
 	}
@@ -32,7 +35,7 @@ func (h *ClientHandler) GetClientByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid client ID format"})
 	}
 
-	client, err := h.queries.GetClientByID(c.Context(), int32(id))
+	client, err := h.store.GetClientByID(c.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Client not found"})
@@ -66,12 +69,29 @@ func (h *ClientHandler) CreateClient(c *fiber.Ctx) error {
 		params.PhoneNumber = sql.NullString{Valid: false}
 	}
 
-	client, err := h.queries.CreateClient(c.Context(), params)
+	// Проверка паспорта, создание клиента и запись в журнал аудита выполняются одной транзакцией
+	var client sqlcgen.Client
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		_, err := q.GetClientByPassport(c.Context(), req.PassportNumber)
+		if err == nil {
+			return newRequestError(fiber.StatusConflict, "Client with this passport number already exists")
+		}
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		client, err = q.CreateClient(c.Context(), params)
+		if isUniqueViolation(err) {
+			return newRequestError(fiber.StatusConflict, "Client with this passport or phone number already exists")
+		}
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditClientCreate, service.AuditEntityClient, client.ID, nil, client)
+	})
 	if err != nil {
-		// Здесь можно добавить проверку на конкретные ошибки PostgreSQL, например, UNIQUE violation
-		// pqErr, ok := err.(*pq.Error)
-		// if ok && pqErr.Code == "23505" { ... }
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create client", "data": err.Error()})
+		log.Printf("Error creating client: %v", err)
+		return respondError(c, err, "Could not create client")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Client created successfully", "data": client})
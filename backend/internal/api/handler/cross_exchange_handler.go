@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// createCrossExchange проводит кросс-конвертацию через рубли: клиент продаёт исходную валюту
+// по курсу продажи и на полученные рубли покупает целевую валюту по курсу покупки.
+// Обе операции записываются одной транзакцией с общим exchange_group, лимиты проверяются по обеим валютам.
+func (h *OperationHandler) createCrossExchange(c *fiber.Ctx, req *CreateOperationRequest) error {
+	if len(req.DailyLimit) > 0 || len(req.SingleLimit) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Operation limits cannot be overridden by the request"})
+	}
+	if req.QuoteID != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Quotes are not supported for cross-currency exchange"})
+	}
+	if req.TargetCurrencyID == 0 || req.TargetCurrencyID == req.CurrencyID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "target_currency_id must be set and differ from currency_id"})
+	}
+
+	// Курсы обеих валют — действующие сейчас, с учётом наступивших плановых изменений
+	if err := h.rates.Refresh(c.Context(), h.store, req.CurrencyID, req.TargetCurrencyID); err != nil {
+		return respondError(c, err, "Could not create operation")
+	}
+	sourceCurrency, err := h.store.GetCurrency(c.Context(), req.CurrencyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Currency not found", "data": err.Error()})
+	}
+	targetCurrency, err := h.store.GetCurrency(c.Context(), req.TargetCurrencyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Target currency not found", "data": err.Error()})
+	}
+	for _, currency := range []sqlcgen.Currency{sourceCurrency, targetCurrency} {
+		if err := ensureCurrencyActive(currency); err != nil {
+			return respondError(c, err, "Could not create operation")
+		}
+	}
+
+	// Первая часть: клиент продаёт исходную валюту за рубли
+	sellLeg, err := calculateExchange(sourceCurrency, service.OperationClientSells, req.Amount)
+	if err != nil {
+		return respondError(c, err, "Could not create operation")
+	}
+	sellFee, err := calculateFee(c.Context(), h.store, sourceCurrency.ID, service.OperationClientSells, sellLeg.AmountRub)
+	if err != nil {
+		return respondError(c, err, "Could not create operation")
+	}
+	if err := applyFee(&sellLeg, service.OperationClientSells, sellFee); err != nil {
+		return respondError(c, err, "Could not create operation")
+	}
+
+	// Вторая часть: на рубли за вычетом комиссий обеих частей клиент покупает целевую валюту.
+	// Комиссия покупки считается от рублей, полученных после первой части.
+	netRub := sellLeg.AmountRub.Sub(sellFee.TotalRub)
+	buyFee, err := calculateFee(c.Context(), h.store, targetCurrency.ID, service.OperationClientBuys, netRub)
+	if err != nil {
+		return respondError(c, err, "Could not create operation")
+	}
+	buyRub := netRub.Sub(buyFee.TotalRub)
+	if buyRub.Sign() <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Amount does not cover exchange fees"})
+	}
+	buyLeg, err := calculateExchange(targetCurrency, service.OperationClientBuys, buyRub)
+	if err != nil {
+		return respondError(c, err, "Could not create operation")
+	}
+	if err := applyFee(&buyLeg, service.OperationClientBuys, buyFee); err != nil {
+		return respondError(c, err, "Could not create operation")
+	}
+
+	sellParams := sqlcgen.CreateCrossExchangeLegParams{
+		ClientID:       req.ClientID,
+		OperationType:  service.OperationClientSells,
+		CurrencyID:     sourceCurrency.ID,
+		AmountCurrency: sellLeg.AmountCurrency,
+		AmountRub:      sellLeg.AmountRub,
+		EffectiveRate:  sellLeg.EffectiveRate,
+		FeeFixedRub:    sellFee.FixedRub,
+		FeePercentRub:  sellFee.PercentRub,
+		FeeRub:         sellFee.TotalRub,
+		FeeRuleID:      sellFee.RuleID,
+		SpreadRub:      sellLeg.SpreadRub,
+		CashierID:      userIDParam(c),
+	}
+	buyParams := sqlcgen.CreateCrossExchangeLegParams{
+		ClientID:       req.ClientID,
+		OperationType:  service.OperationClientBuys,
+		CurrencyID:     targetCurrency.ID,
+		AmountCurrency: buyLeg.AmountCurrency,
+		AmountRub:      buyLeg.AmountRub,
+		EffectiveRate:  buyLeg.EffectiveRate,
+		FeeFixedRub:    buyFee.FixedRub,
+		FeePercentRub:  buyFee.PercentRub,
+		FeeRub:         buyFee.TotalRub,
+		FeeRuleID:      buyFee.RuleID,
+		SpreadRub:      buyLeg.SpreadRub,
+		CashierID:      userIDParam(c),
+	}
+
+	var legs []sqlcgen.Operation
+	var exchangeGroup string
+	// Лимиты проверяются по обеим валютам: берутся блокировки клиента по каждой из них
+	locks := []postgresql.AdvisoryLock{
+		postgresql.ClientCurrencyLock(req.ClientID, sourceCurrency.ID),
+		postgresql.ClientCurrencyLock(req.ClientID, targetCurrency.ID),
+	}
+	err = h.store.RunInTxLocked(c.Context(), locks, func(q sqlcgen.Querier) error {
+		legs = nil
+
+		shift, err := lockOpenShift(c, q)
+		if err != nil {
+			return err
+		}
+
+		for _, currency := range []sqlcgen.Currency{sourceCurrency, targetCurrency} {
+			if err := ensureCurrentRate(c.Context(), q, h.rates, currency); err != nil {
+				return err
+			}
+		}
+		if err := ensureCurrentFee(c.Context(), q, sourceCurrency.ID, service.OperationClientSells, sellLeg.AmountRub, sellFee); err != nil {
+			return err
+		}
+		if err := ensureCurrentFee(c.Context(), q, targetCurrency.ID, service.OperationClientBuys, netRub, buyFee); err != nil {
+			return err
+		}
+		if err := h.checkOperationLimits(c.Context(), q, req.ClientID, sourceCurrency, service.OperationClientSells, sellLeg.AmountCurrency); err != nil {
+			return err
+		}
+		if err := h.checkOperationLimits(c.Context(), q, req.ClientID, targetCurrency, service.OperationClientBuys, buyLeg.AmountCurrency); err != nil {
+			return err
+		}
+
+		// Конвертация получает один номер чека, её операции — номер с суффиксом части
+		groupReference, err := h.nextReceiptNumber(c.Context(), q, service.OperationCrossExchange)
+		if err != nil {
+			return err
+		}
+		exchangeGroup = *groupReference
+		for i, params := range []sqlcgen.CreateCrossExchangeLegParams{sellParams, buyParams} {
+			legReference := fmt.Sprintf("%s-%d", exchangeGroup, i+1)
+			params.ReceiptReference = &legReference
+			params.ExchangeGroup = sql.NullString{String: exchangeGroup, Valid: true}
+			params.ShiftID = shiftIDParam(shift)
+			leg, err := q.CreateCrossExchangeLeg(c.Context(), params)
+			if err != nil {
+				return err
+			}
+			if err := audit(c, q, service.AuditOperationCreate, service.AuditEntityOperation, leg.ID, nil, leg); err != nil {
+				return err
+			}
+			legs = append(legs, leg)
+		}
+
+		// Наличные проводятся по обеим частям сразу: рубли от продажи идут на покупку и кассу не уменьшают
+		movements := operationCashMovements(legs[0], sourceCurrency.Code)
+		movements = append(movements, operationCashMovements(legs[1], targetCurrency.Code)...)
+		return applyCashMovements(c.Context(), q, shift, movements, true)
+	})
+	if err != nil {
+		log.Printf("Error creating cross-currency exchange: %v", err)
+		return respondError(c, err, "Could not create operation")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Cross-currency exchange created successfully",
+		"data": fiber.Map{
+			"exchange_group": exchangeGroup,
+			"legs":           legs,
+		},
+	})
+}
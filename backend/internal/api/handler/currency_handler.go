@@ -1,23 +1,109 @@
 package handler
 
 import (
+	"database/sql"
+	"exchange_point/backend/internal/money"
+	"exchange_point/backend/internal/repository/postgresql"
 	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
 )
 
 type CurrencyHandler struct {
-	queries sqlcgen.Querier
+	store      postgresql.Store
+	location   *time.Location              // Часовой пояс пункта обмена: в нём задаются даты истории курсов
+	reference  service.ReferenceRatePolicy // Коридор вокруг официального курса ЦБ
+	rateChange service.RateChangePolicy    // Допустимый скачок курса без подтверждения
+	rates      *service.RateScheduler      // Плановые изменения курсов
+	broadcast  *service.RateBroadcaster    // Подписчики на изменения курсов (GET /currencies/stream)
 }
 
-func NewCurrencyHandler(q sqlcgen.Querier) *CurrencyHandler {
-	return &CurrencyHandler{queries: q}
+func NewCurrencyHandler(store postgresql.Store, location *time.Location, reference service.ReferenceRatePolicy, rateChange service.RateChangePolicy, rates *service.RateScheduler, broadcast *service.RateBroadcaster) *CurrencyHandler {
+	return &CurrencyHandler{store: store, location: location, reference: reference, rateChange: rateChange, rates: rates, broadcast: broadcast}
 }
 
-// GetCurrencies получает список всех валют
+// rateViolationError — ответ 422 со списком нарушений правил курса
+func rateViolationError(violations []service.RateViolation) *requestError {
+	reqErr := newRequestError(fiber.StatusUnprocessableEntity, "Invalid rates")
+	reqErr.Data = fiber.Map{"violations": violations}
+	return reqErr
+}
+
+// CurrencyResponse — валюта вместе с действующим официальным курсом ЦБ (nil, если он не загружен)
+type CurrencyResponse struct {
+	sqlcgen.Currency
+	ReferenceRate *sqlcgen.ReferenceRate `json:"reference_rate"`
+}
+
+// checkReferenceRate сравнивает новые курсы валюты с официальным курсом ЦБ, действующим в момент at.
+// В режиме REJECT выход за коридор — ошибка 422, иначе отклонения возвращаются как предупреждения.
+func (h *CurrencyHandler) checkReferenceRate(c *fiber.Ctx, q sqlcgen.Querier, code string, at time.Time, buyRate, sellRate decimal.Decimal) ([]service.ReferenceRateDeviation, error) {
+	deviations, err := service.CheckReferenceRate(c.Context(), q, h.reference, code, at.In(h.location), buyRate, sellRate)
+	if err != nil {
+		return nil, err
+	}
+	if len(deviations) > 0 && h.reference.Reject {
+		reqErr := newRequestError(fiber.StatusUnprocessableEntity, "Rates deviate from the CBR reference rate by more than %s%%", h.reference.MaxDeviationPercent)
+		reqErr.Data = deviations
+		return nil, reqErr
+	}
+	return deviations, nil
+}
+
+// currencyResponse отправляет валюту; предупреждения об отклонении от официального курса — в поле warnings
+func currencyResponse(c *fiber.Ctx, status int, message string, currency sqlcgen.Currency, deviations []service.ReferenceRateDeviation) error {
+	body := fiber.Map{"status": "success", "message": message, "data": currency}
+	if len(deviations) > 0 {
+		body["warnings"] = deviations
+	}
+	return c.Status(status).JSON(body)
+}
+
+// Период истории курсов по умолчанию
+const defaultRateHistoryPeriod = 30 * 24 * time.Hour
+
+// recordRateChange добавляет текущие курсы валюты в историю курсов; reason — причина подтверждённого скачка курса
+func recordRateChange(c *fiber.Ctx, q sqlcgen.Querier, currency sqlcgen.Currency, source, reason string) error {
+	_, err := q.CreateCurrencyRateHistory(c.Context(), sqlcgen.CreateCurrencyRateHistoryParams{
+		CurrencyID: currency.ID,
+		BuyRate:    currency.BuyRate,
+		SellRate:   currency.SellRate,
+		Source:     source,
+		ChangedBy:  userIDParam(c),
+		Reason:     sql.NullString{String: reason, Valid: reason != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("could not record rate history: %w", err)
+	}
+	return nil
+}
+
+// GetCurrencies получает список валют с действующими официальными курсами ЦБ.
+// Параметр status: active — только валюты, которыми торгуют, inactive — приостановленные, all (по умолчанию) — все;
+// удалённые валюты в эти списки не входят и возвращаются только с status=deleted.
 func (h *CurrencyHandler) GetCurrencies(c *fiber.Ctx) error {
-	currencies, err := h.queries.ListCurrencies(c.Context())
+	filter := sqlcgen.ListCurrenciesByStatusParams{Deleted: sql.NullBool{Bool: false, Valid: true}}
+	switch status := strings.ToLower(c.Query("status", "all")); status {
+	case "all":
+	case "active":
+		filter.IsActive = sql.NullBool{Bool: true, Valid: true}
+	case "inactive":
+		filter.IsActive = sql.NullBool{Bool: false, Valid: true}
+	case "deleted":
+		filter.Deleted = sql.NullBool{Bool: true, Valid: true}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid status: must be active, inactive, deleted or all",
+			"data":    status,
+		})
+	}
+	currencies, err := h.store.ListCurrenciesByStatus(c.Context(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
@@ -25,20 +111,62 @@ func (h *CurrencyHandler) GetCurrencies(c *fiber.Ctx) error {
 			"data":    err.Error(),
 		})
 	}
+	today, _ := businessDay(time.Now(), h.location)
+	referenceRates, err := h.store.ListReferenceRates(c.Context(), today)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve reference rates",
+			"data":    err.Error(),
+		})
+	}
+	byCode := make(map[string]*sqlcgen.ReferenceRate, len(referenceRates))
+	for i := range referenceRates {
+		byCode[referenceRates[i].CurrencyCode] = &referenceRates[i]
+	}
+
+	result := make([]CurrencyResponse, 0, len(currencies))
+	for _, currency := range currencies {
+		result = append(result, CurrencyResponse{Currency: currency, ReferenceRate: byCode[currency.Code]})
+	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  "success",
 		"message": "Currencies retrieved successfully",
-		"data":    currencies,
+		"data":    result,
 	})
 }
 
+// CurrencyRoundingRequest — точность и способ округления сумм валюты
+type CurrencyRoundingRequest struct {
+	MinorUnits    *int32              `json:"minor_units"`    // По умолчанию 2
+	RoundingMode  string              `json:"rounding_mode"`  // HALF_EVEN (по умолчанию), DOWN или CASH
+	CashIncrement decimal.NullDecimal `json:"cash_increment"` // Наименьшая купюра или монета, обязательна для CASH
+}
+
+// validate подставляет значения по умолчанию и проверяет настройки через пакет money
+func (r *CurrencyRoundingRequest) validate() error {
+	if r.MinorUnits == nil {
+		defaultMinorUnits := money.RUB.MinorUnits
+		r.MinorUnits = &defaultMinorUnits
+	}
+	if r.RoundingMode == "" {
+		r.RoundingMode = string(money.RoundHalfEven)
+	}
+	if r.RoundingMode != string(money.RoundCash) {
+		r.CashIncrement = decimal.NullDecimal{}
+	}
+	_, err := money.NewPrecision(*r.MinorUnits, r.RoundingMode, r.CashIncrement)
+	return err
+}
+
 // CreateCurrency создаёт новую валюту
 func (h *CurrencyHandler) CreateCurrency(c *fiber.Ctx) error {
 	var req struct {
-		Code     string  `json:"code"`
-		Name     string  `json:"name"`
-		BuyRate  float64 `json:"buy_rate"`
-		SellRate float64 `json:"sell_rate"`
+		Code     string          `json:"code"`
+		Name     string          `json:"name"`
+		BuyRate  decimal.Decimal `json:"buy_rate"`
+		SellRate decimal.Decimal `json:"sell_rate"`
+		CurrencyRoundingRequest
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -46,33 +174,62 @@ func (h *CurrencyHandler) CreateCurrency(c *fiber.Ctx) error {
 			"message": "Invalid request body: " + err.Error(),
 		})
 	}
-
-	currency, err := h.queries.CreateCurrency(c.Context(), sqlcgen.CreateCurrencyParams{
-		Code:     req.Code,
-		Name:     req.Name,
-		BuyRate:  fmt.Sprintf("%.8f", req.BuyRate),
-		SellRate: fmt.Sprintf("%.8f", req.SellRate),
-	})
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	if err := req.CurrencyRoundingRequest.validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
-			"message": "Could not create currency",
+			"message": "Invalid rounding settings",
 			"data":    err.Error(),
 		})
 	}
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"status":  "success",
-		"message": "Currency created successfully",
-		"data":    currency,
+	buyRate, sellRate := money.RoundRate(req.BuyRate), money.RoundRate(req.SellRate)
+	if violations := service.ValidateRates(buyRate, sellRate); len(violations) > 0 {
+		return respondError(c, rateViolationError(violations), "Invalid rates")
+	}
+
+	var (
+		currency   sqlcgen.Currency
+		deviations []service.ReferenceRateDeviation
+	)
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		var err error
+		deviations, err = h.checkReferenceRate(c, q, req.Code, time.Now(), buyRate, sellRate)
+		if err != nil {
+			return err
+		}
+		currency, err = q.CreateCurrency(c.Context(), sqlcgen.CreateCurrencyParams{
+			Code:          req.Code,
+			Name:          req.Name,
+			BuyRate:       buyRate,
+			SellRate:      sellRate,
+			MinorUnits:    int16(*req.MinorUnits),
+			RoundingMode:  req.RoundingMode,
+			CashIncrement: req.CashIncrement,
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordRateChange(c, q, currency, service.RateSourceCreate, ""); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditCurrencyCreate, service.AuditEntityCurrency, currency.Code, nil, currency)
 	})
+	if err != nil {
+		return respondError(c, err, "Could not create currency")
+	}
+	h.broadcast.Publish(currency)
+	return currencyResponse(c, fiber.StatusCreated, "Currency created successfully", currency, deviations)
 }
 
-// UpdateCurrency обновляет курс валюты
+// UpdateCurrency обновляет курс валюты; прежний курс остаётся в истории курсов.
+// Курсы сверяются с официальным курсом ЦБ, см. checkReferenceRate. Скачок курса больше
+// RATE_MAX_JUMP_PERCENT проходит только с override и причиной override_reason.
 func (h *CurrencyHandler) UpdateCurrency(c *fiber.Ctx) error {
 	var req struct {
-		Code     string  `json:"code"`
-		BuyRate  float64 `json:"buy_rate"`
-		SellRate float64 `json:"sell_rate"`
+		Code           string          `json:"code"`
+		BuyRate        decimal.Decimal `json:"buy_rate"`
+		SellRate       decimal.Decimal `json:"sell_rate"`
+		Override       bool            `json:"override"`
+		OverrideReason string          `json:"override_reason"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -80,22 +237,193 @@ func (h *CurrencyHandler) UpdateCurrency(c *fiber.Ctx) error {
 			"message": "Invalid request body: " + err.Error(),
 		})
 	}
+	buyRate, sellRate := money.RoundRate(req.BuyRate), money.RoundRate(req.SellRate)
+	if violations := service.ValidateRates(buyRate, sellRate); len(violations) > 0 {
+		return respondError(c, rateViolationError(violations), "Invalid rates")
+	}
+	reason := strings.TrimSpace(req.OverrideReason)
+	if len(reason) > service.MaxRateChangeReasonLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("override_reason must be at most %d bytes", service.MaxRateChangeReasonLength),
+		})
+	}
+	if !req.Override {
+		reason = ""
+	}
 
-	currency, err := h.queries.UpdateCurrency(c.Context(), sqlcgen.UpdateCurrencyParams{
-		Code:     req.Code,
-		BuyRate:  fmt.Sprintf("%.8f", req.BuyRate),
-		SellRate: fmt.Sprintf("%.8f", req.SellRate),
+	var (
+		currency   sqlcgen.Currency
+		deviations []service.ReferenceRateDeviation
+	)
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetCurrencyByCode(c.Context(), req.Code)
+		if err != nil {
+			return err
+		}
+		if err := ensureCurrencyNotDeleted(before); err != nil {
+			return err
+		}
+		// Наступившие плановые изменения применяются раньше ручного, чтобы не перезаписать его
+		if before, err = currentCurrency(c.Context(), q, h.rates, before.ID); err != nil {
+			return err
+		}
+		if jumps := h.rateChange.CheckJump(before.BuyRate, before.SellRate, buyRate, sellRate); len(jumps) > 0 {
+			if !req.Override {
+				return rateViolationError(jumps)
+			}
+			if reason == "" {
+				return rateViolationError([]service.RateViolation{{
+					Field:   "override_reason",
+					Code:    service.RateViolationReasonRequired,
+					Message: "override_reason is required to confirm a rate jump",
+				}})
+			}
+		}
+		deviations, err = h.checkReferenceRate(c, q, before.Code, time.Now(), buyRate, sellRate)
+		if err != nil {
+			return err
+		}
+		currency, err = q.UpdateCurrency(c.Context(), sqlcgen.UpdateCurrencyParams{
+			Code:     req.Code,
+			BuyRate:  buyRate,
+			SellRate: sellRate,
+		})
+		if err != nil {
+			return err
+		}
+		if err := recordRateChange(c, q, currency, service.RateSourceManual, reason); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditCurrencyUpdate, service.AuditEntityCurrency, currency.Code, before, currency)
 	})
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Currency not found",
+			})
+		}
+		return respondError(c, err, "Could not update currency")
+	}
+	h.broadcast.Publish(currency)
+	return currencyResponse(c, fiber.StatusOK, "Currency updated successfully", currency, deviations)
+}
+
+// UpdateCurrencyRounding изменяет точность и способ округления сумм валюты.
+// Проведённые операции не пересчитываются.
+func (h *CurrencyHandler) UpdateCurrencyRounding(c *fiber.Ctx) error {
+	req := new(CurrencyRoundingRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body: " + err.Error(),
+		})
+	}
+	if err := req.validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
-			"message": "Could not update currency",
+			"message": "Invalid rounding settings",
 			"data":    err.Error(),
 		})
 	}
+
+	var currency sqlcgen.Currency
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetCurrencyByCode(c.Context(), c.Params("code"))
+		if err != nil {
+			return err
+		}
+		if err := ensureCurrencyNotDeleted(before); err != nil {
+			return err
+		}
+		currency, err = q.UpdateCurrencyRounding(c.Context(), sqlcgen.UpdateCurrencyRoundingParams{
+			Code:          before.Code,
+			MinorUnits:    int16(*req.MinorUnits),
+			RoundingMode:  req.RoundingMode,
+			CashIncrement: req.CashIncrement,
+		})
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditCurrencyRounding, service.AuditEntityCurrency, currency.Code, before, currency)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Currency not found",
+			})
+		}
+		return respondError(c, err, "Could not update currency rounding")
+	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  "success",
-		"message": "Currency updated successfully",
+		"message": "Currency rounding updated successfully",
 		"data":    currency,
 	})
 }
+
+// GetRateHistory возвращает историю курсов валюты.
+// С параметром at — курс, действовавший в этот момент; иначе — курсы, действовавшие в периоде
+// from–to (по умолчанию последние 30 дней), включая курс на начало периода.
+func (h *CurrencyHandler) GetRateHistory(c *fiber.Ctx) error {
+	currency, err := h.store.GetCurrencyByCode(c.Context(), strings.ToUpper(c.Params("code")))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Currency not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve currency", "data": err.Error()})
+	}
+
+	if v := c.Query("at"); v != "" {
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid at: expected RFC 3339 time", "data": v})
+		}
+		rate, err := h.store.GetCurrencyRateAt(c.Context(), sqlcgen.GetCurrencyRateAtParams{CurrencyID: currency.ID, At: at})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "No rate history for " + currency.Code + " at this time"})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve rate history", "data": err.Error()})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  "success",
+			"message": "Rate retrieved successfully",
+			"data":    fiber.Map{"currency_code": currency.Code, "at": at, "rate": rate},
+		})
+	}
+
+	from, err := parsePeriodBound(c.Query("from"), h.location, false)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid from", "data": err.Error()})
+	}
+	to, err := parsePeriodBound(c.Query("to"), h.location, true)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid to", "data": err.Error()})
+	}
+	if !to.Valid {
+		to = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	if !from.Valid {
+		from = sql.NullTime{Time: to.Time.Add(-defaultRateHistoryPeriod), Valid: true}
+	}
+	if !from.Time.Before(to.Time) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "from must be earlier than to"})
+	}
+
+	rates, err := h.store.ListCurrencyRateHistory(c.Context(), sqlcgen.ListCurrencyRateHistoryParams{
+		CurrencyID: currency.ID,
+		FromTime:   from.Time,
+		ToTime:     to.Time,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve rate history", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Rate history retrieved successfully",
+		"data":    fiber.Map{"currency_code": currency.Code, "from": from.Time, "to": to.Time, "rates": rates},
+	})
+}
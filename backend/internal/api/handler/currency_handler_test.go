@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// referenceRateQuerier возвращает один официальный курс; остальные методы Querier не вызываются
+type referenceRateQuerier struct {
+	sqlcgen.Querier
+	rate sqlcgen.ReferenceRate
+}
+
+func (q referenceRateQuerier) GetReferenceRate(ctx context.Context, arg sqlcgen.GetReferenceRateParams) (sqlcgen.ReferenceRate, error) {
+	return q.rate, nil
+}
+
+// В режиме WARN выход за коридор возвращается как предупреждение, в режиме REJECT — ошибка 422
+func TestCheckReferenceRateMode(t *testing.T) {
+	q := referenceRateQuerier{rate: sqlcgen.ReferenceRate{
+		CurrencyCode: "USD",
+		RateDate:     time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+		Rate:         decimal.NewFromInt(80),
+	}}
+	tests := []struct {
+		name         string
+		reject       bool
+		buy          string
+		wantStatus   int
+		wantWarnings int
+	}{
+		{"warn inside corridor", false, "85", fiber.StatusOK, 0},
+		{"warn outside corridor", false, "90", fiber.StatusOK, 1},
+		{"reject inside corridor", true, "85", fiber.StatusOK, 0},
+		{"reject outside corridor", true, "90", fiber.StatusUnprocessableEntity, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &CurrencyHandler{
+				location:  time.UTC,
+				reference: service.ReferenceRatePolicy{MaxDeviationPercent: decimal.NewFromInt(10), Reject: tt.reject},
+			}
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				deviations, err := h.checkReferenceRate(c, q, "USD", time.Now(), decimal.RequireFromString(tt.buy), decimal.NewFromInt(79))
+				if err != nil {
+					return respondError(c, err, "")
+				}
+				return c.JSON(fiber.Map{"warnings": deviations})
+			})
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			var body struct {
+				Warnings []service.ReferenceRateDeviation `json:"warnings"`
+				Data     []service.ReferenceRateDeviation `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Warnings) != tt.wantWarnings {
+				t.Errorf("%d warnings, want %d", len(body.Warnings), tt.wantWarnings)
+			}
+			if tt.wantStatus == fiber.StatusUnprocessableEntity && (len(body.Data) != 1 || body.Data[0].Side != "BUY") {
+				t.Errorf("rejection data %+v, want the BUY deviation", body.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Наибольшая длина причины приостановки торговли валютой
+const maxInactiveReasonLength = 255
+
+type DeactivateCurrencyRequest struct {
+	Reason string `json:"reason" validate:"required"` // Например, «техническое обслуживание» или «нет наличных»
+}
+
+// ensureCurrencyActive отказывает в новой операции или котировке по валюте, торговля которой приостановлена
+func ensureCurrencyActive(currency sqlcgen.Currency) error {
+	if currency.IsActive {
+		return nil
+	}
+	if currency.DeletedAt.Valid {
+		reqErr := newRequestError(fiber.StatusUnprocessableEntity, "Currency %s has been deleted", currency.Code)
+		reqErr.Data = fiber.Map{"currency_code": currency.Code, "deleted_at": currency.DeletedAt.Time}
+		return reqErr
+	}
+	reqErr := newRequestError(fiber.StatusUnprocessableEntity, "Trading in %s is suspended", currency.Code)
+	reqErr.Data = fiber.Map{"currency_code": currency.Code, "reason": currency.InactiveReason.String}
+	return reqErr
+}
+
+// ensureCurrencyNotDeleted отказывает в изменении удалённой валюты: её нельзя снова активировать,
+// менять ей курсы и правила округления
+func ensureCurrencyNotDeleted(currency sqlcgen.Currency) error {
+	if currency.DeletedAt.Valid {
+		return newRequestError(fiber.StatusConflict, "Currency %s has been deleted", currency.Code)
+	}
+	return nil
+}
+
+// DeactivateCurrency приостанавливает торговлю валютой. Валюта и её операции остаются в базе,
+// курсы можно менять, но новые операции, черновики и котировки не проводятся.
+func (h *CurrencyHandler) DeactivateCurrency(c *fiber.Ctx) error {
+	req := new(DeactivateCurrencyRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "reason is required"})
+	}
+	if len(reason) > maxInactiveReasonLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("reason must be at most %d bytes", maxInactiveReasonLength),
+		})
+	}
+	return h.setCurrencyActive(c, false, reason)
+}
+
+// ActivateCurrency возобновляет торговлю валютой
+func (h *CurrencyHandler) ActivateCurrency(c *fiber.Ctx) error {
+	return h.setCurrencyActive(c, true, "")
+}
+
+func (h *CurrencyHandler) setCurrencyActive(c *fiber.Ctx, active bool, reason string) error {
+	action := service.AuditCurrencyActivate
+	if !active {
+		action = service.AuditCurrencyDeactivate
+	}
+	var currency sqlcgen.Currency
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetCurrencyByCode(c.Context(), strings.ToUpper(c.Params("code")))
+		if err == sql.ErrNoRows {
+			return newRequestError(fiber.StatusNotFound, "Currency not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := ensureCurrencyNotDeleted(before); err != nil {
+			return err
+		}
+		currency, err = q.SetCurrencyActive(c.Context(), sqlcgen.SetCurrencyActiveParams{
+			IsActive:       active,
+			InactiveReason: sql.NullString{String: reason, Valid: reason != ""},
+			ChangedBy:      userIDParam(c),
+			Code:           before.Code,
+		})
+		if err != nil {
+			return err
+		}
+		return audit(c, q, action, service.AuditEntityCurrency, currency.Code, before, currency)
+	})
+	if err != nil {
+		return respondError(c, err, "Could not change currency status")
+	}
+	h.broadcast.Publish(currency)
+	message := "Currency activated successfully"
+	if !active {
+		message = "Currency deactivated successfully"
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": message, "data": currency})
+}
+
+// DeleteCurrency удаляет валюту. Строка валюты остаётся: на неё ссылаются операции и история курсов.
+// Удалённая валюта не торгуется, скрыта из списков валют и с табло, и её нельзя снова активировать.
+// Валюту с наличными в кассе удалить нельзя; её плановые изменения курса отменяются.
+func (h *CurrencyHandler) DeleteCurrency(c *fiber.Ctx) error {
+	var currency sqlcgen.Currency
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetCurrencyByCode(c.Context(), strings.ToUpper(c.Params("code")))
+		if err == sql.ErrNoRows {
+			return newRequestError(fiber.StatusNotFound, "Currency not found")
+		}
+		if err != nil {
+			return err
+		}
+		if err := ensureCurrencyNotDeleted(before); err != nil {
+			return err
+		}
+		balance, err := q.GetCashBalance(c.Context(), before.Code)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && !balance.Balance.IsZero() {
+			reqErr := newRequestError(fiber.StatusConflict, "Cash balance of %s is not zero", before.Code)
+			reqErr.Data = fiber.Map{"currency_code": before.Code, "balance": balance.Balance}
+			return reqErr
+		}
+
+		currency, err = q.DeleteCurrency(c.Context(), sqlcgen.DeleteCurrencyParams{
+			DeletedBy: userIDParam(c),
+			Code:      before.Code,
+		})
+		if err != nil {
+			return err
+		}
+		if err := audit(c, q, service.AuditCurrencyDelete, service.AuditEntityCurrency, currency.Code, before, currency); err != nil {
+			return err
+		}
+
+		pending, err := q.ListScheduledRateChanges(c.Context(), sqlcgen.ListScheduledRateChangesParams{
+			CurrencyID: currency.ID,
+			Status:     service.RateScheduleStatusPending,
+		})
+		if err != nil {
+			return err
+		}
+		for _, before := range pending {
+			schedule, err := q.CancelScheduledRateChange(c.Context(), sqlcgen.CancelScheduledRateChangeParams{
+				ID:          before.ID,
+				CurrencyID:  currency.ID,
+				CancelledBy: userIDParam(c),
+			})
+			if err != nil {
+				return err
+			}
+			if err := audit(c, q, service.AuditRateScheduleCancel, service.AuditEntityRateSchedule, schedule.ID, before, schedule); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return respondError(c, err, "Could not delete currency")
+	}
+	h.broadcast.Publish(currency)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Currency deleted successfully", "data": currency})
+}
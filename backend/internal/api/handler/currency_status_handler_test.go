@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/postgresql/pgtest"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestEnsureCurrencyActiveDeleted(t *testing.T) {
+	deleted := sqlcgen.Currency{Code: "EUR", DeletedAt: sql.NullTime{Time: time.Now(), Valid: true}}
+	err := ensureCurrencyActive(deleted)
+	reqErr, ok := err.(*requestError)
+	if !ok || reqErr.Status != fiber.StatusUnprocessableEntity || reqErr.Message != "Currency EUR has been deleted" {
+		t.Errorf("ensureCurrencyActive = %v, want 422 for a deleted currency", err)
+	}
+	if err := ensureCurrencyNotDeleted(deleted); err == nil {
+		t.Error("ensureCurrencyNotDeleted accepted a deleted currency")
+	}
+	if err := ensureCurrencyNotDeleted(sqlcgen.Currency{Code: "USD"}); err != nil {
+		t.Errorf("ensureCurrencyNotDeleted(USD) = %v", err)
+	}
+}
+
+// Удалённая валюта скрыта из списков, не активируется снова, а её плановые изменения курса отменены.
+// Валюту с наличными в кассе удалить нельзя.
+func TestDeleteCurrency(t *testing.T) {
+	store := postgresql.NewStore(pgtest.NewDB(t))
+	ctx := context.Background()
+
+	supervisor, err := store.CreateUser(ctx, sqlcgen.CreateUserParams{Username: "supervisor", PasswordHash: "-", FullName: "Старший кассир", Role: service.RoleSupervisor})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	eur, err := store.GetCurrencyByCode(ctx, "EUR")
+	if err != nil {
+		t.Fatalf("load EUR: %v", err)
+	}
+	schedule, err := store.CreateScheduledRateChange(ctx, sqlcgen.CreateScheduledRateChangeParams{
+		CurrencyID:  eur.ID,
+		BuyRate:     decimal.NewFromInt(99),
+		SellRate:    decimal.NewFromInt(98),
+		EffectiveAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("schedule rate change: %v", err)
+	}
+	if _, err := store.ChangeCashBalance(ctx, sqlcgen.ChangeCashBalanceParams{CurrencyCode: "USD", Amount: decimal.NewFromInt(100)}); err != nil {
+		t.Fatalf("fund cash desk: %v", err)
+	}
+
+	broadcast := service.NewRateBroadcaster()
+	rates := service.NewRateScheduler(service.RateChangePolicy{}, service.ReferenceRatePolicy{}, time.UTC, broadcast)
+	h := NewCurrencyHandler(store, time.UTC, service.ReferenceRatePolicy{}, service.RateChangePolicy{}, rates, broadcast)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		middleware.SetCurrentUser(c, supervisor)
+		return c.Next()
+	})
+	app.Get("/currencies", h.GetCurrencies)
+	app.Post("/currencies/:code/activate", h.ActivateCurrency)
+	app.Delete("/currencies/:code", h.DeleteCurrency)
+
+	request := func(method, target string) (int, []sqlcgen.Currency) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(method, target, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("%s %s: %v", method, target, err)
+		}
+		var currencies []sqlcgen.Currency
+		if method == fiber.MethodGet {
+			if err := json.Unmarshal(body.Data, &currencies); err != nil {
+				t.Fatalf("%s %s: %v", method, target, err)
+			}
+		}
+		return resp.StatusCode, currencies
+	}
+	codes := func(currencies []sqlcgen.Currency) map[string]bool {
+		found := make(map[string]bool, len(currencies))
+		for _, currency := range currencies {
+			found[currency.Code] = true
+		}
+		return found
+	}
+
+	if status, _ := request(fiber.MethodDelete, "/currencies/eur"); status != fiber.StatusOK {
+		t.Fatalf("delete EUR: status %d", status)
+	}
+	deleted, err := store.GetCurrencyByCode(ctx, "EUR")
+	if err != nil {
+		t.Fatalf("load EUR: %v", err)
+	}
+	if !deleted.DeletedAt.Valid || deleted.IsActive || deleted.DeletedBy.Int32 != supervisor.ID {
+		t.Errorf("EUR after delete: deleted_at %v, is_active %v, deleted_by %v", deleted.DeletedAt, deleted.IsActive, deleted.DeletedBy)
+	}
+
+	if _, all := request(fiber.MethodGet, "/currencies"); codes(all)["EUR"] || !codes(all)["USD"] {
+		t.Errorf("GET /currencies: %v, want USD without EUR", codes(all))
+	}
+	if _, inactive := request(fiber.MethodGet, "/currencies?status=inactive"); codes(inactive)["EUR"] {
+		t.Error("GET /currencies?status=inactive lists deleted EUR")
+	}
+	if _, removed := request(fiber.MethodGet, "/currencies?status=deleted"); len(removed) != 1 || removed[0].Code != "EUR" {
+		t.Errorf("GET /currencies?status=deleted: %v, want EUR", codes(removed))
+	}
+	listed, err := store.ListCurrencies(ctx)
+	if err != nil {
+		t.Fatalf("ListCurrencies: %v", err)
+	}
+	if codes(listed)["EUR"] {
+		t.Error("ListCurrencies lists deleted EUR")
+	}
+
+	if status, _ := request(fiber.MethodPost, "/currencies/EUR/activate"); status != fiber.StatusConflict {
+		t.Errorf("activate deleted EUR: status %d, want %d", status, fiber.StatusConflict)
+	}
+	if status, _ := request(fiber.MethodDelete, "/currencies/EUR"); status != fiber.StatusConflict {
+		t.Errorf("delete EUR again: status %d, want %d", status, fiber.StatusConflict)
+	}
+	cancelled, err := store.GetScheduledRateChange(ctx, schedule.ID)
+	if err != nil {
+		t.Fatalf("load scheduled rate change: %v", err)
+	}
+	if cancelled.Status != service.RateScheduleStatusCancelled {
+		t.Errorf("scheduled rate change status %s, want %s", cancelled.Status, service.RateScheduleStatusCancelled)
+	}
+
+	if status, _ := request(fiber.MethodDelete, "/currencies/USD"); status != fiber.StatusConflict {
+		t.Errorf("delete USD with cash: status %d, want %d", status, fiber.StatusConflict)
+	}
+	if status, _ := request(fiber.MethodDelete, "/currencies/XYZ"); status != fiber.StatusNotFound {
+		t.Errorf("delete unknown currency: status %d, want %d", status, fiber.StatusNotFound)
+	}
+
+	report, err := service.VerifyAuditChain(ctx, store)
+	if err != nil || !report.Valid {
+		t.Errorf("audit chain: %+v, %v", report, err)
+	}
+}
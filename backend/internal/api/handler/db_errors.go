@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Коды ошибок PostgreSQL, которые обрабатываются отдельно
+const (
+	pgUniqueViolation = "23505"
+)
+
+// isUniqueViolation сообщает, нарушено ли ограничение UNIQUE
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pgUniqueViolation
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"exchange_point/backend/internal/money"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// operationFee — комиссия операции в рублях
+type operationFee struct {
+	FixedRub   decimal.Decimal
+	PercentRub decimal.Decimal
+	TotalRub   decimal.Decimal
+	RuleID     sql.NullInt32 // Применённое правило из operation_fees
+}
+
+// calculateFee рассчитывает комиссию за операцию на сумму baseRub по правилам operation_fees
+func calculateFee(ctx context.Context, q sqlcgen.Querier, currencyID int32, operationType string, baseRub decimal.Decimal) (operationFee, error) {
+	var fee operationFee
+
+	fees, err := q.ListOperationFees(ctx)
+	if err != nil {
+		return fee, fmt.Errorf("could not load operation fees: %w", err)
+	}
+	rule, ok := service.ResolveOperationFee(fees, currencyID, operationType, baseRub)
+	if !ok {
+		return fee, nil
+	}
+
+	fee.FixedRub = money.RUB.Round(rule.FixedFeeRub)
+	fee.PercentRub = money.RUB.Round(baseRub.Mul(rule.PercentFee).Div(decimal.NewFromInt(100)))
+	fee.TotalRub = fee.FixedRub.Add(fee.PercentRub)
+	fee.RuleID = sql.NullInt32{Int32: rule.ID, Valid: true}
+	return fee, nil
+}
+
+// ensureCurrentFee проверяет в транзакции записи операции, что комиссия fee рассчитана по правилам,
+// действующим на время записи: правило могли изменить или удалить между расчётом и записью.
+// Правила читаются в той же сериализуемой транзакции, поэтому их изменение до коммита приведёт к её повтору.
+func ensureCurrentFee(ctx context.Context, q sqlcgen.Querier, currencyID int32, operationType string, baseRub decimal.Decimal, fee operationFee) error {
+	current, err := calculateFee(ctx, q, currencyID, operationType, baseRub)
+	if err != nil {
+		return err
+	}
+	if current.RuleID != fee.RuleID || !current.TotalRub.Equal(fee.TotalRub) ||
+		!current.FixedRub.Equal(fee.FixedRub) || !current.PercentRub.Equal(fee.PercentRub) {
+		return newRequestError(fiber.StatusConflict, "Operation fees have changed, recalculate the operation")
+	}
+	return nil
+}
+
+// spreadRub — доход пункта обмена от разницы курса операции и среднего курса (buy_rate + sell_rate) / 2
+func spreadRub(calc operationCalculation, operationType string) decimal.Decimal {
+	midRate := calc.Currency.BuyRate.Add(calc.Currency.SellRate).Div(decimal.NewFromInt(2))
+	atMid := calc.AmountCurrency.Mul(midRate)
+
+	// Клиент продаёт: пункт платит меньше, чем по среднему курсу; покупает — получает больше
+	if operationType == service.OperationClientSells {
+		return money.RUB.Round(atMid.Sub(calc.AmountRub))
+	}
+	return money.RUB.Round(calc.AmountRub.Sub(atMid))
+}
+
+// applyFee добавляет к расчёту комиссию и доход от спреда.
+// Комиссия взимается сверх суммы обмена: при продаже валюты удерживается из выплаты, при покупке доплачивается.
+func applyFee(calc *operationCalculation, operationType string, fee operationFee) error {
+	if operationType == service.OperationClientSells && fee.TotalRub.Cmp(calc.AmountRub) >= 0 {
+		return newRequestError(fiber.StatusBadRequest, "Fee %s RUB is not less than the operation amount %s RUB",
+			money.RUB.Format(fee.TotalRub), money.RUB.Format(calc.AmountRub))
+	}
+	calc.Fee = fee
+	calc.SpreadRub = spreadRub(*calc, operationType)
+	return nil
+}
@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+type OperationFeeHandler struct {
+	store postgresql.Store
+}
+
+func NewOperationFeeHandler(store postgresql.Store) *OperationFeeHandler {
+	return &OperationFeeHandler{store: store}
+}
+
+// Верхняя граница сумм в формате DECIMAL(19,4)
+var maxFeeAmount = decimal.New(1, 15)
+
+type CreateOperationFeeRequest struct {
+	CurrencyID    *int32          `json:"currency_id"`    // Не указана — для всех валют
+	OperationType string          `json:"operation_type"` // Не указан — для всех типов операций
+	MinAmountRub  decimal.Decimal `json:"min_amount_rub"` // Порог ступени, по умолчанию 0
+	FixedFeeRub   decimal.Decimal `json:"fixed_fee_rub"`
+	PercentFee    decimal.Decimal `json:"percent_fee"`
+	Description   string          `json:"description"`
+}
+
+type UpdateOperationFeeRequest struct {
+	FixedFeeRub decimal.Decimal `json:"fixed_fee_rub"`
+	PercentFee  decimal.Decimal `json:"percent_fee"`
+	Description *string         `json:"description"`
+}
+
+// validateFeeAmount проверяет, что сумма неотрицательна и укладывается в DECIMAL(19,4)
+func validateFeeAmount(name string, value decimal.Decimal) error {
+	if value.Sign() < 0 || value.GreaterThanOrEqual(maxFeeAmount) || !value.Equal(value.Truncate(4)) {
+		return fmt.Errorf("%s must be a non-negative decimal with up to 4 fractional digits", name)
+	}
+	return nil
+}
+
+// validateFeeValues проверяет размер комиссии
+func validateFeeValues(fixedFeeRub, percentFee decimal.Decimal) error {
+	if err := validateFeeAmount("fixed_fee_rub", fixedFeeRub); err != nil {
+		return err
+	}
+	if percentFee.Sign() < 0 || !percentFee.Equal(percentFee.Truncate(4)) {
+		return fmt.Errorf("percent_fee must be a non-negative decimal with up to 4 fractional digits")
+	}
+	if percentFee.GreaterThan(decimal.NewFromInt(100)) {
+		return fmt.Errorf("percent_fee must not exceed 100")
+	}
+	return nil
+}
+
+func parseFeeID(c *fiber.Ctx) (int32, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	return int32(id), err
+}
+
+// GetFees возвращает все правила комиссий
+func (h *OperationFeeHandler) GetFees(c *fiber.Ctx) error {
+	fees, err := h.store.ListOperationFees(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve operation fees",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation fees retrieved successfully",
+		"data":    fees,
+	})
+}
+
+// GetFee возвращает правило комиссии по идентификатору
+func (h *OperationFeeHandler) GetFee(c *fiber.Ctx) error {
+	id, err := parseFeeID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid fee ID format"})
+	}
+	fee, err := h.store.GetOperationFee(c.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation fee not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve operation fee",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation fee retrieved successfully",
+		"data":    fee,
+	})
+}
+
+// CreateFee создаёт правило комиссии
+func (h *OperationFeeHandler) CreateFee(c *fiber.Ctx) error {
+	req := new(CreateOperationFeeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body: " + err.Error(),
+		})
+	}
+
+	if err := validateFeeAmount("min_amount_rub", req.MinAmountRub); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid min_amount_rub",
+			"data":    err.Error(),
+		})
+	}
+	if err := validateFeeValues(req.FixedFeeRub, req.PercentFee); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid fee value",
+			"data":    err.Error(),
+		})
+	}
+
+	params := sqlcgen.CreateOperationFeeParams{
+		MinAmountRub: req.MinAmountRub,
+		FixedFeeRub:  req.FixedFeeRub,
+		PercentFee:   req.PercentFee,
+	}
+	if req.CurrencyID != nil {
+		if _, err := h.store.GetCurrency(c.Context(), *req.CurrencyID); err != nil {
+			if err == sql.ErrNoRows {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Currency not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Could not create operation fee",
+				"data":    err.Error(),
+			})
+		}
+		params.CurrencyID = sql.NullInt32{Int32: *req.CurrencyID, Valid: true}
+	}
+	if req.OperationType != "" {
+		if req.OperationType != service.OperationClientSells && req.OperationType != service.OperationClientBuys {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid operation_type",
+				"data":    req.OperationType,
+			})
+		}
+		params.OperationType = sql.NullString{String: req.OperationType, Valid: true}
+	}
+	if req.Description != "" {
+		params.Description = sql.NullString{String: req.Description, Valid: true}
+	}
+
+	var fee sqlcgen.OperationFee
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		var err error
+		fee, err = q.CreateOperationFee(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditFeeCreate, service.AuditEntityOperationFee, fee.ID, nil, fee)
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation fee with this scope and min_amount_rub already exists",
+			})
+		}
+		log.Printf("Error creating operation fee: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not create operation fee",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation fee created successfully",
+		"data":    fee,
+	})
+}
+
+// UpdateFee изменяет размер комиссии
+func (h *OperationFeeHandler) UpdateFee(c *fiber.Ctx) error {
+	id, err := parseFeeID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid fee ID format"})
+	}
+	req := new(UpdateOperationFeeRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body: " + err.Error(),
+		})
+	}
+	if err := validateFeeValues(req.FixedFeeRub, req.PercentFee); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid fee value",
+			"data":    err.Error(),
+		})
+	}
+
+	params := sqlcgen.UpdateOperationFeeParams{
+		ID:          id,
+		FixedFeeRub: req.FixedFeeRub,
+		PercentFee:  req.PercentFee,
+	}
+	if req.Description != nil {
+		params.Description = sql.NullString{String: *req.Description, Valid: true}
+	}
+
+	var fee sqlcgen.OperationFee
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetOperationFee(c.Context(), id)
+		if err != nil {
+			return err
+		}
+		fee, err = q.UpdateOperationFee(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditFeeUpdate, service.AuditEntityOperationFee, id, before, fee)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation fee not found",
+			})
+		}
+		log.Printf("Error updating operation fee: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not update operation fee",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation fee updated successfully",
+		"data":    fee,
+	})
+}
+
+// DeleteFee удаляет правило комиссии; в проведённых операциях комиссия сохраняется
+func (h *OperationFeeHandler) DeleteFee(c *fiber.Ctx) error {
+	id, err := parseFeeID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid fee ID format"})
+	}
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetOperationFee(c.Context(), id)
+		if err != nil {
+			return err
+		}
+		if _, err := q.DeleteOperationFee(c.Context(), id); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditFeeDelete, service.AuditEntityOperationFee, id, before, nil)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation fee not found",
+			})
+		}
+		log.Printf("Error deleting operation fee: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not delete operation fee",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation fee deleted successfully",
+	})
+}
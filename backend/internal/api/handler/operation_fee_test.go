@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// operationFeesQuerier возвращает заданные правила комиссий; остальные методы Querier не вызываются
+type operationFeesQuerier struct {
+	sqlcgen.Querier
+	fees []sqlcgen.OperationFee
+}
+
+func (q operationFeesQuerier) ListOperationFees(ctx context.Context) ([]sqlcgen.OperationFee, error) {
+	return q.fees, nil
+}
+
+// Комиссия, рассчитанная до транзакции, отклоняется с 409, если правило изменили или удалили до записи операции
+func TestEnsureCurrentFee(t *testing.T) {
+	rule := sqlcgen.OperationFee{
+		ID:           1,
+		CurrencyID:   sql.NullInt32{Int32: 2, Valid: true},
+		FixedFeeRub:  decimal.NewFromInt(100),
+		PercentFee:   decimal.RequireFromString("0.5"),
+		MinAmountRub: decimal.Zero,
+	}
+	base := decimal.NewFromInt(10_000)
+	calculated, err := calculateFee(context.Background(), operationFeesQuerier{fees: []sqlcgen.OperationFee{rule}}, 2, service.OperationClientBuys, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := rule
+	changed.PercentFee = decimal.NewFromInt(1)
+	tests := []struct {
+		name       string
+		fees       []sqlcgen.OperationFee
+		wantStatus int
+	}{
+		{name: "unchanged", fees: []sqlcgen.OperationFee{rule}},
+		{name: "rule changed", fees: []sqlcgen.OperationFee{changed}, wantStatus: fiber.StatusConflict},
+		{name: "rule deleted", fees: nil, wantStatus: fiber.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ensureCurrentFee(context.Background(), operationFeesQuerier{fees: tt.fees}, 2, service.OperationClientBuys, base, calculated)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var reqErr *requestError
+			if !errors.As(err, &reqErr) || reqErr.Status != tt.wantStatus {
+				t.Fatalf("error %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
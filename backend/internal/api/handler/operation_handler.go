@@ -1,157 +1,535 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"exchange_point/backend/internal/money"
+	"exchange_point/backend/internal/repository/postgresql"
 	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
 	"fmt"
 	"log"
-	"math/big"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
 )
 
 type OperationHandler struct {
-	queries sqlcgen.Querier
+	store    postgresql.Store
+	receipts *service.ReceiptNumbering // Нумерация чеков
+	location *time.Location            // Часовой пояс пункта обмена для границ дня
+	draftTTL time.Duration             // Время жизни черновика до подтверждения
+	rates    *service.RateScheduler    // Плановые изменения курсов: применяются до расчёта операции
 }
 
-func NewOperationHandler(q sqlcgen.Querier) *OperationHandler {
-	return &OperationHandler{queries: q}
+func NewOperationHandler(store postgresql.Store, receipts *service.ReceiptNumbering, location *time.Location, draftTTL time.Duration, rates *service.RateScheduler) *OperationHandler {
+	return &OperationHandler{store: store, receipts: receipts, location: location, draftTTL: draftTTL, rates: rates}
 }
 
 type CreateOperationRequest struct {
-	ClientID      int32  `json:"client_id" validate:"required"`
-	OperationType string `json:"operation_type" validate:"required,oneof=CLIENT_SELLS_TO_EXCHANGE CLIENT_BUYS_FROM_EXCHANGE"`
-	CurrencyID    int32  `json:"currency_id" validate:"required"`
-	Amount        string `json:"amount" validate:"required,gt=0"`
-	DailyLimit    string `json:"daily_currency_volume" validate:"required,gt=0"`   // Новый параметр
-	SingleLimit   string `json:"single_operation_amount" validate:"required,gt=0"` // Новый параметр
+	ClientID      int32           `json:"client_id" validate:"required"`
+	OperationType string          `json:"operation_type" validate:"required,oneof=CLIENT_SELLS_TO_EXCHANGE CLIENT_BUYS_FROM_EXCHANGE CROSS_CURRENCY_EXCHANGE"`
+	CurrencyID    int32           `json:"currency_id" validate:"required"`
+	Amount        decimal.Decimal `json:"amount" validate:"required,gt=0"`
+	QuoteID       string          `json:"quote_id"` // Котировка с зафиксированным курсом (POST /quotes)
+	// Для CROSS_CURRENCY_EXCHANGE: валюта, которую получает клиент; currency_id и amount — отдаваемая валюта и сумма
+	TargetCurrencyID int32 `json:"target_currency_id"`
+	// Лимиты берутся только из таблицы operation_limits, переопределять их в запросе нельзя
+	DailyLimit  json.RawMessage `json:"daily_currency_volume,omitempty"`
+	SingleLimit json.RawMessage `json:"single_operation_amount,omitempty"`
 }
 
-func toBigFloat(s string) (*big.Float, error) {
-	f, _, err := big.ParseFloat(s, 10, 256, big.ToNearestEven)
+// businessDay возвращает границы бизнес-дня [start, end), в который попадает t
+func businessDay(t time.Time, location *time.Location) (time.Time, time.Time) {
+	local := t.In(location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// checkOperationLimits проверяет лимит одной операции и дневной лимит клиента по валюте.
+// Вызывается в транзакции, начатой под блокировкой postgresql.ClientCurrencyLock: параллельные операции
+// того же клиента по той же валюте ждут её завершения и видят записанную операцию в дневном объёме.
+func (h *OperationHandler) checkOperationLimits(ctx context.Context, q sqlcgen.Querier, clientID int32, currency sqlcgen.Currency, operationType string, amountCurrency decimal.Decimal) error {
+	// Загрузка лимитов для валюты и типа операции
+	limits, err := q.ListOperationLimits(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse '%s' to big.Float: %w", s, err)
+		return fmt.Errorf("could not load operation limits: %w", err)
+	}
+	singleLimit, ok := service.ResolveOperationLimit(limits, service.LimitSingleOperationAmount, currency.Code, operationType)
+	if !ok {
+		return fmt.Errorf("operation limit '%s' is not configured", service.LimitSingleOperationAmount)
+	}
+	dailyLimit, ok := service.ResolveOperationLimit(limits, service.LimitDailyCurrencyVolume, currency.Code, operationType)
+	if !ok {
+		return fmt.Errorf("operation limit '%s' is not configured", service.LimitDailyCurrencyVolume)
 	}
-	return f, nil
-}
 
-func (h *OperationHandler) CreateOperation(c *fiber.Ctx) error {
-	req := new(CreateOperationRequest)
-	if err := c.BodyParser(req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	// Проверка лимита на сумму одной операции
+	if amountCurrency.Cmp(singleLimit.LimitValue) > 0 {
+		return newRequestError(fiber.StatusForbidden, "Single operation amount exceeds limit. Current limit: %s, requested: %s %s",
+			singleLimit.LimitValue.StringFixed(4), amountCurrency.StringFixed(4), currency.Code)
 	}
 
-	// Получить данные по валюте
-	currencyDB, err := h.queries.GetCurrency(c.Context(), req.CurrencyID)
+	// Проверка дневного лимита по объёму за текущий бизнес-день
+	dayStart, dayEnd := businessDay(time.Now(), h.location)
+	currentVolume, err := q.GetDailyClientForeignCurrencyVolume(ctx, sqlcgen.GetDailyClientForeignCurrencyVolumeParams{
+		ClientID:          clientID,
+		ForeignCurrencyID: currency.ID,
+		DayStart:          dayStart,
+		DayEnd:            dayEnd,
+	})
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Currency not found", "data": err.Error()})
+		return fmt.Errorf("could not fetch daily volume: %w", err)
 	}
 
-	if currencyDB.Code == "RUB" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Operations with RUB as the selected currency are not allowed"})
+	totalVolume := currentVolume.Add(amountCurrency)
+	if totalVolume.Cmp(dailyLimit.LimitValue) > 0 {
+		return newRequestError(fiber.StatusForbidden, "Daily limit of %s units for foreign currency %s exceeded. Current today: %s, this op: %s",
+			dailyLimit.LimitValue.StringFixed(2), currency.Code, currentVolume.StringFixed(2), amountCurrency.StringFixed(2))
 	}
+	return nil
+}
 
-	// Конвертировать входные данные в big.Float
-	amountBig, err := toBigFloat(req.Amount)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid amount format", "data": err.Error()})
+// nextReceiptNumber выдаёт номер чека завершаемой операции. Занятые номера пропускаются;
+// если занято слишком много номеров подряд, операция не проводится, пока расхождение не разобрано.
+func (h *OperationHandler) nextReceiptNumber(ctx context.Context, q sqlcgen.Querier, operationType string) (*string, error) {
+	reference, err := h.receipts.Next(ctx, q, operationType)
+	if errors.Is(err, service.ErrReceiptNumberTaken) {
+		return nil, newRequestError(fiber.StatusConflict, "Could not issue receipt number: %v", err)
 	}
-	buyRateBig, err := toBigFloat(currencyDB.BuyRate)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Invalid buy_rate format in DB", "data": err.Error()})
+		return nil, err
 	}
-	sellRateBig, err := toBigFloat(currencyDB.SellRate)
+	return &reference, nil
+}
+
+// operationCalculation — рассчитанные суммы и курс операции
+type operationCalculation struct {
+	Currency       sqlcgen.Currency
+	Precision      money.Precision // Точность и округление сумм в валюте операции
+	AmountCurrency decimal.Decimal
+	AmountRub      decimal.Decimal
+	EffectiveRate  decimal.Decimal
+	QuoteID        string          // Котировка, по которой зафиксирован курс (если есть)
+	Fee            operationFee    // Комиссия сверх суммы обмена
+	SpreadRub      decimal.Decimal // Доход от спреда относительно среднего курса
+}
+
+// currencyPrecision возвращает правила округления сумм валюты
+func currencyPrecision(currency sqlcgen.Currency) (money.Precision, error) {
+	precision, err := money.NewPrecision(int32(currency.MinorUnits), currency.RoundingMode, currency.CashIncrement)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Invalid sell_rate format in DB", "data": err.Error()})
+		return money.Precision{}, fmt.Errorf("invalid rounding settings of currency %s: %w", currency.Code, err)
 	}
-	dailyLimitBig, err := toBigFloat(req.DailyLimit)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid daily limit format", "data": err.Error()})
+	return precision, nil
+}
+
+// calculateExchange рассчитывает суммы операции по текущему курсу валюты.
+// Сумма в валюте округляется по правилам валюты до того, что можно выдать наличными,
+// рублёвая сумма пересчитывается от неё и округляется до копеек.
+func calculateExchange(currency sqlcgen.Currency, operationType string, amount decimal.Decimal) (operationCalculation, error) {
+	if currency.Code == "RUB" {
+		return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Operations with RUB as the selected currency are not allowed")
 	}
-	singleLimitBig, err := toBigFloat(req.SingleLimit)
+
+	precision, err := currencyPrecision(currency)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid single operation limit format", "data": err.Error()})
+		return operationCalculation{}, err
+	}
+
+	if amount.Sign() <= 0 {
+		return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Amount must be greater than zero")
 	}
 
 	// Расчёт операции
-	var amountCurrencyBig, amountRubBig, effectiveRateBig *big.Float
-	if req.OperationType == "CLIENT_SELLS_TO_EXCHANGE" {
-		amountCurrencyBig = amountBig
-		effectiveRateBig = sellRateBig
-		amountRubBig = new(big.Float).Mul(amountCurrencyBig, effectiveRateBig)
-	} else if req.OperationType == "CLIENT_BUYS_FROM_EXCHANGE" {
-		amountRubBig = amountBig
-		effectiveRateBig = buyRateBig
-		amountCurrencyBig = new(big.Float).Quo(amountRubBig, effectiveRateBig)
+	calc := operationCalculation{Currency: currency, Precision: precision}
+	if operationType == service.OperationClientSells {
+		// Клиент сдаёт наличные: сумма должна быть представима в валюте
+		if !precision.IsRounded(amount) {
+			return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Amount in %s must have %s", currency.Code, precision.Describe())
+		}
+		calc.AmountCurrency = amount
+		calc.EffectiveRate = currency.SellRate
+		calc.AmountRub = money.RUB.Round(calc.AmountCurrency.Mul(calc.EffectiveRate))
+	} else if operationType == service.OperationClientBuys {
+		if !money.RUB.IsRounded(amount) {
+			return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Amount in RUB must have %s", money.RUB.Describe())
+		}
+		// Клиент получает сумму, которую можно выдать, и платит рубли только за неё
+		calc.EffectiveRate = currency.BuyRate
+		calc.AmountCurrency = precision.Round(money.Quo(amount, calc.EffectiveRate))
+		calc.AmountRub = money.RUB.Round(calc.AmountCurrency.Mul(calc.EffectiveRate))
+		// Округление вверх не должно требовать от клиента больше запрошенной суммы
+		if calc.AmountRub.Cmp(amount) > 0 {
+			calc.AmountCurrency = precision.RoundDown(money.Quo(amount, calc.EffectiveRate))
+			calc.AmountRub = money.RUB.Round(calc.AmountCurrency.Mul(calc.EffectiveRate))
+		}
+		if calc.AmountCurrency.Sign() <= 0 {
+			return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Amount is too small to buy %s (%s)", currency.Code, precision.Describe())
+		}
 	} else {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid operation type"})
+		return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Invalid operation type")
 	}
+	return calc, nil
+}
 
-	// Проверка лимита на сумму одной операции
-	if amountCurrencyBig.Cmp(singleLimitBig) > 0 {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"status": "error",
-			"message": fmt.Sprintf("Single operation amount exceeds limit. Current limit: %s, requested: %s %s",
-				singleLimitBig.Text('f', 4), amountCurrencyBig.Text('f', 4), currencyDB.Code),
-		})
-	}
-
-	// Проверка дневного лимита через аналитику
-	today := time.Now().Truncate(24 * time.Hour)
-	sqlParams := sqlcgen.GetOperationsForAnalyticsParams{
-		StartDate: today,
-		EndDate:   today.Add(24 * time.Hour).Add(-time.Nanosecond),
-	}
-	operations, err := h.queries.GetOperationsForAnalytics(c.Context(), sqlParams)
-	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Error fetching operations for analytics: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch analytics data", "data": err.Error()})
-	}
-
-	// Вычисляем текущий дневной объём для клиента и валюты
-	currentVolumeBig := big.NewFloat(0)
-	for _, op := range operations {
-		if op.ClientID == req.ClientID && op.CurrencyID == req.CurrencyID {
-			vol, err := toBigFloat(op.AmountCurrency)
-			if err != nil {
-				log.Printf("Error converting operation volume: %v", err)
-				continue
-			}
-			currentVolumeBig.Add(currentVolumeBig, vol)
+// calculateOperation проверяет запрос и рассчитывает суммы и комиссию операции:
+// по котировке, если передан quote_id, иначе по текущему курсу валюты
+func (h *OperationHandler) calculateOperation(ctx context.Context, req *CreateOperationRequest) (operationCalculation, error) {
+	if len(req.DailyLimit) > 0 || len(req.SingleLimit) > 0 {
+		return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Operation limits cannot be overridden by the request")
+	}
+
+	var calc operationCalculation
+	if req.QuoteID != "" {
+		var err error
+		calc, err = h.calculateFromQuote(ctx, req)
+		if err != nil {
+			return operationCalculation{}, err
+		}
+	} else {
+		// Получить данные по валюте с курсом, действующим сейчас
+		if err := h.rates.Refresh(ctx, h.store, req.CurrencyID); err != nil {
+			return operationCalculation{}, err
 		}
+		currencyDB, err := h.store.GetCurrency(ctx, req.CurrencyID)
+		if err != nil {
+			return operationCalculation{}, &requestError{Status: fiber.StatusNotFound, Message: "Currency not found", Data: err.Error()}
+		}
+		if err := ensureCurrencyActive(currencyDB); err != nil {
+			return operationCalculation{}, err
+		}
+		calc, err = calculateExchange(currencyDB, req.OperationType, req.Amount)
+		if err != nil {
+			return operationCalculation{}, err
+		}
+	}
+
+	// Комиссия по действующим правилам; котировка фиксирует только курс и суммы обмена
+	fee, err := calculateFee(ctx, h.store, calc.Currency.ID, req.OperationType, calc.AmountRub)
+	if err != nil {
+		return operationCalculation{}, err
+	}
+	if err := applyFee(&calc, req.OperationType, fee); err != nil {
+		return operationCalculation{}, err
+	}
+	return calc, nil
+}
+
+// calculateFromQuote берёт суммы и курс из котировки без пересчёта.
+// Окончательно котировка помечается использованной в транзакции создания операции (useQuote).
+func (h *OperationHandler) calculateFromQuote(ctx context.Context, req *CreateOperationRequest) (operationCalculation, error) {
+	quote, err := h.store.GetRateQuote(ctx, req.QuoteID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return operationCalculation{}, newRequestError(fiber.StatusNotFound, "Quote not found")
+		}
+		return operationCalculation{}, err
+	}
+	if quote.UsedAt.Valid {
+		return operationCalculation{}, newRequestError(fiber.StatusConflict, "Quote has already been used")
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		return operationCalculation{}, newRequestError(fiber.StatusConflict, "Quote has expired")
+	}
+	if quote.CurrencyID != req.CurrencyID || quote.OperationType != req.OperationType {
+		return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Quote does not match currency_id or operation_type")
+	}
+	if quote.ClientID.Valid && quote.ClientID.Int32 != req.ClientID {
+		return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Quote was issued for another client")
+	}
+	if !req.Amount.IsZero() {
+		quoted, err := money.Parse(quote.Amount)
+		if err != nil || req.Amount.Cmp(quoted) != 0 {
+			return operationCalculation{}, newRequestError(fiber.StatusBadRequest, "Amount does not match the quote")
+		}
+	}
+
+	currencyDB, err := h.store.GetCurrency(ctx, quote.CurrencyID)
+	if err != nil {
+		return operationCalculation{}, err
+	}
+	if err := ensureCurrencyActive(currencyDB); err != nil {
+		return operationCalculation{}, err
+	}
+	precision, err := currencyPrecision(currencyDB)
+	if err != nil {
+		return operationCalculation{}, err
+	}
+	return operationCalculation{
+		Currency:       currencyDB,
+		Precision:      precision,
+		AmountCurrency: quote.AmountCurrency,
+		AmountRub:      quote.AmountRub,
+		EffectiveRate:  quote.EffectiveRate,
+		QuoteID:        quote.ID,
+	}, nil
+}
+
+// ensureOperationRate проверяет, что операция без котировки рассчитана по курсу, действующему на время её записи,
+// и что торговля валютой не приостановлена за время расчёта
+func (h *OperationHandler) ensureOperationRate(ctx context.Context, q sqlcgen.Querier, calc operationCalculation) error {
+	if calc.QuoteID != "" {
+		// Курс зафиксирован котировкой, но торговлю валютой могли приостановить после её выдачи
+		currency, err := q.GetCurrency(ctx, calc.Currency.ID)
+		if err != nil {
+			return err
+		}
+		return ensureCurrencyActive(currency)
+	}
+	return ensureCurrentRate(ctx, q, h.rates, calc.Currency)
+}
+
+// ensureOperationFee проверяет, что комиссия операции рассчитана по правилам, действующим на время её записи
+func ensureOperationFee(ctx context.Context, q sqlcgen.Querier, calc operationCalculation, operationType string) error {
+	return ensureCurrentFee(ctx, q, calc.Currency.ID, operationType, calc.AmountRub, calc.Fee)
+}
+
+// useQuote помечает котировку использованной операцией operationID.
+// Повторное использование или истечение срока между расчётом и записью приводит к отказу.
+func useQuote(ctx context.Context, q sqlcgen.Querier, calc operationCalculation, operationID int64) error {
+	if calc.QuoteID == "" {
+		return nil
+	}
+	_, err := q.UseRateQuote(ctx, sqlcgen.UseRateQuoteParams{
+		ID:          calc.QuoteID,
+		OperationID: sql.NullInt64{Int64: operationID, Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return newRequestError(fiber.StatusConflict, "Quote has expired or has already been used")
 	}
+	return err
+}
 
-	totalVolumeBig := new(big.Float).Add(currentVolumeBig, amountCurrencyBig)
-	if totalVolumeBig.Cmp(dailyLimitBig) > 0 {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"status": "error",
-			"message": fmt.Sprintf("Daily limit of %s units for foreign currency %s exceeded. Current today: %s, this op: %s",
-				dailyLimitBig.Text('f', 2), currencyDB.Code, currentVolumeBig.Text('f', 2), amountCurrencyBig.Text('f', 2)),
-		})
+// CreateOperation проводит операцию за один шаг, сразу в статусе COMPLETED
+func (h *OperationHandler) CreateOperation(c *fiber.Ctx) error {
+	req := new(CreateOperationRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	if req.OperationType == service.OperationCrossExchange {
+		return h.createCrossExchange(c, req)
+	}
+
+	calc, err := h.calculateOperation(c.Context(), req)
+	if err != nil {
+		return respondError(c, err, "Could not create operation")
 	}
 
 	// Подготовка параметров для sqlc
 	params := sqlcgen.CreateOperationParams{
-		ClientID:         req.ClientID,
-		OperationType:    req.OperationType,
-		CurrencyID:       req.CurrencyID,
-		AmountCurrency:   amountCurrencyBig.Text('f', 4),
-		AmountRub:        amountRubBig.Text('f', 4),
-		EffectiveRate:    effectiveRateBig.Text('f', 8),
-		ReceiptReference: fmt.Sprintf("RCPT-%d-%s", time.Now().UnixNano(), req.OperationType[:3]),
+		ClientID:       req.ClientID,
+		OperationType:  req.OperationType,
+		CurrencyID:     req.CurrencyID,
+		AmountCurrency: calc.AmountCurrency,
+		AmountRub:      calc.AmountRub,
+		EffectiveRate:  calc.EffectiveRate,
+		FeeFixedRub:    calc.Fee.FixedRub,
+		FeePercentRub:  calc.Fee.PercentRub,
+		FeeRub:         calc.Fee.TotalRub,
+		FeeRuleID:      calc.Fee.RuleID,
+		SpreadRub:      calc.SpreadRub,
+		CashierID:      userIDParam(c),
 	}
 
-	operation, err := h.queries.CreateOperation(c.Context(), params)
+	// Проверка лимитов, выдача номера чека, запись операции и движение наличных выполняются одной транзакцией
+	var operation sqlcgen.Operation
+	locks := []postgresql.AdvisoryLock{postgresql.ClientCurrencyLock(req.ClientID, calc.Currency.ID)}
+	err = h.store.RunInTxLocked(c.Context(), locks, func(q sqlcgen.Querier) error {
+		shift, err := lockOpenShift(c, q)
+		if err != nil {
+			return err
+		}
+		params.ShiftID = shiftIDParam(shift)
+		if err := h.ensureOperationRate(c.Context(), q, calc); err != nil {
+			return err
+		}
+		if err := ensureOperationFee(c.Context(), q, calc, req.OperationType); err != nil {
+			return err
+		}
+		if err := h.checkOperationLimits(c.Context(), q, req.ClientID, calc.Currency, req.OperationType, calc.AmountCurrency); err != nil {
+			return err
+		}
+		params.ReceiptReference, err = h.nextReceiptNumber(c.Context(), q, req.OperationType)
+		if err != nil {
+			return err
+		}
+		operation, err = q.CreateOperation(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		if err := applyCashMovements(c.Context(), q, shift, operationCashMovements(operation, calc.Currency.Code), true); err != nil {
+			return err
+		}
+		if err := useQuote(c.Context(), q, calc, operation.ID); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditOperationCreate, service.AuditEntityOperation, operation.ID, nil, operation)
+	})
 	if err != nil {
-		log.Printf("Error creating operation in DB: %v. Params: %+v", err, params)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create operation", "data": err.Error()})
+		log.Printf("Error creating operation: %v. Params: %+v", err, params)
+		return respondError(c, err, "Could not create operation")
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Operation created successfully", "data": operation})
 }
 
+type ReverseOperationRequest struct {
+	ReasonCode string `json:"reason_code" validate:"required"`
+	Comment    string `json:"comment"`
+}
+
+// ReverseOperation сторнирует операцию: исходная запись помечается как REVERSED и не изменяется,
+// а обратная операция с теми же суммами и курсом создаётся как компенсирующая запись.
+func (h *OperationHandler) ReverseOperation(c *fiber.Ctx) error {
+	id, err := parseOperationID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid operation ID format"})
+	}
+
+	req := new(ReverseOperationRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	if _, ok := service.ReversalReasons[req.ReasonCode]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Unknown reversal reason code", "data": req.ReasonCode})
+	}
+	if req.ReasonCode == "OTHER" && req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Comment is required for reason code OTHER"})
+	}
+
+	var original, reversal sqlcgen.Operation
+	var linked []fiber.Map
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		linked = nil
+
+		shift, err := lockOpenShift(c, q)
+		if err != nil {
+			return err
+		}
+
+		// Сторнировать можно только завершённую операцию; черновик или подтверждённую — отменить
+		op, err := lockOperation(c, q, id, service.OperationStatusCompleted)
+		if err != nil {
+			if op.Status == service.OperationStatusReversed {
+				return newRequestError(fiber.StatusConflict, "Operation is already reversed")
+			}
+			return err
+		}
+
+		var movements []cashMovement
+		original, reversal, movements, err = h.reverseLockedOperation(c.Context(), q, shift, userIDParam(c), op, req)
+		if err != nil {
+			return err
+		}
+		if err := auditReversal(c, q, op, original, reversal); err != nil {
+			return err
+		}
+		if !op.ExchangeGroup.Valid {
+			// Сторно возвращает наличные клиенту независимо от резерва, но не больше, чем есть в кассе
+			return applyCashMovements(c.Context(), q, shift, movements, false)
+		}
+
+		// Операции кросс-конвертации сторнируются только вместе
+		legs, err := q.ListExchangeGroupOperationsForUpdate(c.Context(), op.ExchangeGroup)
+		if err != nil {
+			return err
+		}
+		for _, leg := range legs {
+			if leg.ID == op.ID {
+				continue
+			}
+			if leg.Status != service.OperationStatusCompleted {
+				return newRequestError(fiber.StatusConflict, "Linked operation %d in status %s cannot be reversed", leg.ID, leg.Status)
+			}
+			legOriginal, legReversal, legMovements, err := h.reverseLockedOperation(c.Context(), q, shift, userIDParam(c), leg, req)
+			if err != nil {
+				return err
+			}
+			if err := auditReversal(c, q, leg, legOriginal, legReversal); err != nil {
+				return err
+			}
+			linked = append(linked, fiber.Map{"original": legOriginal, "reversal": legReversal})
+			movements = append(movements, legMovements...)
+		}
+		// Наличные по всем операциям конвертации проводятся вместе, как и при её создании
+		return applyCashMovements(c.Context(), q, shift, movements, false)
+	})
+	if err != nil {
+		log.Printf("Error reversing operation %d: %v", id, err)
+		return respondError(c, err, "Could not reverse operation")
+	}
+
+	data := fiber.Map{
+		"original": original,
+		"reversal": reversal,
+	}
+	if len(linked) > 0 {
+		data["linked"] = linked
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation reversed successfully",
+		"data":    data,
+	})
+}
+
+// reverseLockedOperation помечает заблокированную операцию как REVERSED и создаёт компенсирующую от имени кассира cashierID.
+// Возвращает также движения наличных сторно, которые вызывающий проводит по кассе.
+func (h *OperationHandler) reverseLockedOperation(ctx context.Context, q sqlcgen.Querier, shift sqlcgen.Shift, cashierID sql.NullInt32, op sqlcgen.Operation, req *ReverseOperationRequest) (sqlcgen.Operation, sqlcgen.Operation, []cashMovement, error) {
+	original, err := q.MarkOperationReversed(ctx, op.ID)
+	if err != nil {
+		return original, sqlcgen.Operation{}, nil, err
+	}
+	currency, err := q.GetCurrency(ctx, op.CurrencyID)
+	if err != nil {
+		return original, sqlcgen.Operation{}, nil, err
+	}
+
+	// Чек сторно получает собственный номер из общей нумерации, связь с исходным — через reversal_of_id
+	receiptReference, err := h.nextReceiptNumber(ctx, q, service.OperationStatusReversal)
+	if err != nil {
+		return original, sqlcgen.Operation{}, nil, err
+	}
+
+	params := sqlcgen.CreateReversalOperationParams{
+		ClientID:         op.ClientID,
+		OperationType:    service.OppositeOperationType(op.OperationType),
+		CurrencyID:       op.CurrencyID,
+		AmountCurrency:   op.AmountCurrency,
+		AmountRub:        op.AmountRub,
+		EffectiveRate:    op.EffectiveRate,
+		ReceiptReference: receiptReference,
+		ReversalOfID:     sql.NullInt64{Int64: op.ID, Valid: true},
+		ReversalReason:   sql.NullString{String: req.ReasonCode, Valid: true},
+		FeeFixedRub:      op.FeeFixedRub,
+		FeePercentRub:    op.FeePercentRub,
+		FeeRub:           op.FeeRub,
+		FeeRuleID:        op.FeeRuleID,
+		SpreadRub:        op.SpreadRub,
+		ShiftID:          shiftIDParam(shift),
+		CashierID:        cashierID,
+	}
+	if req.Comment != "" {
+		params.ReversalComment = sql.NullString{String: req.Comment, Valid: true}
+	}
+	reversal, err := q.CreateReversalOperation(ctx, params)
+	if err != nil {
+		return original, reversal, nil, err
+	}
+	return original, reversal, reversalCashMovements(op, reversal, currency.Code), nil
+}
+
+// auditReversal записывает сторно операции op в журнал аудита: исходная операция и созданная компенсирующая
+func auditReversal(c *fiber.Ctx, q sqlcgen.Querier, op, original, reversal sqlcgen.Operation) error {
+	return audit(c, q, service.AuditOperationReverse, service.AuditEntityOperation, op.ID, op,
+		fiber.Map{"original": original, "reversal": reversal})
+}
+
 func (h *OperationHandler) GetOperations(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	pageSize, _ := strconv.Atoi(c.Query("pageSize", "10"))
@@ -171,7 +549,7 @@ func (h *OperationHandler) GetOperations(c *fiber.Ctx) error {
 		Offset: int32(offset),
 	}
 
-	operations, err := h.queries.ListOperations(c.Context(), params)
+	operations, err := h.store.ListOperations(c.Context(), params)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve operations", "data": err.Error()})
 	}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Двухшаговое проведение операции:
+// DRAFT (суммы и курс зафиксированы) -> CONFIRMED (наличные пересчитаны, клиент подписал) -> COMPLETED.
+// До завершения операцию можно отменить; неподтверждённый черновик истекает через draftTTL.
+
+// CreateDraftOperation создаёт черновик операции с зафиксированными суммами и курсом.
+// Черновик не входит в лимиты, но лимиты проверяются заранее, чтобы кассир сразу видел отказ.
+func (h *OperationHandler) CreateDraftOperation(c *fiber.Ctx) error {
+	req := new(CreateOperationRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+
+	calc, err := h.calculateOperation(c.Context(), req)
+	if err != nil {
+		return respondError(c, err, "Could not create draft operation")
+	}
+
+	params := sqlcgen.CreateDraftOperationParams{
+		ClientID:       req.ClientID,
+		OperationType:  req.OperationType,
+		CurrencyID:     req.CurrencyID,
+		AmountCurrency: calc.AmountCurrency,
+		AmountRub:      calc.AmountRub,
+		EffectiveRate:  calc.EffectiveRate,
+		FeeFixedRub:    calc.Fee.FixedRub,
+		FeePercentRub:  calc.Fee.PercentRub,
+		FeeRub:         calc.Fee.TotalRub,
+		FeeRuleID:      calc.Fee.RuleID,
+		SpreadRub:      calc.SpreadRub,
+		ExpiresAt:      sql.NullTime{Time: time.Now().Add(h.draftTTL), Valid: true},
+		CashierID:      userIDParam(c),
+	}
+
+	var operation sqlcgen.Operation
+	locks := []postgresql.AdvisoryLock{postgresql.ClientCurrencyLock(req.ClientID, calc.Currency.ID)}
+	err = h.store.RunInTxLocked(c.Context(), locks, func(q sqlcgen.Querier) error {
+		shift, err := lockOpenShift(c, q)
+		if err != nil {
+			return err
+		}
+		params.ShiftID = shiftIDParam(shift)
+		if err := h.ensureOperationRate(c.Context(), q, calc); err != nil {
+			return err
+		}
+		if err := ensureOperationFee(c.Context(), q, calc, req.OperationType); err != nil {
+			return err
+		}
+		if err := h.checkOperationLimits(c.Context(), q, req.ClientID, calc.Currency, req.OperationType, calc.AmountCurrency); err != nil {
+			return err
+		}
+		operation, err = q.CreateDraftOperation(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		if err := useQuote(c.Context(), q, calc, operation.ID); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditOperationDraft, service.AuditEntityOperation, operation.ID, nil, operation)
+	})
+	if err != nil {
+		log.Printf("Error creating draft operation: %v. Params: %+v", err, params)
+		return respondError(c, err, "Could not create draft operation")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Draft operation created successfully", "data": operation})
+}
+
+// lockOperation загружает операцию с блокировкой строки и проверяет её статус
+func lockOperation(c *fiber.Ctx, q sqlcgen.Querier, id int64, allowedStatuses ...string) (sqlcgen.Operation, error) {
+	op, err := q.GetOperationForUpdate(c.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return op, newRequestError(fiber.StatusNotFound, "Operation not found")
+		}
+		return op, err
+	}
+	for _, status := range allowedStatuses {
+		if op.Status == status {
+			return op, nil
+		}
+	}
+	return op, newRequestError(fiber.StatusConflict, "Operation in status %s cannot be changed this way", op.Status)
+}
+
+func parseOperationID(c *fiber.Ctx) (int64, error) {
+	return strconv.ParseInt(c.Params("id"), 10, 64)
+}
+
+// ConfirmOperation подтверждает черновик. Лимиты проверяются повторно в той же транзакции,
+// так как за время жизни черновика клиент мог провести другие операции.
+func (h *OperationHandler) ConfirmOperation(c *fiber.Ctx) error {
+	id, err := parseOperationID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid operation ID format"})
+	}
+
+	// Клиент и валюта операции не меняются: по ним берётся блокировка лимитов до начала транзакции
+	found, err := h.store.GetOperation(c.Context(), id)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Operation not found"})
+	}
+	if err != nil {
+		log.Printf("Error loading operation %d: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not confirm operation"})
+	}
+
+	var operation sqlcgen.Operation
+	locks := []postgresql.AdvisoryLock{postgresql.ClientCurrencyLock(found.ClientID, found.CurrencyID)}
+	err = h.store.RunInTxLocked(c.Context(), locks, func(q sqlcgen.Querier) error {
+		if _, err := lockOpenShift(c, q); err != nil {
+			return err
+		}
+		draft, err := lockOperation(c, q, id, service.OperationStatusDraft)
+		if err != nil {
+			return err
+		}
+		if err := ensureOperationOwner(c, draft); err != nil {
+			return err
+		}
+		if draft.ExpiresAt.Valid && time.Now().After(draft.ExpiresAt.Time) {
+			return newRequestError(fiber.StatusConflict, "Draft operation has expired")
+		}
+
+		currency, err := q.GetCurrency(c.Context(), draft.CurrencyID)
+		if err != nil {
+			return err
+		}
+		// Черновик по валюте, торговля которой приостановлена, не подтверждается; подтверждённую операцию можно завершить
+		if err := ensureCurrencyActive(currency); err != nil {
+			return err
+		}
+		if err := h.checkOperationLimits(c.Context(), q, draft.ClientID, currency, draft.OperationType, draft.AmountCurrency); err != nil {
+			return err
+		}
+		// Касса должна быть в состоянии выдать наличные; остатки изменятся при завершении операции
+		flows := service.OperationCashFlows(draft.OperationType, currency.Code, draft.AmountCurrency, draft.AmountRub, draft.FeeRub)
+		if err := checkCashAvailable(c.Context(), q, flows); err != nil {
+			return err
+		}
+
+		operation, err = q.ConfirmOperation(c.Context(), id)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditOperationConfirm, service.AuditEntityOperation, id, draft, operation)
+	})
+	if err != nil {
+		log.Printf("Error confirming operation %d: %v", id, err)
+		return respondError(c, err, "Could not confirm operation")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Operation confirmed successfully", "data": operation})
+}
+
+// CompleteOperation завершает подтверждённую операцию после выдачи наличных
+func (h *OperationHandler) CompleteOperation(c *fiber.Ctx) error {
+	id, err := parseOperationID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid operation ID format"})
+	}
+
+	var operation sqlcgen.Operation
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		shift, err := lockOpenShift(c, q)
+		if err != nil {
+			return err
+		}
+		confirmed, err := lockOperation(c, q, id, service.OperationStatusConfirmed)
+		if err != nil {
+			return err
+		}
+		if err := ensureOperationOwner(c, confirmed); err != nil {
+			return err
+		}
+		currency, err := q.GetCurrency(c.Context(), confirmed.CurrencyID)
+		if err != nil {
+			return err
+		}
+		receiptReference, err := h.nextReceiptNumber(c.Context(), q, confirmed.OperationType)
+		if err != nil {
+			return err
+		}
+		operation, err = q.CompleteOperation(c.Context(), sqlcgen.CompleteOperationParams{
+			ID:               id,
+			ShiftID:          shiftIDParam(shift),
+			ReceiptReference: receiptReference,
+		})
+		if err != nil {
+			return err
+		}
+		if err := applyCashMovements(c.Context(), q, shift, operationCashMovements(operation, currency.Code), true); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditOperationComplete, service.AuditEntityOperation, id, confirmed, operation)
+	})
+	if err != nil {
+		log.Printf("Error completing operation %d: %v", id, err)
+		return respondError(c, err, "Could not complete operation")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Operation completed successfully", "data": operation})
+}
+
+// CancelOperation отменяет черновик или подтверждённую, но не завершённую операцию.
+// Отменить операцию может её кассир в своей открытой смене, а также старший кассир или администратор.
+func (h *OperationHandler) CancelOperation(c *fiber.Ctx) error {
+	id, err := parseOperationID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid operation ID format"})
+	}
+
+	var operation sqlcgen.Operation
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		if _, err := lockOpenShift(c, q); err != nil {
+			return err
+		}
+		before, err := lockOperation(c, q, id, service.OperationStatusDraft, service.OperationStatusConfirmed)
+		if err != nil {
+			return err
+		}
+		if err := ensureOperationOwner(c, before); err != nil {
+			return err
+		}
+		operation, err = q.CancelOperation(c.Context(), id)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditOperationCancel, service.AuditEntityOperation, id, before, operation)
+	})
+	if err != nil {
+		log.Printf("Error cancelling operation %d: %v", id, err)
+		return respondError(c, err, "Could not cancel operation")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Operation cancelled successfully", "data": operation})
+}
@@ -0,0 +1,266 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+type OperationLimitHandler struct {
+	store postgresql.Store
+}
+
+func NewOperationLimitHandler(store postgresql.Store) *OperationLimitHandler {
+	return &OperationLimitHandler{store: store}
+}
+
+// Верхняя граница значения в формате DECIMAL(15,4)
+var maxLimitValue = decimal.New(1, 11)
+
+type CreateOperationLimitRequest struct {
+	LimitName   string          `json:"limit_name" validate:"required"`
+	LimitValue  decimal.Decimal `json:"limit_value" validate:"required"`
+	Description string          `json:"description"`
+}
+
+type UpdateOperationLimitRequest struct {
+	LimitValue  decimal.Decimal `json:"limit_value" validate:"required"`
+	Description *string         `json:"description"`
+}
+
+// validateLimitValue проверяет, что значение лимита — положительное десятичное число
+func validateLimitValue(value decimal.Decimal) error {
+	if value.GreaterThanOrEqual(maxLimitValue) || !value.Equal(value.Truncate(4)) {
+		return fmt.Errorf("limit_value must be a positive decimal with up to 4 fractional digits")
+	}
+	if value.Sign() <= 0 {
+		return fmt.Errorf("limit_value must be greater than zero")
+	}
+	return nil
+}
+
+// validateLimitName проверяет имя лимита и существование указанной в нём валюты
+func (h *OperationLimitHandler) validateLimitName(c *fiber.Ctx, name string) error {
+	_, currencyCode, _, err := service.ParseLimitName(name)
+	if err != nil {
+		return err
+	}
+	if currencyCode != "" {
+		if _, err := h.store.GetCurrencyByCode(c.Context(), currencyCode); err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("unknown currency '%s'", currencyCode)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLimits возвращает все лимиты операций
+func (h *OperationLimitHandler) GetLimits(c *fiber.Ctx) error {
+	limits, err := h.store.ListOperationLimits(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve operation limits",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation limits retrieved successfully",
+		"data":    limits,
+	})
+}
+
+// GetLimit возвращает лимит по имени
+func (h *OperationLimitHandler) GetLimit(c *fiber.Ctx) error {
+	limit, err := h.store.GetOperationLimit(c.Context(), c.Params("name"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation limit not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve operation limit",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation limit retrieved successfully",
+		"data":    limit,
+	})
+}
+
+// CreateLimit создаёт новый лимит
+func (h *OperationLimitHandler) CreateLimit(c *fiber.Ctx) error {
+	req := new(CreateOperationLimitRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body: " + err.Error(),
+		})
+	}
+
+	if err := h.validateLimitName(c, req.LimitName); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid limit_name",
+			"data":    err.Error(),
+		})
+	}
+	if err := validateLimitValue(req.LimitValue); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid limit_value",
+			"data":    err.Error(),
+		})
+	}
+
+	params := sqlcgen.CreateOperationLimitParams{
+		LimitName:  req.LimitName,
+		LimitValue: req.LimitValue,
+	}
+	if req.Description != "" {
+		params.Description = sql.NullString{String: req.Description, Valid: true}
+	}
+
+	var limit sqlcgen.OperationLimit
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		var err error
+		limit, err = q.CreateOperationLimit(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditLimitCreate, service.AuditEntityOperationLimit, limit.LimitName, nil, limit)
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation limit already exists",
+			})
+		}
+		log.Printf("Error creating operation limit: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not create operation limit",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation limit created successfully",
+		"data":    limit,
+	})
+}
+
+// UpdateLimit изменяет значение лимита
+func (h *OperationLimitHandler) UpdateLimit(c *fiber.Ctx) error {
+	req := new(UpdateOperationLimitRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body: " + err.Error(),
+		})
+	}
+	if err := validateLimitValue(req.LimitValue); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid limit_value",
+			"data":    err.Error(),
+		})
+	}
+
+	params := sqlcgen.UpdateOperationLimitParams{
+		LimitName:  c.Params("name"),
+		LimitValue: req.LimitValue,
+	}
+	if req.Description != nil {
+		params.Description = sql.NullString{String: *req.Description, Valid: true}
+	}
+
+	var limit sqlcgen.OperationLimit
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetOperationLimit(c.Context(), params.LimitName)
+		if err != nil {
+			return err
+		}
+		limit, err = q.UpdateOperationLimit(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditLimitUpdate, service.AuditEntityOperationLimit, limit.LimitName, before, limit)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation limit not found",
+			})
+		}
+		log.Printf("Error updating operation limit: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not update operation limit",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation limit updated successfully",
+		"data":    limit,
+	})
+}
+
+// DeleteLimit удаляет лимит
+func (h *OperationLimitHandler) DeleteLimit(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	// Общие лимиты обязательны: без них CreateOperation не сможет проверить операцию
+	if name == service.LimitDailyCurrencyVolume || name == service.LimitSingleOperationAmount {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Default operation limits cannot be deleted",
+		})
+	}
+
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetOperationLimit(c.Context(), name)
+		if err != nil {
+			return err
+		}
+		if _, err := q.DeleteOperationLimit(c.Context(), name); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditLimitDelete, service.AuditEntityOperationLimit, name, before, nil)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Operation limit not found",
+			})
+		}
+		log.Printf("Error deleting operation limit: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not delete operation limit",
+			"data":    err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Operation limit deleted successfully",
+	})
+}
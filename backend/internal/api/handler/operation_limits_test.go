@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/postgresql/pgtest"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Параллельные операции одного клиента по одной валюте не должны вместе превысить дневной лимит:
+// при лимите 10000 USD из десяти операций по 3000 USD проходят ровно три.
+func TestCreateOperationDailyLimitUnderConcurrency(t *testing.T) {
+	db := pgtest.NewDB(t)
+	store := postgresql.NewStore(db)
+	ctx := context.Background()
+
+	cashier, err := store.CreateUser(ctx, sqlcgen.CreateUserParams{Username: "cashier", PasswordHash: "-", FullName: "Кассир", Role: service.RoleCashier})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if _, err := store.OpenShift(ctx, sqlcgen.OpenShiftParams{CashierID: sql.NullInt32{Int32: cashier.ID, Valid: true}, CashierName: cashier.FullName}); err != nil {
+		t.Fatalf("open shift: %v", err)
+	}
+	client, err := store.CreateClient(ctx, sqlcgen.CreateClientParams{PassportNumber: "4500 123456", FullName: "Иванов Иван"})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	if _, err := store.ChangeCashBalance(ctx, sqlcgen.ChangeCashBalanceParams{CurrencyCode: "RUB", Amount: decimal.NewFromInt(10_000_000)}); err != nil {
+		t.Fatalf("fund cash desk: %v", err)
+	}
+	usd, err := store.GetCurrencyByCode(ctx, "USD")
+	if err != nil {
+		t.Fatalf("load USD: %v", err)
+	}
+
+	rates := service.NewRateScheduler(service.RateChangePolicy{}, service.ReferenceRatePolicy{}, time.UTC, service.NewRateBroadcaster())
+	h := NewOperationHandler(store, service.NewReceiptNumbering("MSK01", service.DefaultReceiptNumberTemplate, time.UTC), time.UTC, time.Minute, rates)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		middleware.SetCurrentUser(c, cashier)
+		return c.Next()
+	})
+	app.Post("/operations", h.CreateOperation)
+
+	const requests = 10
+	body := fmt.Sprintf(`{"client_id":%d,"operation_type":%q,"currency_id":%d,"amount":"3000"}`,
+		client.ID, service.OperationClientSells, usd.ID)
+	statuses := make([]int, requests)
+	var wg sync.WaitGroup
+	for i := range statuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(fiber.MethodPost, "/operations", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Errorf("request %d: %v", i, err)
+				return
+			}
+			defer resp.Body.Close()
+			statuses[i] = resp.StatusCode
+			if resp.StatusCode != fiber.StatusCreated && resp.StatusCode != fiber.StatusForbidden {
+				var payload map[string]interface{}
+				_ = json.NewDecoder(resp.Body).Decode(&payload)
+				t.Errorf("request %d: status %d: %v", i, resp.StatusCode, payload)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, status := range statuses {
+		if status == fiber.StatusCreated {
+			created++
+		}
+	}
+	if created != 3 {
+		t.Errorf("created %d operations, want 3 (statuses %v)", created, statuses)
+	}
+	dayStart, dayEnd := businessDay(time.Now(), time.UTC)
+	volume, err := store.GetDailyClientForeignCurrencyVolume(ctx, sqlcgen.GetDailyClientForeignCurrencyVolumeParams{
+		ClientID:          client.ID,
+		ForeignCurrencyID: usd.ID,
+		DayStart:          dayStart,
+		DayEnd:            dayEnd,
+	})
+	if err != nil {
+		t.Fatalf("daily volume: %v", err)
+	}
+	if !volume.Equal(decimal.NewFromInt(9000)) {
+		t.Errorf("daily volume %s, want 9000", volume)
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// parsePeriodBound разбирает границу периода из параметра запроса: время в RFC 3339
+// или дату YYYY-MM-DD в часовом поясе location. Дата в верхней границе (upper)
+// включается в период целиком. Пустое значение — граница не задана.
+func parsePeriodBound(value string, location *time.Location, upper bool) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return sql.NullTime{Time: t, Valid: true}, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, location)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("'%s' is neither an RFC 3339 time nor a YYYY-MM-DD date", value)
+	}
+	if upper {
+		day = day.AddDate(0, 0, 1)
+	}
+	return sql.NullTime{Time: day, Valid: true}, nil
+}
+
+// parseBusinessDate разбирает дату YYYY-MM-DD в часовом поясе location; пустое значение — сегодня
+func parseBusinessDate(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		today, _ := businessDay(time.Now(), location)
+		return today, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is not a YYYY-MM-DD date", value)
+	}
+	return day, nil
+}
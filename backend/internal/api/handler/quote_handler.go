@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+type QuoteHandler struct {
+	store    postgresql.Store
+	quoteTTL time.Duration          // Время, в течение которого действует курс котировки
+	rates    *service.RateScheduler // Плановые изменения курсов: применяются до расчёта котировки
+}
+
+func NewQuoteHandler(store postgresql.Store, quoteTTL time.Duration, rates *service.RateScheduler) *QuoteHandler {
+	return &QuoteHandler{store: store, quoteTTL: quoteTTL, rates: rates}
+}
+
+type CreateQuoteRequest struct {
+	ClientID      int32           `json:"client_id"` // Необязательно: котировку можно привязать к клиенту
+	OperationType string          `json:"operation_type" validate:"required,oneof=CLIENT_SELLS_TO_EXCHANGE CLIENT_BUYS_FROM_EXCHANGE"`
+	CurrencyID    int32           `json:"currency_id" validate:"required"`
+	Amount        decimal.Decimal `json:"amount" validate:"required,gt=0"`
+}
+
+// newQuoteID формирует случайный идентификатор котировки
+func newQuoteID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "Q-" + hex.EncodeToString(b), nil
+}
+
+// CreateQuote рассчитывает операцию по текущему курсу и фиксирует результат на quoteTTL
+func (h *QuoteHandler) CreateQuote(c *fiber.Ctx) error {
+	req := new(CreateQuoteRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+
+	if err := h.rates.Refresh(c.Context(), h.store, req.CurrencyID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not calculate quote", "data": err.Error()})
+	}
+	currencyDB, err := h.store.GetCurrency(c.Context(), req.CurrencyID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Currency not found", "data": err.Error()})
+	}
+	if err := ensureCurrencyActive(currencyDB); err != nil {
+		return respondError(c, err, "Could not calculate quote")
+	}
+
+	calc, err := calculateExchange(currencyDB, req.OperationType, req.Amount)
+	if err != nil {
+		return respondError(c, err, "Could not calculate quote")
+	}
+
+	id, err := newQuoteID()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not generate quote ID", "data": err.Error()})
+	}
+
+	params := sqlcgen.CreateRateQuoteParams{
+		ID:             id,
+		OperationType:  req.OperationType,
+		CurrencyID:     req.CurrencyID,
+		Amount:         req.Amount.String(),
+		AmountCurrency: calc.AmountCurrency,
+		AmountRub:      calc.AmountRub,
+		EffectiveRate:  calc.EffectiveRate,
+		ExpiresAt:      time.Now().Add(h.quoteTTL),
+	}
+	if req.ClientID != 0 {
+		params.ClientID = sql.NullInt32{Int32: req.ClientID, Valid: true}
+	}
+
+	var quote sqlcgen.RateQuote
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		if err := ensureCurrentRate(c.Context(), q, h.rates, currencyDB); err != nil {
+			return err
+		}
+		var err error
+		quote, err = q.CreateRateQuote(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditQuoteCreate, service.AuditEntityRateQuote, quote.ID, nil, quote)
+	})
+	if err != nil {
+		log.Printf("Error creating quote: %v. Params: %+v", err, params)
+		return respondError(c, err, "Could not create quote")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "Quote created successfully", "data": quote})
+}
+
+// GetQuote возвращает котировку по идентификатору
+func (h *QuoteHandler) GetQuote(c *fiber.Ctx) error {
+	quote, err := h.store.GetRateQuote(c.Context(), c.Params("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Quote not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve quote", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Quote retrieved successfully", "data": quote})
+}
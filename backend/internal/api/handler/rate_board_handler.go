@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Табло курсов для клиентов: открыто без аутентификации, поэтому отдаёт только код, название и курсы
+// валют, которыми сейчас торгуют. Плановые изменения курсов применяются фоновой задачей, табло их само не применяет.
+
+// Сколько секунд браузер и прокси могут показывать табло без повторного запроса
+const rateBoardMaxAge = 10
+
+// Интервал автоматического обновления HTML-табло
+const rateBoardRefreshSeconds = 30
+
+// PublicRate — курс валюты на публичном табло
+type PublicRate struct {
+	Code      string          `json:"code"`
+	Name      string          `json:"name"`
+	BuyRate   decimal.Decimal `json:"buy_rate"`  // Курс, по которому клиент покупает валюту (пункт продаёт)
+	SellRate  decimal.Decimal `json:"sell_rate"` // Курс, по которому клиент продаёт валюту (пункт покупает)
+	UpdatedAt *time.Time      `json:"updated_at"`
+}
+
+type RateBoardHandler struct {
+	store    postgresql.Store
+	location *time.Location // Часовой пояс пункта обмена: в нём показывается время обновления на HTML-табло
+}
+
+func NewRateBoardHandler(store postgresql.Store, location *time.Location) *RateBoardHandler {
+	return &RateBoardHandler{store: store, location: location}
+}
+
+// rateBoard — содержимое табло и его версия для условных запросов
+type rateBoard struct {
+	Rates     []PublicRate
+	UpdatedAt time.Time // Последнее изменение курса или статуса валют; нулевое, если валют нет
+	etag      string
+}
+
+// loadRateBoard загружает активные валюты табло. ETag меняется при любом изменении курса (last_rate_update_at),
+// а также при появлении и исчезновении валют. Загружаются все валюты, включая удалённые: Last-Modified должен
+// учитывать и приостановку торговли или удаление (status_changed_at) валюты, которая с табло уже пропала.
+func (h *RateBoardHandler) loadRateBoard(c *fiber.Ctx) (rateBoard, error) {
+	currencies, err := h.store.ListCurrenciesByStatus(c.Context(), sqlcgen.ListCurrenciesByStatusParams{})
+	if err != nil {
+		return rateBoard{}, err
+	}
+	board := rateBoard{Rates: make([]PublicRate, 0, len(currencies))}
+	hash := sha256.New()
+	for _, currency := range currencies {
+		if currency.StatusChangedAt.Valid && currency.StatusChangedAt.Time.After(board.UpdatedAt) {
+			board.UpdatedAt = currency.StatusChangedAt.Time
+		}
+		if !currency.IsActive {
+			continue
+		}
+		rate := publicRate(currency)
+		if rate.UpdatedAt != nil && rate.UpdatedAt.After(board.UpdatedAt) {
+			board.UpdatedAt = *rate.UpdatedAt
+		}
+		fmt.Fprintf(hash, "%s|%s|%s|%d\n", currency.Code, currency.BuyRate, currency.SellRate, currency.LastRateUpdateAt.Time.UnixNano())
+		board.Rates = append(board.Rates, rate)
+	}
+	board.etag = `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	return board, nil
+}
+
+func publicRate(currency sqlcgen.Currency) PublicRate {
+	rate := PublicRate{Code: currency.Code, Name: currency.Name, BuyRate: currency.BuyRate, SellRate: currency.SellRate}
+	if currency.LastRateUpdateAt.Valid {
+		updatedAt := currency.LastRateUpdateAt.Time
+		rate.UpdatedAt = &updatedAt
+	}
+	return rate
+}
+
+// notModified выставляет заголовки кэширования табло и сообщает, что у клиента актуальная версия.
+// If-None-Match важнее If-Modified-Since (RFC 9110): c.Fresh так не умеет и считает свежим
+// любой запрос с одним If-Modified-Since, поэтому условия проверяются здесь.
+func notModified(c *fiber.Ctx, board rateBoard) bool {
+	c.Set(fiber.HeaderETag, board.etag)
+	if !board.UpdatedAt.IsZero() {
+		c.Set(fiber.HeaderLastModified, board.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", rateBoardMaxAge))
+
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, tag := range strings.Split(noneMatch, ",") {
+			tag = strings.TrimSpace(tag)
+			// Слабое сравнение: W/ не учитывается
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(board.etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if modifiedSince := c.Get(fiber.HeaderIfModifiedSince); modifiedSince != "" && !board.UpdatedAt.IsZero() {
+		since, err := http.ParseTime(modifiedSince)
+		// Last-Modified передаётся с точностью до секунды
+		return err == nil && !board.UpdatedAt.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// GetRates возвращает курсы валют для публичного табло.
+// Поддерживает условные запросы: If-None-Match и If-Modified-Since, ответ 304 без тела.
+func (h *RateBoardHandler) GetRates(c *fiber.Ctx) error {
+	board, err := h.loadRateBoard(c)
+	if err != nil {
+		log.Printf("Error loading rate board: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve rates"})
+	}
+	if notModified(c, board) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	var updatedAt *time.Time
+	if !board.UpdatedAt.IsZero() {
+		updatedAt = &board.UpdatedAt
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Rates retrieved successfully",
+		"data":    fiber.Map{"rates": board.Rates, "updated_at": updatedAt},
+	})
+}
+
+// GetBoardPage отдаёт HTML-табло курсов для экрана в зале; страница сама обновляется каждые 30 секунд
+func (h *RateBoardHandler) GetBoardPage(c *fiber.Ctx) error {
+	board, err := h.loadRateBoard(c)
+	if err != nil {
+		log.Printf("Error loading rate board: %v", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not retrieve rates")
+	}
+	if notModified(c, board) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	var page strings.Builder
+	err = rateBoardTemplate.Execute(&page, fiber.Map{
+		"Rates":          board.Rates,
+		"UpdatedAt":      board.UpdatedAt.In(h.location),
+		"HasUpdate":      !board.UpdatedAt.IsZero(),
+		"RefreshSeconds": rateBoardRefreshSeconds,
+	})
+	if err != nil {
+		log.Printf("Error rendering rate board: %v", err)
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not render rate board")
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(fiber.StatusOK).SendString(page.String())
+}
+
+// formatBoardRate печатает курс без лишних нулей, но не короче двух знаков после запятой
+func formatBoardRate(rate decimal.Decimal) string {
+	if rate.Equal(rate.Round(2)) {
+		return rate.StringFixed(2)
+	}
+	return rate.String()
+}
+
+// rateBoardTemplate — HTML-табло. Колонки подписаны со стороны пункта, как принято на табло обменников:
+// «Покупка» — пункт покупает валюту у клиента (SellRate), «Продажа» — пункт продаёт её клиенту (BuyRate).
+var rateBoardTemplate = template.Must(template.New("board").Funcs(template.FuncMap{"rate": formatBoardRate}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.RefreshSeconds}}">
+<title>Курсы валют</title>
+<style>
+body { margin: 0; padding: 2vw; background: #111; color: #fff; font-family: sans-serif; }
+h1 { margin: 0 0 2vw; font-size: 4vw; }
+table { width: 100%; border-collapse: collapse; font-size: 3.5vw; }
+th, td { padding: 1vw 2vw; border-bottom: 1px solid #444; }
+th { color: #aaa; font-weight: normal; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+td { text-align: right; font-variant-numeric: tabular-nums; }
+.name { color: #aaa; font-size: 2vw; }
+footer { margin-top: 2vw; color: #aaa; font-size: 1.8vw; }
+</style>
+</head>
+<body>
+<h1>Курсы валют</h1>
+<table>
+<thead><tr><th>Валюта</th><th>Покупка</th><th>Продажа</th></tr></thead>
+<tbody>
+{{- range .Rates}}
+<tr><td>{{.Code}} <span class="name">{{.Name}}</span></td><td>{{rate .SellRate}}</td><td>{{rate .BuyRate}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- if .HasUpdate}}
+<footer>Обновлено {{.UpdatedAt.Format "02.01.2006 15:04"}}</footer>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// currencyListStore возвращает заданный список валют; остальные методы Store не вызываются
+type currencyListStore struct {
+	postgresql.Store
+	currencies []sqlcgen.Currency
+}
+
+func (s currencyListStore) ListCurrenciesByStatus(ctx context.Context, arg sqlcgen.ListCurrenciesByStatusParams) ([]sqlcgen.Currency, error) {
+	return s.currencies, nil
+}
+
+// Клиент покупает доллары по 90.50 и продаёт по 89.50: на табло «Покупка» — 89.50, «Продажа» — 90.50
+func TestGetBoardPageColumnOrder(t *testing.T) {
+	store := currencyListStore{currencies: []sqlcgen.Currency{{
+		Code:     "USD",
+		Name:     "Доллар США",
+		BuyRate:  decimal.RequireFromString("90.5"),
+		SellRate: decimal.RequireFromString("89.5"),
+		IsActive: true,
+	}}}
+	app := fiber.New()
+	app.Get("/board", NewRateBoardHandler(store, time.UTC).GetBoardPage)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/board", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status %d, want %d", resp.StatusCode, fiber.StatusOK)
+	}
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !regexp.MustCompile(`<th>Валюта</th><th>Покупка</th><th>Продажа</th>`).Match(page) {
+		t.Fatalf("unexpected table header:\n%s", page)
+	}
+	row := regexp.MustCompile(`<tr><td>USD <span class="name">[^<]*</span></td><td>([^<]*)</td><td>([^<]*)</td></tr>`).FindSubmatch(page)
+	if row == nil {
+		t.Fatalf("USD row not found:\n%s", page)
+	}
+	if buy, sell := string(row[1]), string(row[2]); buy != "89.50" || sell != "90.50" {
+		t.Errorf("Покупка %s, Продажа %s; want 89.50 and 90.50", buy, sell)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Интервал heartbeat-событий: по ним клиент и прокси видят, что соединение живо,
+// а сервер обнаруживает отключившихся клиентов
+const rateStreamHeartbeatInterval = 15 * time.Second
+
+// StreamRates отправляет изменения курсов по Server-Sent Events.
+// При подключении приходит событие snapshot со всеми валютами, затем событие rate на каждое изменение курса
+// и heartbeat раз в 15 секунд. Если клиент не успевает забирать изменения, поток закрывается —
+// после переподключения клиент получает новый snapshot.
+func (h *CurrencyHandler) StreamRates(c *fiber.Ctx) error {
+	// Подписка до загрузки снимка: изменение между ними придёт событием rate, а не потеряется
+	updates, unsubscribe := h.broadcast.Subscribe()
+	currencies, err := h.store.ListCurrencies(c.Context())
+	if err != nil {
+		unsubscribe()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not retrieve currencies",
+			"data":    err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Иначе nginx буферизует поток
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		if err := writeSSE(w, "snapshot", currencies); err != nil {
+			return
+		}
+		heartbeat := time.NewTicker(rateStreamHeartbeatInterval)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case currency, ok := <-updates:
+				if !ok {
+					return
+				}
+				if err := writeSSE(w, "rate", currency); err != nil {
+					return
+				}
+			case t := <-heartbeat.C:
+				if err := writeSSE(w, "heartbeat", fiber.Map{"time": t}); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// writeSSE записывает событие event с данными data в формате JSON и сразу отправляет его клиенту
+func writeSSE(w *bufio.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", event, err)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"database/sql"
 	"exchange_point/backend/internal/repository/sqlcgen"
 	"exchange_point/backend/internal/service"
 	"log"
@@ -33,39 +34,58 @@ func (h *ReceiptHandler) GetReceiptByReference(c *fiber.Ctx) error {
 	}
 
 	// Ищем операцию в базе данных по номеру чека
-	operations, err := h.queries.ListOperations(c.Context(), sqlcgen.ListOperationsParams{
-		Limit:  100, // Задаем разумный лимит
-		Offset: 0,
-	})
-	if err != nil {
-		log.Printf("Error fetching operations: %v", err)
+	row, err := h.queries.GetOperationByReceiptReference(c.Context(), &receiptReference)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("Error fetching operation: %v", err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
-			"message": "Failed to retrieve operations",
+			"message": "Failed to retrieve operation",
 			"data":    err.Error(),
 		})
 	}
+	targetOperation := sqlcgen.ListOperationsRow(row)
 
-	// Ищем операцию с нужным номером чека
-	var targetOperation sqlcgen.ListOperationsRow
-	found := false
-	for _, op := range operations {
-		if op.ReceiptReference == receiptReference {
-			targetOperation = op
-			found = true
-			break
+	// Для кросс-конвертации печатается общий чек по обеим операциям.
+	// Номер чека может быть как номером одной из операций, так и общим номером конвертации.
+	exchangeGroup := ""
+	if err == sql.ErrNoRows {
+		exchangeGroup = receiptReference
+	} else if targetOperation.ExchangeGroup.Valid && targetOperation.Status != service.OperationStatusReversal {
+		exchangeGroup = targetOperation.ExchangeGroup.String
+	}
+	var legs []sqlcgen.ListOperationsRow
+	if exchangeGroup != "" {
+		groupRows, err := h.queries.ListOperationsByExchangeGroup(c.Context(), sql.NullString{String: exchangeGroup, Valid: true})
+		if err != nil {
+			log.Printf("Error fetching exchange operations: %v", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to retrieve operation",
+				"data":    err.Error(),
+			})
+		}
+		for _, r := range groupRows {
+			legs = append(legs, sqlcgen.ListOperationsRow(r))
 		}
 	}
 
-	if !found {
+	if targetOperation.ID == 0 && len(legs) == 0 {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Receipt not found",
 		})
 	}
 
-	// Генерируем PDF
-	pdfBytes, err := h.pdfService.GenerateReceiptFromOperation(targetOperation)
+	// Генерируем PDF: для сторно — отдельный чек со ссылкой на исходную операцию
+	var pdfBytes []byte
+	switch {
+	case len(legs) > 0:
+		pdfBytes, err = h.pdfService.GenerateCrossExchangeReceipt(legs)
+	case targetOperation.Status == service.OperationStatusReversal:
+		pdfBytes, err = h.pdfService.GenerateReversalReceipt(targetOperation)
+	default:
+		pdfBytes, err = h.pdfService.GenerateReceiptFromOperation(targetOperation)
+	}
 	if err != nil {
 		log.Printf("Error generating PDF receipt: %v", err)
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReferenceRateHandler struct {
+	store    postgresql.Store
+	cbr      *service.CBRClient
+	location *time.Location // Часовой пояс пункта обмена: в нём задаются даты курсов
+}
+
+func NewReferenceRateHandler(store postgresql.Store, cbr *service.CBRClient, location *time.Location) *ReferenceRateHandler {
+	return &ReferenceRateHandler{store: store, cbr: cbr, location: location}
+}
+
+// GetReferenceRates возвращает официальные курсы ЦБ, действующие на дату (параметр date, по умолчанию сегодня)
+func (h *ReferenceRateHandler) GetReferenceRates(c *fiber.Ctx) error {
+	date, err := parseBusinessDate(c.Query("date"), h.location)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid date", "data": err.Error()})
+	}
+	rates, err := h.store.ListReferenceRates(c.Context(), date)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve reference rates", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Reference rates retrieved successfully", "data": rates})
+}
+
+// ImportReferenceRates загружает официальные курсы ЦБ в формате XML_daily.asp:
+// из файла, переданного в поле file формы multipart, или с адреса CBR_RATES_URL на дату из параметра date.
+func (h *ReferenceRateHandler) ImportReferenceRates(c *fiber.Ctx) error {
+	var (
+		daily  service.CBRDaily
+		source string
+	)
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Could not read uploaded file", "data": err.Error()})
+		}
+		defer f.Close()
+		daily, err = service.ParseCBRDaily(f)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid reference rates file", "data": err.Error()})
+		}
+		source = "file:" + file.Filename
+	} else {
+		var date time.Time
+		if v := c.Query("date"); v != "" {
+			if date, err = parseBusinessDate(v, h.location); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid date", "data": err.Error()})
+			}
+		}
+		daily, source, err = h.cbr.FetchDaily(c.Context(), date)
+		if err != nil {
+			log.Printf("Error fetching reference rates from %s: %v", source, err)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"status": "error", "message": "Could not fetch reference rates", "data": err.Error()})
+		}
+	}
+
+	var rates []sqlcgen.ReferenceRate
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		var err error
+		rates, err = service.ImportReferenceRates(c.Context(), q, daily, source)
+		if err != nil {
+			return err
+		}
+		rateDate := daily.Date.Format("2006-01-02")
+		return audit(c, q, service.AuditReferenceRateImport, service.AuditEntityReferenceRate, rateDate, nil,
+			fiber.Map{"rate_date": rateDate, "source": source, "count": len(rates)})
+	})
+	if err != nil {
+		log.Printf("Error importing reference rates from %s: %v", source, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not import reference rates", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Reference rates imported successfully",
+		"data":    fiber.Map{"rate_date": daily.Date.Format("2006-01-02"), "source": source, "rates": rates},
+	})
+}
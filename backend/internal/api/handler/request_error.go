@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestError — ошибка, которую нужно вернуть клиенту с конкретным HTTP-статусом.
+// Используется внутри транзакций, где ответ ещё нельзя отправить.
+type requestError struct {
+	Status  int
+	Message string
+	Data    interface{}
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+func newRequestError(status int, format string, args ...interface{}) *requestError {
+	return &requestError{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// respondError отправляет ошибку клиенту: requestError — со своим статусом, остальные — как 500 с fallbackMessage
+func respondError(c *fiber.Ctx, err error, fallbackMessage string) error {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		body := fiber.Map{"status": "error", "message": reqErr.Message}
+		if reqErr.Data != nil {
+			body["data"] = reqErr.Data
+		}
+		return c.Status(reqErr.Status).JSON(body)
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": fallbackMessage, "data": err.Error()})
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"exchange_point/backend/internal/money"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+type CreateScheduledRateRequest struct {
+	BuyRate        decimal.Decimal `json:"buy_rate" validate:"required"`
+	SellRate       decimal.Decimal `json:"sell_rate" validate:"required"`
+	EffectiveAt    time.Time       `json:"effective_at" validate:"required"` // RFC 3339
+	Override       bool            `json:"override"`
+	OverrideReason string          `json:"override_reason"`
+}
+
+// currentCurrency загружает валюту, предварительно применив наступившие плановые изменения её курса
+func currentCurrency(ctx context.Context, q sqlcgen.Querier, rates *service.RateScheduler, currencyID int32) (sqlcgen.Currency, error) {
+	if _, err := rates.ApplyDue(ctx, q, sql.NullInt32{Int32: currencyID, Valid: true}); err != nil {
+		return sqlcgen.Currency{}, err
+	}
+	return q.GetCurrency(ctx, currencyID)
+}
+
+// ensureCurrentRate проверяет в транзакции записи операции, что она рассчитана по курсу, действующему
+// на время операции: плановое изменение курса могло вступить в силу между расчётом и записью.
+// Торговлю валютой за это время тоже могли приостановить.
+func ensureCurrentRate(ctx context.Context, q sqlcgen.Querier, rates *service.RateScheduler, calculated sqlcgen.Currency) error {
+	current, err := currentCurrency(ctx, q, rates, calculated.ID)
+	if err != nil {
+		return err
+	}
+	if err := ensureCurrencyActive(current); err != nil {
+		return err
+	}
+	if !current.BuyRate.Equal(calculated.BuyRate) || !current.SellRate.Equal(calculated.SellRate) {
+		return newRequestError(fiber.StatusConflict, "Rate of %s has changed, recalculate the operation", current.Code)
+	}
+	return nil
+}
+
+// loadCurrencyByCode загружает валюту по коду из параметра :code
+func (h *CurrencyHandler) loadCurrencyByCode(c *fiber.Ctx) (sqlcgen.Currency, error) {
+	currency, err := h.store.GetCurrencyByCode(c.Context(), strings.ToUpper(c.Params("code")))
+	if err == sql.ErrNoRows {
+		return currency, newRequestError(fiber.StatusNotFound, "Currency not found")
+	}
+	return currency, err
+}
+
+// CreateScheduledRate назначает изменение курса валюты на время effective_at.
+// Курсы проверяются сразу по правилам UpdateCurrency; при применении скачок и коридор ЦБ проверяются повторно.
+func (h *CurrencyHandler) CreateScheduledRate(c *fiber.Ctx) error {
+	req := new(CreateScheduledRateRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	currency, err := h.loadCurrencyByCode(c)
+	if err != nil {
+		return respondError(c, err, "Could not retrieve currency")
+	}
+	if err := ensureCurrencyNotDeleted(currency); err != nil {
+		return respondError(c, err, "")
+	}
+	if !req.EffectiveAt.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "effective_at must be in the future"})
+	}
+	buyRate, sellRate := money.RoundRate(req.BuyRate), money.RoundRate(req.SellRate)
+	if violations := service.ValidateRates(buyRate, sellRate); len(violations) > 0 {
+		return respondError(c, rateViolationError(violations), "Invalid rates")
+	}
+	reason := strings.TrimSpace(req.OverrideReason)
+	if len(reason) > service.MaxRateChangeReasonLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("override_reason must be at most %d bytes", service.MaxRateChangeReasonLength),
+		})
+	}
+	if !req.Override {
+		reason = ""
+	} else if reason == "" {
+		return respondError(c, rateViolationError([]service.RateViolation{{
+			Field:   "override_reason",
+			Code:    service.RateViolationReasonRequired,
+			Message: "override_reason is required with override",
+		}}), "Invalid rates")
+	}
+	// Скачок относительно текущего курса: без override изменение всё равно не применится
+	if jumps := h.rateChange.CheckJump(currency.BuyRate, currency.SellRate, buyRate, sellRate); len(jumps) > 0 && !req.Override {
+		return respondError(c, rateViolationError(jumps), "Invalid rates")
+	}
+
+	var (
+		schedule   sqlcgen.ScheduledRateChange
+		deviations []service.ReferenceRateDeviation
+	)
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		var err error
+		deviations, err = h.checkReferenceRate(c, q, currency.Code, req.EffectiveAt, buyRate, sellRate)
+		if err != nil {
+			return err
+		}
+		schedule, err = q.CreateScheduledRateChange(c.Context(), sqlcgen.CreateScheduledRateChangeParams{
+			CurrencyID:  currency.ID,
+			BuyRate:     buyRate,
+			SellRate:    sellRate,
+			EffectiveAt: req.EffectiveAt,
+			Override:    req.Override,
+			Reason:      sql.NullString{String: reason, Valid: reason != ""},
+			CreatedBy:   userIDParam(c),
+		})
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditRateScheduleCreate, service.AuditEntityRateSchedule, schedule.ID, nil, schedule)
+	})
+	if err != nil {
+		log.Printf("Error scheduling rate change for %s: %v", currency.Code, err)
+		return respondError(c, err, "Could not schedule rate change")
+	}
+	body := fiber.Map{"status": "success", "message": "Rate change scheduled successfully", "data": schedule}
+	if len(deviations) > 0 {
+		body["warnings"] = deviations
+	}
+	return c.Status(fiber.StatusCreated).JSON(body)
+}
+
+// GetScheduledRates возвращает плановые изменения курса валюты; по умолчанию — ещё не применённые (status=PENDING)
+func (h *CurrencyHandler) GetScheduledRates(c *fiber.Ctx) error {
+	currency, err := h.loadCurrencyByCode(c)
+	if err != nil {
+		return respondError(c, err, "Could not retrieve currency")
+	}
+	status := strings.ToUpper(c.Query("status", service.RateScheduleStatusPending))
+	switch status {
+	case service.RateScheduleStatusPending, service.RateScheduleStatusApplied, service.RateScheduleStatusCancelled, service.RateScheduleStatusFailed:
+	case "ALL":
+		status = ""
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid status", "data": status})
+	}
+	schedules, err := h.store.ListScheduledRateChanges(c.Context(), sqlcgen.ListScheduledRateChangesParams{CurrencyID: currency.ID, Status: status})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve scheduled rate changes", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Scheduled rate changes retrieved successfully", "data": schedules})
+}
+
+// CancelScheduledRate отменяет плановое изменение курса, пока оно не применено
+func (h *CurrencyHandler) CancelScheduledRate(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid scheduled rate change ID format"})
+	}
+	currency, err := h.loadCurrencyByCode(c)
+	if err != nil {
+		return respondError(c, err, "Could not retrieve currency")
+	}
+
+	var (
+		schedule sqlcgen.ScheduledRateChange
+		applied  []sqlcgen.Currency
+	)
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		// Наступившее изменение уже действует: сначала оно применяется, и отменить его нельзя
+		var err error
+		applied, err = h.rates.ApplyDue(c.Context(), q, sql.NullInt32{Int32: currency.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		before, err := q.GetScheduledRateChange(c.Context(), int32(id))
+		if err == sql.ErrNoRows || (err == nil && before.CurrencyID != currency.ID) {
+			return newRequestError(fiber.StatusNotFound, "Scheduled rate change not found")
+		}
+		if err != nil {
+			return err
+		}
+		schedule, err = q.CancelScheduledRateChange(c.Context(), sqlcgen.CancelScheduledRateChangeParams{
+			ID:          before.ID,
+			CurrencyID:  currency.ID,
+			CancelledBy: userIDParam(c),
+		})
+		if err == sql.ErrNoRows {
+			return newRequestError(fiber.StatusConflict, "Scheduled rate change in status %s cannot be cancelled", before.Status)
+		}
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditRateScheduleCancel, service.AuditEntityRateSchedule, schedule.ID, before, schedule)
+	})
+	if err != nil {
+		return respondError(c, err, "Could not cancel scheduled rate change")
+	}
+	h.rates.Publish(applied...)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Scheduled rate change cancelled successfully", "data": schedule})
+}
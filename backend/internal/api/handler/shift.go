@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"fmt"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// lockOpenShift возвращает открытую смену, в которой проводится операция.
+// Смена блокируется до конца транзакции, чтобы её не закрыли посреди операции.
+// Кассир работает только в своей смене; старший кассир и администратор — в любой.
+func lockOpenShift(c *fiber.Ctx, q sqlcgen.Querier) (sqlcgen.Shift, error) {
+	shift, err := q.LockOpenShift(c.Context())
+	if err == sql.ErrNoRows {
+		return shift, newRequestError(fiber.StatusConflict, "No open shift: open a cashier shift before performing operations")
+	}
+	if err != nil {
+		return shift, fmt.Errorf("could not load open shift: %w", err)
+	}
+	if err := ensureShiftOwner(c, shift); err != nil {
+		return shift, err
+	}
+	return shift, nil
+}
+
+// ensureShiftOwner отказывает кассиру, который не открывал смену shift
+func ensureShiftOwner(c *fiber.Ctx, shift sqlcgen.Shift) error {
+	return ensureCashier(c, shift.CashierID, "Shift %d is open by another cashier (%s)", shift.ID, shift.CashierName)
+}
+
+// ensureOperationOwner отказывает кассиру, который не создавал операцию op: подтвердить, завершить
+// или отменить чужой черновик может только старший кассир или администратор
+func ensureOperationOwner(c *fiber.Ctx, op sqlcgen.Operation) error {
+	return ensureCashier(c, op.CashierID, "Operation %d belongs to another cashier", op.ID)
+}
+
+// ensureCashier пропускает кассира cashierID, старшего кассира и администратора; остальным — 403 с сообщением format
+func ensureCashier(c *fiber.Ctx, cashierID sql.NullInt32, format string, args ...interface{}) error {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return newRequestError(fiber.StatusUnauthorized, "Authentication required")
+	}
+	if user.Role == service.RoleSupervisor || user.Role == service.RoleAdmin {
+		return nil
+	}
+	if !cashierID.Valid || cashierID.Int32 != user.ID {
+		return newRequestError(fiber.StatusForbidden, format, args...)
+	}
+	return nil
+}
+
+// shiftIDParam — ссылка на смену для записи операции
+func shiftIDParam(shift sqlcgen.Shift) sql.NullInt32 {
+	return sql.NullInt32{Int32: shift.ID, Valid: true}
+}
+
+// reconcileShift сверяет кассу на закрытие смены: ожидаемый остаток по каждой валюте —
+// остаток на открытие плюс движения наличных за смену, расхождение — пересчитанная сумма минус ожидаемая.
+// Пересчитанная сумма обязательна для каждой валюты с ненулевым ожидаемым остатком.
+func reconcileShift(ctx context.Context, q sqlcgen.Querier, shift sqlcgen.Shift, counted map[string]decimal.Decimal) ([]sqlcgen.ShiftBalance, error) {
+	openingBalances, err := q.ListShiftBalances(ctx, shift.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load opening balances: %w", err)
+	}
+	totals, err := q.GetShiftCashTotals(ctx, shiftIDParam(shift))
+	if err != nil {
+		return nil, fmt.Errorf("could not load shift cash movements: %w", err)
+	}
+
+	balances := make(map[string]*sqlcgen.SaveShiftBalanceParams)
+	balanceFor := func(code string) *sqlcgen.SaveShiftBalanceParams {
+		if _, ok := balances[code]; !ok {
+			balances[code] = &sqlcgen.SaveShiftBalanceParams{ShiftID: shift.ID, CurrencyCode: code}
+		}
+		return balances[code]
+	}
+	for _, opening := range openingBalances {
+		balanceFor(opening.CurrencyCode).OpeningBalance = opening.OpeningBalance
+	}
+	for _, total := range totals {
+		balance := balanceFor(total.CurrencyCode)
+		switch total.MovementType {
+		case service.CashMovementOperation, service.CashMovementReversal:
+			balance.OperationsAmount = balance.OperationsAmount.Add(total.TotalAmount)
+		case service.CashMovementIn:
+			balance.CashInAmount = balance.CashInAmount.Add(total.TotalAmount)
+		case service.CashMovementOut:
+			balance.CashOutAmount = balance.CashOutAmount.Sub(total.TotalAmount)
+		}
+	}
+	for code := range counted {
+		if _, ok := balances[code]; !ok {
+			return nil, newRequestError(fiber.StatusBadRequest, "Currency %s has no cash balance in this shift", code)
+		}
+	}
+
+	codes := make([]string, 0, len(balances))
+	for code := range balances {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	result := make([]sqlcgen.ShiftBalance, 0, len(codes))
+	for _, code := range codes {
+		params := balances[code]
+		expected := params.OpeningBalance.Add(params.OperationsAmount).Add(params.CashInAmount).Sub(params.CashOutAmount)
+		countedAmount, ok := counted[code]
+		if !ok && !expected.IsZero() {
+			return nil, newRequestError(fiber.StatusBadRequest, "Counted amount is required for %s: expected balance %s", code, expected.StringFixed(2))
+		}
+		if countedAmount.Sign() < 0 {
+			return nil, newRequestError(fiber.StatusBadRequest, "Counted amount for %s must not be negative", code)
+		}
+		params.ExpectedBalance = decimal.NewNullDecimal(expected)
+		params.CountedBalance = decimal.NewNullDecimal(countedAmount)
+		params.Discrepancy = decimal.NewNullDecimal(countedAmount.Sub(expected))
+
+		balance, err := q.SaveShiftBalance(ctx, *params)
+		if err != nil {
+			return nil, fmt.Errorf("could not save shift balance: %w", err)
+		}
+		result = append(result, balance)
+	}
+	return result, nil
+}
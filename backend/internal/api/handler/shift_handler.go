@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+type ShiftHandler struct {
+	store      postgresql.Store
+	pdfService *service.PdfService
+}
+
+func NewShiftHandler(store postgresql.Store, pdfService *service.PdfService) *ShiftHandler {
+	return &ShiftHandler{store: store, pdfService: pdfService}
+}
+
+type CloseShiftRequest struct {
+	Counted map[string]decimal.Decimal `json:"counted"` // Пересчитанные наличные по кодам валют
+	Comment string                     `json:"comment"`
+}
+
+func parseShiftID(c *fiber.Ctx) (int32, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	return int32(id), err
+}
+
+// OpenShift открывает смену аутентифицированного кассира и фиксирует остатки кассы на открытие
+func (h *ShiftHandler) OpenShift(c *fiber.Ctx) error {
+	user, _ := middleware.CurrentUser(c)
+	params := sqlcgen.OpenShiftParams{
+		CashierID:   sql.NullInt32{Int32: user.ID, Valid: true},
+		CashierName: user.FullName,
+	}
+
+	var shift sqlcgen.Shift
+	var balances []sqlcgen.ShiftBalance
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		var err error
+		shift, err = q.OpenShift(c.Context(), params)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return newRequestError(fiber.StatusConflict, "Another shift is already open")
+			}
+			return err
+		}
+		if err := q.SnapshotShiftOpeningBalances(c.Context(), shift.ID); err != nil {
+			return err
+		}
+		balances, err = q.ListShiftBalances(c.Context(), shift.ID)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditShiftOpen, service.AuditEntityShift, shift.ID, nil, fiber.Map{"shift": shift, "balances": balances})
+	})
+	if err != nil {
+		log.Printf("Error opening shift: %v", err)
+		return respondError(c, err, "Could not open shift")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Shift opened successfully",
+		"data":    fiber.Map{"shift": shift, "balances": balances},
+	})
+}
+
+// CloseShift закрывает открытую смену: сверяет пересчитанные наличные с ожидаемыми остатками
+// и записывает расхождения. Остатки кассы не корректируются: излишек или недостачу
+// оформляют отдельным движением ADJUSTMENT.
+func (h *ShiftHandler) CloseShift(c *fiber.Ctx) error {
+	req := new(CloseShiftRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	counted := make(map[string]decimal.Decimal, len(req.Counted))
+	for code, amount := range req.Counted {
+		counted[strings.ToUpper(code)] = amount
+	}
+
+	var shift sqlcgen.Shift
+	var balances []sqlcgen.ShiftBalance
+	err := h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		// Блокировка ждёт завершения операций, проводимых в смене
+		open, err := q.GetOpenShiftForUpdate(c.Context())
+		if err == sql.ErrNoRows {
+			return newRequestError(fiber.StatusConflict, "No open shift")
+		}
+		if err != nil {
+			return err
+		}
+		// Смену закрывает открывший её кассир; старший кассир и администратор — любую
+		if err := ensureShiftOwner(c, open); err != nil {
+			return err
+		}
+		balances, err = reconcileShift(c.Context(), q, open, counted)
+		if err != nil {
+			return err
+		}
+		params := sqlcgen.CloseShiftParams{ID: open.ID}
+		if req.Comment != "" {
+			params.CloseComment = sql.NullString{String: req.Comment, Valid: true}
+		}
+		shift, err = q.CloseShift(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditShiftClose, service.AuditEntityShift, shift.ID, open, fiber.Map{"shift": shift, "balances": balances})
+	})
+	if err != nil {
+		log.Printf("Error closing shift: %v", err)
+		return respondError(c, err, "Could not close shift")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Shift closed successfully",
+		"data":    fiber.Map{"shift": shift, "balances": balances},
+	})
+}
+
+// GetCurrentShift возвращает открытую смену
+func (h *ShiftHandler) GetCurrentShift(c *fiber.Ctx) error {
+	shift, err := h.store.GetOpenShift(c.Context())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "No open shift"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve shift", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Shift retrieved successfully", "data": shift})
+}
+
+// GetShifts возвращает смены постранично, новые первыми
+func (h *ShiftHandler) GetShifts(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	} else if pageSize > 100 {
+		pageSize = 100
+	}
+
+	shifts, err := h.store.ListShifts(c.Context(), sqlcgen.ListShiftsParams{
+		Limit:  int32(pageSize),
+		Offset: int32((page - 1) * pageSize),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve shifts", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Shifts retrieved successfully", "data": shifts})
+}
+
+// GetShift возвращает смену со сверкой кассы
+func (h *ShiftHandler) GetShift(c *fiber.Ctx) error {
+	id, err := parseShiftID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid shift ID format"})
+	}
+	shift, err := h.store.GetShift(c.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Shift not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve shift", "data": err.Error()})
+	}
+	balances, err := h.store.ListShiftBalances(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve shift balances", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Shift retrieved successfully",
+		"data":    fiber.Map{"shift": shift, "balances": balances},
+	})
+}
+
+// GetZReport возвращает PDF-отчёт о закрытии смены
+func (h *ShiftHandler) GetZReport(c *fiber.Ctx) error {
+	id, err := parseShiftID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid shift ID format"})
+	}
+	shift, err := h.store.GetShift(c.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Shift not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve shift", "data": err.Error()})
+	}
+	if shift.Status != service.ShiftStatusClosed {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": "Z-report is available only for a closed shift"})
+	}
+
+	operations, err := h.store.GetShiftOperationTotals(c.Context(), shiftIDParam(shift))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve shift operations", "data": err.Error()})
+	}
+	balances, err := h.store.ListShiftBalances(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve shift balances", "data": err.Error()})
+	}
+
+	pdfBytes, err := h.pdfService.GenerateZReport(shift, operations, balances)
+	if err != nil {
+		log.Printf("Error generating Z-report for shift %d: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Failed to generate Z-report", "data": err.Error()})
+	}
+
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", "inline; filename=z_report_"+strconv.Itoa(int(id))+".pdf")
+	return c.Send(pdfBytes)
+}
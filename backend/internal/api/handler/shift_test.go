@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestEnsureShiftOwner(t *testing.T) {
+	shift := sqlcgen.Shift{ID: 7, CashierID: sql.NullInt32{Int32: 1, Valid: true}, CashierName: "Кассир"}
+	tests := []struct {
+		name string
+		user *sqlcgen.User
+		want int
+	}{
+		{"owner", &sqlcgen.User{ID: 1, Role: service.RoleCashier}, fiber.StatusOK},
+		{"another cashier", &sqlcgen.User{ID: 2, Role: service.RoleCashier}, fiber.StatusForbidden},
+		{"supervisor", &sqlcgen.User{ID: 3, Role: service.RoleSupervisor}, fiber.StatusOK},
+		{"admin", &sqlcgen.User{ID: 4, Role: service.RoleAdmin}, fiber.StatusOK},
+		{"auditor", &sqlcgen.User{ID: 5, Role: service.RoleAuditor}, fiber.StatusForbidden},
+		{"not authenticated", nil, fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				if tt.user != nil {
+					middleware.SetCurrentUser(c, *tt.user)
+				}
+				if err := ensureShiftOwner(c, shift); err != nil {
+					return respondError(c, err, "")
+				}
+				return c.SendStatus(fiber.StatusOK)
+			})
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureOperationOwner(t *testing.T) {
+	op := sqlcgen.Operation{ID: 42, CashierID: sql.NullInt32{Int32: 1, Valid: true}}
+	tests := []struct {
+		name string
+		user *sqlcgen.User
+		op   sqlcgen.Operation
+		want int
+	}{
+		{"owner", &sqlcgen.User{ID: 1, Role: service.RoleCashier}, op, fiber.StatusOK},
+		{"another cashier", &sqlcgen.User{ID: 2, Role: service.RoleCashier}, op, fiber.StatusForbidden},
+		{"supervisor", &sqlcgen.User{ID: 3, Role: service.RoleSupervisor}, op, fiber.StatusOK},
+		{"admin", &sqlcgen.User{ID: 4, Role: service.RoleAdmin}, op, fiber.StatusOK},
+		{"operation without cashier", &sqlcgen.User{ID: 1, Role: service.RoleCashier}, sqlcgen.Operation{ID: 43}, fiber.StatusForbidden},
+		{"not authenticated", nil, op, fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				if tt.user != nil {
+					middleware.SetCurrentUser(c, *tt.user)
+				}
+				if err := ensureOperationOwner(c, tt.op); err != nil {
+					return respondError(c, err, "")
+				}
+				return c.SendStatus(fiber.StatusOK)
+			})
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UserHandler struct {
+	store postgresql.Store
+}
+
+func NewUserHandler(store postgresql.Store) *UserHandler {
+	return &UserHandler{store: store}
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	FullName string `json:"full_name" validate:"required"`
+	Role     string `json:"role" validate:"required"`
+}
+
+type UpdateUserRequest struct {
+	FullName *string `json:"full_name"`
+	Role     *string `json:"role"`
+	IsActive *bool   `json:"is_active"`
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+func parseUserID(c *fiber.Ctx) (int32, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 32)
+	return int32(id), err
+}
+
+// GetUsers возвращает всех пользователей
+func (h *UserHandler) GetUsers(c *fiber.Ctx) error {
+	users, err := h.store.ListUsers(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve users", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Users retrieved successfully", "data": users})
+}
+
+// GetUser возвращает пользователя по ID
+func (h *UserHandler) GetUser(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+	user, err := h.store.GetUser(c.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "User not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not retrieve user", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "User retrieved successfully", "data": user})
+}
+
+// CreateUser создаёт пользователя с заданной ролью
+func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
+	req := new(CreateUserRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	req.FullName = strings.TrimSpace(req.FullName)
+	if req.Username == "" || req.FullName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "username and full_name are required"})
+	}
+	if !service.IsValidRole(req.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid role", "data": req.Role})
+	}
+	if err := service.ValidatePassword(req.Password); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid password: " + err.Error()})
+	}
+	hash, err := service.HashPassword(req.Password)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create user", "data": err.Error()})
+	}
+
+	var user sqlcgen.User
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		var err error
+		user, err = q.CreateUser(c.Context(), sqlcgen.CreateUserParams{
+			Username:     req.Username,
+			PasswordHash: hash,
+			FullName:     req.FullName,
+			Role:         req.Role,
+		})
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditUserCreate, service.AuditEntityUser, user.ID, nil, user)
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": "User with this username already exists"})
+		}
+		log.Printf("Error creating user '%s': %v", req.Username, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create user", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "message": "User created successfully", "data": user})
+}
+
+// UpdateUser меняет имя, роль или активность пользователя.
+// Администратор не может заблокировать себя или снять с себя роль ADMIN, чтобы не потерять доступ.
+func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+	req := new(UpdateUserRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+
+	params := sqlcgen.UpdateUserParams{ID: id}
+	if req.FullName != nil {
+		fullName := strings.TrimSpace(*req.FullName)
+		if fullName == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "full_name must not be empty"})
+		}
+		params.FullName = sql.NullString{String: fullName, Valid: true}
+	}
+	if req.Role != nil {
+		if !service.IsValidRole(*req.Role) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid role", "data": *req.Role})
+		}
+		params.Role = sql.NullString{String: *req.Role, Valid: true}
+	}
+	if req.IsActive != nil {
+		params.IsActive = sql.NullBool{Bool: *req.IsActive, Valid: true}
+	}
+
+	if current, _ := middleware.CurrentUser(c); current.ID == id {
+		if (params.IsActive.Valid && !params.IsActive.Bool) || (params.Role.Valid && params.Role.String != service.RoleAdmin) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": "Administrators cannot deactivate or demote themselves"})
+		}
+	}
+
+	var user sqlcgen.User
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		before, err := q.GetUser(c.Context(), id)
+		if err != nil {
+			return err
+		}
+		user, err = q.UpdateUser(c.Context(), params)
+		if err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditUserUpdate, service.AuditEntityUser, id, before, user)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "User not found"})
+		}
+		log.Printf("Error updating user %d: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update user", "data": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "User updated successfully", "data": user})
+}
+
+// ResetPassword задаёт пользователю новый пароль без проверки текущего
+func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
+	id, err := parseUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid user ID format"})
+	}
+	req := new(ResetPasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Cannot parse JSON", "data": err.Error()})
+	}
+	err = h.store.RunInTx(c.Context(), func(q sqlcgen.Querier) error {
+		if _, err := q.GetUser(c.Context(), id); err != nil {
+			if err == sql.ErrNoRows {
+				return newRequestError(fiber.StatusNotFound, "User not found")
+			}
+			return err
+		}
+		if err := setUserPassword(c, q, id, req.Password); err != nil {
+			return err
+		}
+		return audit(c, q, service.AuditUserPasswordReset, service.AuditEntityUser, id, nil, nil)
+	})
+	if err != nil {
+		return respondError(c, err, "Could not reset password")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"status": "success", "message": "Password reset successfully"})
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"database/sql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/service"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Ключ c.Locals, под которым хранится аутентифицированный пользователь
+const currentUserKey = "currentUser"
+
+// NewAuth возвращает middleware, пропускающее только запросы с действительным токеном
+// в заголовке Authorization: Bearer <token>. Пользователь загружается из базы на каждый запрос,
+// поэтому блокировка пользователя и смена роли действуют сразу, не дожидаясь истечения токена.
+// EventSource в браузере не умеет передавать заголовки, поэтому потоки Server-Sent Events
+// (Accept: text/event-stream) принимают вместо токена короткоживущий билет в параметре ticket,
+// выданный POST /auth/stream-ticket. Сам токен доступа в адресе запроса не принимается.
+func NewAuth(auth *service.AuthService, q sqlcgen.Querier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		token, found := strings.CutPrefix(header, "Bearer ")
+		parse := auth.ParseToken
+		if !found && strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream") {
+			token, found = c.Query("ticket"), true
+			parse = auth.ParseStreamTicket
+		}
+		if !found || token == "" {
+			return unauthorized(c, "Missing bearer token")
+		}
+		claims, err := parse(token)
+		if err != nil {
+			return unauthorized(c, "Invalid or expired token")
+		}
+		userID, err := claims.UserID()
+		if err != nil {
+			return unauthorized(c, "Invalid or expired token")
+		}
+
+		user, err := q.GetUser(c.Context(), userID)
+		if err == sql.ErrNoRows || (err == nil && !user.IsActive) {
+			return unauthorized(c, "User is not active")
+		}
+		if err != nil {
+			log.Printf("Error loading user %d: %v", userID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not authenticate request", "data": err.Error()})
+		}
+
+		SetCurrentUser(c, user)
+		return c.Next()
+	}
+}
+
+// RequireRoles пропускает только пользователей с одной из ролей roles
+func RequireRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user, ok := CurrentUser(c)
+		if !ok {
+			return unauthorized(c, "Authentication required")
+		}
+		for _, role := range roles {
+			if user.Role == role {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Insufficient permissions for role " + user.Role})
+	}
+}
+
+// CurrentUser возвращает пользователя, аутентифицированного NewAuth
+func CurrentUser(c *fiber.Ctx) (sqlcgen.User, bool) {
+	user, ok := c.Locals(currentUserKey).(sqlcgen.User)
+	return user, ok
+}
+
+// SetCurrentUser запоминает аутентифицированного пользователя запроса
+func SetCurrentUser(c *fiber.Ctx, user sqlcgen.User) {
+	c.Locals(currentUserKey, user)
+}
+
+func unauthorized(c *fiber.Ctx, message string) error {
+	c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": message})
+}
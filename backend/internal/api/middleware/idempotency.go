@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Заголовок с ключом идемпотентности
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Максимальная длина ключа (idempotency_keys.idempotency_key)
+const maxIdempotencyKeyLength = 255
+
+// Сколько ключ остаётся занятым запросом. Если процесс упал, не сохранив ответ,
+// повтор с тем же ключом после этого срока проверяет, успел ли запрос что-то изменить.
+const idempotencyLease = 2 * time.Minute
+
+// Ключ c.Locals, под которым хранится занятый запросом ключ идемпотентности
+const idempotencyClaimKey = "idempotencyClaim"
+
+// NewIdempotency возвращает middleware для POST-запросов с заголовком Idempotency-Key.
+// Ключ уникален в пределах пользователя: одинаковые ключи разных пользователей независимы.
+// Первый запрос с ключом выполняется, и его ответ сохраняется; повтор с тем же телом
+// получает сохранённый ответ без повторного выполнения, а с другим телом — 409.
+// Ответы 5xx не сохраняются, чтобы запрос можно было повторить после сбоя.
+// Обработчик привязывает к ключу изменённую сущность в своей транзакции (BindIdempotencyKey):
+// если ответ не сохранился из-за падения процесса, повтор после idempotencyLease получает ссылку
+// на сущность вместо повторного выполнения, а запрос, который ничего не изменил, выполняется заново.
+func NewIdempotency(q sqlcgen.Querier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		user, ok := CurrentUser(c)
+		if c.Method() != fiber.MethodPost || key == "" || !ok {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Idempotency-Key is too long"})
+		}
+
+		hash := requestHash(c)
+		claim, err := claimKey(c, q, user.ID, key, hash)
+		if err == sql.ErrNoRows {
+			// Ключ уже использован: отдаём сохранённый ответ
+			return replay(c, q, user.ID, key, hash)
+		}
+		if err != nil {
+			log.Printf("Error storing idempotency key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not process idempotency key", "data": err.Error()})
+		}
+		c.Locals(idempotencyClaimKey, claim)
+
+		if err := c.Next(); err != nil {
+			release(c, q, claim)
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			release(c, q, claim)
+			return nil
+		}
+
+		// Тело копируется: буфер ответа fasthttp переиспользуется после завершения запроса
+		body := append([]byte(nil), c.Response().Body()...)
+		err = q.CompleteIdempotencyKey(c.Context(), sqlcgen.CompleteIdempotencyKeyParams{
+			UserID:              user.ID,
+			IdempotencyKey:      key,
+			ClaimNumber:         claim.ClaimNumber,
+			ResponseStatus:      sql.NullInt32{Int32: int32(status), Valid: true},
+			ResponseContentType: sql.NullString{String: string(c.Response().Header.ContentType()), Valid: true},
+			ResponseBody:        body,
+		})
+		if err != nil {
+			log.Printf("Error saving response for idempotency key %s: %v", key, err)
+		}
+		return nil
+	}
+}
+
+// claimKey занимает новый ключ или ключ, аренда которого истекла, а запрос ничего не изменил.
+// sql.ErrNoRows — ключ занят или уже использован.
+func claimKey(c *fiber.Ctx, q sqlcgen.Querier, userID int32, key, hash string) (sqlcgen.IdempotencyKey, error) {
+	claim, err := q.CreateIdempotencyKey(c.Context(), sqlcgen.CreateIdempotencyKeyParams{
+		UserID:         userID,
+		IdempotencyKey: key,
+		RequestMethod:  c.Method(),
+		RequestPath:    c.Path(),
+		RequestHash:    hash,
+		LeaseSeconds:   int32(idempotencyLease / time.Second),
+	})
+	if err != sql.ErrNoRows {
+		return claim, err
+	}
+	return q.ReclaimIdempotencyKey(c.Context(), sqlcgen.ReclaimIdempotencyKeyParams{
+		UserID:         userID,
+		IdempotencyKey: key,
+		RequestHash:    hash,
+		LeaseSeconds:   int32(idempotencyLease / time.Second),
+	})
+}
+
+// BindIdempotencyKey привязывает к ключу идемпотентности запроса сущность, которую он изменил.
+// Вызывается в транзакции обработчика, поэтому привязка сохраняется тогда и только тогда, когда сохранено изменение.
+// Если ключ за это время занял повторный запрос, возвращается ошибка, и транзакция должна откатиться.
+func BindIdempotencyKey(c *fiber.Ctx, q sqlcgen.Querier, entityType, entityID string) error {
+	claim, ok := c.Locals(idempotencyClaimKey).(sqlcgen.IdempotencyKey)
+	if !ok {
+		return nil
+	}
+	rows, err := q.BindIdempotencyKey(c.Context(), sqlcgen.BindIdempotencyKeyParams{
+		UserID:         claim.UserID,
+		IdempotencyKey: claim.IdempotencyKey,
+		ClaimNumber:    claim.ClaimNumber,
+		EntityType:     sql.NullString{String: entityType, Valid: true},
+		EntityID:       sql.NullString{String: entityID, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("idempotency key lease expired and was claimed by another request")
+	}
+	return nil
+}
+
+// requestHash — SHA-256 метода, пути и тела запроса
+func requestHash(c *fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Path()))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay возвращает сохранённый ответ или 409, если ключ использован для другого запроса
+func replay(c *fiber.Ctx, q sqlcgen.Querier, userID int32, key, hash string) error {
+	stored, err := q.GetIdempotencyKey(c.Context(), sqlcgen.GetIdempotencyKeyParams{UserID: userID, IdempotencyKey: key})
+	if err != nil {
+		log.Printf("Error loading idempotency key %s: %v", key, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not process idempotency key", "data": err.Error()})
+	}
+	if stored.RequestHash != hash {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": "Idempotency-Key was already used with a different request"})
+	}
+	if !stored.ResponseStatus.Valid {
+		// Запрос изменил данные, но ответ не сохранён (процесс упал): повторять его нельзя
+		if stored.EntityID.Valid && time.Now().After(stored.LockedUntil) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "A request with this Idempotency-Key was completed, but its response was not saved",
+				"data":    fiber.Map{"entity_type": stored.EntityType.String, "entity_id": stored.EntityID.String},
+			})
+		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": "A request with this Idempotency-Key is still being processed"})
+	}
+
+	c.Set("Idempotent-Replayed", "true")
+	if stored.ResponseContentType.Valid {
+		c.Set(fiber.HeaderContentType, stored.ResponseContentType.String)
+	}
+	return c.Status(int(stored.ResponseStatus.Int32)).Send(stored.ResponseBody)
+}
+
+// release удаляет ключ, чтобы запрос можно было повторить. Ключ, который уже занял повторный запрос, не трогается.
+func release(c *fiber.Ctx, q sqlcgen.Querier, claim sqlcgen.IdempotencyKey) {
+	err := q.DeleteIdempotencyKey(c.Context(), sqlcgen.DeleteIdempotencyKeyParams{
+		UserID:         claim.UserID,
+		IdempotencyKey: claim.IdempotencyKey,
+		ClaimNumber:    claim.ClaimNumber,
+	})
+	if err != nil {
+		log.Printf("Error releasing idempotency key %s: %v", claim.IdempotencyKey, err)
+	}
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/postgresql/pgtest"
+	"exchange_point/backend/internal/repository/sqlcgen"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Один и тот же ключ у разных пользователей не пересекается: запрос второго пользователя выполняется,
+// а не получает сохранённый ответ первого; повтор первого пользователя получает его собственный ответ.
+func TestIdempotencyKeyIsScopedToUser(t *testing.T) {
+	db := pgtest.NewDB(t)
+	store := postgresql.NewStore(db)
+	ctx := context.Background()
+
+	users := make(map[string]sqlcgen.User)
+	for _, name := range []string{"alice", "bob"} {
+		user, err := store.CreateUser(ctx, sqlcgen.CreateUserParams{Username: name, PasswordHash: "-", FullName: name, Role: "CASHIER"})
+		if err != nil {
+			t.Fatalf("create user %s: %v", name, err)
+		}
+		users[name] = user
+	}
+
+	calls := 0
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		SetCurrentUser(c, users[c.Get("X-User")])
+		return c.Next()
+	})
+	app.Use(NewIdempotency(store))
+	app.Post("/orders", func(c *fiber.Ctx) error {
+		calls++
+		user, _ := CurrentUser(c)
+		return c.Status(fiber.StatusCreated).SendString(user.Username)
+	})
+
+	send := func(user string) (string, bool) {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set("X-User", user)
+		req.Header.Set(IdempotencyKeyHeader, "same-key")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != fiber.StatusCreated {
+			t.Fatalf("%s: status %d, want %d", user, resp.StatusCode, fiber.StatusCreated)
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body), resp.Header.Get("Idempotent-Replayed") == "true"
+	}
+
+	if body, replayed := send("alice"); body != "alice" || replayed {
+		t.Fatalf("alice: body %q, replayed %v", body, replayed)
+	}
+	if body, replayed := send("bob"); body != "bob" || replayed {
+		t.Fatalf("bob: body %q, replayed %v; want a fresh response", body, replayed)
+	}
+	if body, replayed := send("alice"); body != "alice" || !replayed {
+		t.Fatalf("alice retry: body %q, replayed %v; want the saved response", body, replayed)
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
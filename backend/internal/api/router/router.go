@@ -3,44 +3,156 @@ package router
 import (
 	"database/sql"
 	"exchange_point/backend/internal/api/handler"
-	"exchange_point/backend/internal/repository/sqlcgen"
+	"exchange_point/backend/internal/api/middleware"
+	"exchange_point/backend/internal/config"
+	"exchange_point/backend/internal/repository/postgresql"
 	"exchange_point/backend/internal/service"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 )
 
-func SetupRoutes(app *fiber.App, dbConnection *sql.DB) {
-	queries := sqlcgen.New(dbConnection)
+func SetupRoutes(app *fiber.App, dbConnection *sql.DB, cfg *config.Config, rateScheduler *service.RateScheduler, rateBroadcaster *service.RateBroadcaster) {
+	store := postgresql.NewStore(dbConnection)
 
 	healthHandler := handler.NewHealthHandler()
-	clientHandler := handler.NewClientHandler(queries)
-	currencyHandler := handler.NewCurrencyHandler(queries)
-	operationHandler := handler.NewOperationHandler(queries)
-	analyticsHandler := handler.NewAnalyticsHandler(queries)
-	receiptHandler := handler.NewReceiptHandler(queries, service.NewPdfService())
+	clientHandler := handler.NewClientHandler(store)
+	currencyHandler := handler.NewCurrencyHandler(store, cfg.BusinessLocation, cfg.ReferenceRatePolicy, cfg.RateChangePolicy, rateScheduler, rateBroadcaster)
+	receiptNumbering := service.NewReceiptNumbering(cfg.BranchCode, cfg.ReceiptNumberTemplate, cfg.BusinessLocation)
+	operationHandler := handler.NewOperationHandler(store, receiptNumbering, cfg.BusinessLocation, cfg.DraftTTL, rateScheduler)
+	operationLimitHandler := handler.NewOperationLimitHandler(store)
+	operationFeeHandler := handler.NewOperationFeeHandler(store)
+	quoteHandler := handler.NewQuoteHandler(store, cfg.QuoteTTL, rateScheduler)
+	analyticsHandler := handler.NewAnalyticsHandler(store)
+	pdfService := service.NewPdfService()
+	receiptHandler := handler.NewReceiptHandler(store, pdfService)
+	cashHandler := handler.NewCashHandler(store)
+	shiftHandler := handler.NewShiftHandler(store, pdfService)
+	authService := service.NewAuthService(cfg.JWTSecret, cfg.JWTTTL)
+
+	authHandler := handler.NewAuthHandler(store, authService)
+	userHandler := handler.NewUserHandler(store)
+	auditHandler := handler.NewAuditHandler(store, cfg.BusinessLocation)
+	referenceRateHandler := handler.NewReferenceRateHandler(store, service.NewCBRClient(cfg.CBRRatesURL), cfg.BusinessLocation)
+	rateBoardHandler := handler.NewRateBoardHandler(store, cfg.BusinessLocation)
 
 	api := app.Group("/api/v1")
 
-	// Health
+	// Без аутентификации доступны только проверка состояния и вход
 	api.Get("/health", healthHandler.HealthCheck)
+	api.Post("/auth/login", authHandler.Login)
+
+	// Публичное табло курсов для экрана в зале; число запросов с одного адреса ограничено
+	publicLimit := limiter.New(limiter.Config{
+		Max:        cfg.PublicRateBoardLimit,
+		Expiration: time.Minute,
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"status": "error", "message": "Too many requests"})
+		},
+	})
+	api.Get("/public/rates", publicLimit, rateBoardHandler.GetRates)
+	api.Get("/public/rates/board", publicLimit, rateBoardHandler.GetBoardPage)
+
+	api.Use(middleware.NewAuth(authService, store))
+
+	// POST-запросы с заголовком Idempotency-Key выполняются не более одного раза
+	api.Use(middleware.NewIdempotency(store))
+
+	// Читать данные могут все роли, аудитор — только читать
+	anyRole := middleware.RequireRoles(service.RoleCashier, service.RoleSupervisor, service.RoleAdmin, service.RoleAuditor)
+	// Операции с клиентами и кассой проводят кассиры, старшие кассиры и администраторы
+	cashier := middleware.RequireRoles(service.RoleCashier, service.RoleSupervisor, service.RoleAdmin)
+	// Курсы, лимиты, комиссии, резервы и сторно — старшие кассиры и администраторы
+	supervisor := middleware.RequireRoles(service.RoleSupervisor, service.RoleAdmin)
+	admin := middleware.RequireRoles(service.RoleAdmin)
+	// Журнал аудита читают аудиторы и администраторы
+	auditor := middleware.RequireRoles(service.RoleAuditor, service.RoleAdmin)
+
+	// Auth
+	api.Get("/auth/me", anyRole, authHandler.Me)
+	api.Put("/auth/password", anyRole, authHandler.ChangePassword)
+	api.Post("/auth/stream-ticket", anyRole, authHandler.IssueStreamTicket)
+
+	// Users
+	api.Get("/users", admin, userHandler.GetUsers)
+	api.Post("/users", admin, userHandler.CreateUser)
+	api.Get("/users/:id", admin, userHandler.GetUser)
+	api.Put("/users/:id", admin, userHandler.UpdateUser)
+	api.Put("/users/:id/password", admin, userHandler.ResetPassword)
+
+	// Audit log
+	api.Get("/audit/events", auditor, auditHandler.GetEvents)
+	api.Get("/audit/verify", auditor, auditHandler.VerifyChain)
 
 	// Clients
-	api.Get("/clients", clientHandler.GetClients)
-	api.Post("/clients", clientHandler.CreateClient)
-	api.Get("/clients/:id", clientHandler.GetClientByID)
+	api.Get("/clients", anyRole, clientHandler.GetClients)
+	api.Post("/clients", cashier, clientHandler.CreateClient)
+	api.Get("/clients/:id", anyRole, clientHandler.GetClientByID)
 
 	// Currencies
-	api.Get("/currencies", currencyHandler.GetCurrencies)
-	api.Post("/currencies", currencyHandler.CreateCurrency)
-	api.Put("/currencies", currencyHandler.UpdateCurrency)
+	api.Get("/currencies", anyRole, currencyHandler.GetCurrencies)
+	api.Get("/currencies/stream", anyRole, currencyHandler.StreamRates)
+	api.Post("/currencies", supervisor, currencyHandler.CreateCurrency)
+	api.Put("/currencies", supervisor, currencyHandler.UpdateCurrency)
+	api.Put("/currencies/:code/rounding", supervisor, currencyHandler.UpdateCurrencyRounding)
+	api.Post("/currencies/:code/activate", supervisor, currencyHandler.ActivateCurrency)
+	api.Post("/currencies/:code/deactivate", supervisor, currencyHandler.DeactivateCurrency)
+	api.Delete("/currencies/:code", supervisor, currencyHandler.DeleteCurrency)
+	api.Get("/currencies/:code/rates", anyRole, currencyHandler.GetRateHistory)
+	api.Get("/currencies/:code/scheduled-rates", anyRole, currencyHandler.GetScheduledRates)
+	api.Post("/currencies/:code/scheduled-rates", supervisor, currencyHandler.CreateScheduledRate)
+	api.Post("/currencies/:code/scheduled-rates/:id/cancel", supervisor, currencyHandler.CancelScheduledRate)
+
+	// CBR reference rates
+	api.Get("/reference-rates", anyRole, referenceRateHandler.GetReferenceRates)
+	api.Post("/reference-rates/import", supervisor, referenceRateHandler.ImportReferenceRates)
 
 	// Operations
-	api.Get("/operations", operationHandler.GetOperations)
-	api.Post("/operations", operationHandler.CreateOperation)
+	api.Get("/operations", anyRole, operationHandler.GetOperations)
+	api.Post("/operations", cashier, operationHandler.CreateOperation)
+	api.Post("/operations/drafts", cashier, operationHandler.CreateDraftOperation)
+	api.Post("/operations/:id/confirm", cashier, operationHandler.ConfirmOperation)
+	api.Post("/operations/:id/complete", cashier, operationHandler.CompleteOperation)
+	api.Post("/operations/:id/cancel", cashier, operationHandler.CancelOperation)
+	api.Post("/operations/:id/reverse", supervisor, operationHandler.ReverseOperation)
+
+	// Quotes
+	api.Post("/quotes", cashier, quoteHandler.CreateQuote)
+	api.Get("/quotes/:id", anyRole, quoteHandler.GetQuote)
+
+	// Operation limits
+	api.Get("/limits", anyRole, operationLimitHandler.GetLimits)
+	api.Post("/limits", supervisor, operationLimitHandler.CreateLimit)
+	api.Get("/limits/:name", anyRole, operationLimitHandler.GetLimit)
+	api.Put("/limits/:name", supervisor, operationLimitHandler.UpdateLimit)
+	api.Delete("/limits/:name", supervisor, operationLimitHandler.DeleteLimit)
+
+	// Operation fees
+	api.Get("/fees", anyRole, operationFeeHandler.GetFees)
+	api.Post("/fees", supervisor, operationFeeHandler.CreateFee)
+	api.Get("/fees/:id", anyRole, operationFeeHandler.GetFee)
+	api.Put("/fees/:id", supervisor, operationFeeHandler.UpdateFee)
+	api.Delete("/fees/:id", supervisor, operationFeeHandler.DeleteFee)
+
+	// Cashier shifts
+	api.Post("/shifts/open", cashier, shiftHandler.OpenShift)
+	api.Post("/shifts/close", cashier, shiftHandler.CloseShift)
+	api.Get("/shifts", anyRole, shiftHandler.GetShifts)
+	api.Get("/shifts/current", anyRole, shiftHandler.GetCurrentShift)
+	api.Get("/shifts/:id", anyRole, shiftHandler.GetShift)
+	api.Get("/shifts/:id/z-report", anyRole, shiftHandler.GetZReport)
+
+	// Cash drawer
+	api.Get("/cash/balances", anyRole, cashHandler.GetBalances)
+	api.Put("/cash/balances/:code/reserve", supervisor, cashHandler.UpdateReserve)
+	api.Get("/cash/movements", anyRole, cashHandler.GetMovements)
+	api.Post("/cash/in", cashier, cashHandler.CashIn)
+	api.Post("/cash/out", cashier, cashHandler.CashOut)
 
 	// Analytics
-	api.Get("/analytics/operations", analyticsHandler.GetOperationsAnalytics)
+	api.Get("/analytics/operations", anyRole, analyticsHandler.GetOperationsAnalytics)
 
 	// Receipts
-	api.Get("/receipts/:reference", receiptHandler.GetReceiptByReference)
+	api.Get("/receipts/:reference", anyRole, receiptHandler.GetReceiptByReference)
 }
@@ -1,15 +1,70 @@
 package config
 
 import (
+	"exchange_point/backend/internal/service"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
 )
 
 type Config struct {
 	DatabaseURL string
 	AppPort     string
+	// Часовой пояс пункта обмена: по нему определяются границы дня для дневных лимитов
+	BusinessLocation *time.Location
+	// Время жизни черновика операции до подтверждения
+	DraftTTL time.Duration
+	// Время действия котировки курса
+	QuoteTTL time.Duration
+	// Срок хранения ключей идемпотентности
+	IdempotencyKeyTTL time.Duration
+	// Код отделения: входит в номер чека, нумерация ведётся отдельно по каждому отделению
+	BranchCode string
+	// Шаблон номера чека, см. service.DefaultReceiptNumberTemplate
+	ReceiptNumberTemplate string
+	// Секрет подписи JWT и время жизни токена
+	JWTSecret string
+	JWTTTL    time.Duration
+	// Администратор, создаваемый при первом запуске, когда пользователей ещё нет
+	AdminUsername string
+	AdminPassword string
+	// Источники, которым разрешены запросы из браузера (CORS), через запятую
+	CORSAllowOrigins string
+	// Адрес официальных курсов ЦБ РФ в формате XML_daily.asp и интервал их загрузки (0 — только вручную)
+	CBRRatesURL         string
+	CBRImportInterval   time.Duration
+	ReferenceRatePolicy service.ReferenceRatePolicy
+	// Наибольший скачок курса относительно прежнего без подтверждения старшего кассира
+	RateChangePolicy service.RateChangePolicy
+	// Сколько запросов в минуту принимается с одного адреса к публичному табло курсов
+	PublicRateBoardLimit int
+}
+
+// Минимальная длина секрета JWT: для HS256 нужно не меньше 256 бит
+const minJWTSecretLength = 32
+
+// Признаки секрета-заглушки из примеров конфигурации ("change-me-to-a-long-random-secret-value"):
+// с таким секретом токен может подделать любой, кто видел пример
+var placeholderJWTSecretMarkers = []string{"change-me", "changeme"}
+
+// validateJWTSecret проверяет длину секрета JWT и отказывает в заглушках из примеров
+func validateJWTSecret(secret string) error {
+	if len(secret) < minJWTSecretLength {
+		return fmt.Errorf("JWT_SECRET must be set and at least %d characters long", minJWTSecretLength)
+	}
+	lower := strings.ToLower(secret)
+	for _, marker := range placeholderJWTSecretMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("JWT_SECRET looks like an example placeholder: generate a random value, e.g. openssl rand -hex 32")
+		}
+	}
+	return nil
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -32,8 +87,140 @@ func LoadConfig(path string) (*Config, error) {
 		appPort = "8080"
 	}
 
+	businessTZ := os.Getenv("BUSINESS_TIMEZONE")
+	if businessTZ == "" {
+		businessTZ = "Europe/Moscow"
+	}
+	businessLocation, err := time.LoadLocation(businessTZ)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BUSINESS_TIMEZONE '%s': %w", businessTZ, err)
+	}
+
+	draftTTL := 15 * time.Minute
+	if v := os.Getenv("DRAFT_TTL"); v != "" {
+		draftTTL, err = time.ParseDuration(v)
+		if err != nil || draftTTL <= 0 {
+			return nil, fmt.Errorf("invalid DRAFT_TTL '%s'", v)
+		}
+	}
+
+	quoteTTL := 2 * time.Minute
+	if v := os.Getenv("QUOTE_TTL"); v != "" {
+		quoteTTL, err = time.ParseDuration(v)
+		if err != nil || quoteTTL <= 0 {
+			return nil, fmt.Errorf("invalid QUOTE_TTL '%s'", v)
+		}
+	}
+
+	idempotencyKeyTTL := 24 * time.Hour
+	if v := os.Getenv("IDEMPOTENCY_KEY_TTL"); v != "" {
+		idempotencyKeyTTL, err = time.ParseDuration(v)
+		if err != nil || idempotencyKeyTTL <= 0 {
+			return nil, fmt.Errorf("invalid IDEMPOTENCY_KEY_TTL '%s'", v)
+		}
+	}
+
+	branchCode := os.Getenv("BRANCH_CODE")
+	if branchCode == "" {
+		branchCode = "MSK01"
+	}
+	if len(branchCode) > 20 {
+		return nil, fmt.Errorf("invalid BRANCH_CODE '%s': must be at most 20 characters", branchCode)
+	}
+
+	receiptNumberTemplate := os.Getenv("RECEIPT_NUMBER_TEMPLATE")
+	if receiptNumberTemplate == "" {
+		receiptNumberTemplate = service.DefaultReceiptNumberTemplate
+	}
+	if err := service.ValidateReceiptNumberTemplate(receiptNumberTemplate); err != nil {
+		return nil, fmt.Errorf("invalid RECEIPT_NUMBER_TEMPLATE '%s': %w", receiptNumberTemplate, err)
+	}
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if err := validateJWTSecret(jwtSecret); err != nil {
+		return nil, err
+	}
+
+	jwtTTL := 12 * time.Hour
+	if v := os.Getenv("JWT_TTL"); v != "" {
+		jwtTTL, err = time.ParseDuration(v)
+		if err != nil || jwtTTL <= 0 {
+			return nil, fmt.Errorf("invalid JWT_TTL '%s'", v)
+		}
+	}
+
+	adminUsername := os.Getenv("ADMIN_USERNAME")
+	if adminUsername == "" {
+		adminUsername = "admin"
+	}
+
+	corsAllowOrigins := os.Getenv("CORS_ALLOW_ORIGINS")
+	if corsAllowOrigins == "" {
+		corsAllowOrigins = "http://localhost:3000"
+	}
+
+	cbrRatesURL := os.Getenv("CBR_RATES_URL")
+	if cbrRatesURL == "" {
+		cbrRatesURL = service.DefaultCBRDailyURL
+	}
+
+	var cbrImportInterval time.Duration
+	if v := os.Getenv("CBR_IMPORT_INTERVAL"); v != "" {
+		cbrImportInterval, err = time.ParseDuration(v)
+		if err != nil || cbrImportInterval < 0 {
+			return nil, fmt.Errorf("invalid CBR_IMPORT_INTERVAL '%s'", v)
+		}
+	}
+
+	referenceRatePolicy := service.ReferenceRatePolicy{MaxDeviationPercent: decimal.NewFromInt(10)}
+	if v := os.Getenv("REFERENCE_RATE_MAX_DEVIATION_PERCENT"); v != "" {
+		referenceRatePolicy.MaxDeviationPercent, err = decimal.NewFromString(v)
+		if err != nil || referenceRatePolicy.MaxDeviationPercent.IsNegative() {
+			return nil, fmt.Errorf("invalid REFERENCE_RATE_MAX_DEVIATION_PERCENT '%s'", v)
+		}
+	}
+	switch v := os.Getenv("REFERENCE_RATE_DEVIATION_MODE"); v {
+	case "", "WARN":
+	case "REJECT":
+		referenceRatePolicy.Reject = true
+	default:
+		return nil, fmt.Errorf("invalid REFERENCE_RATE_DEVIATION_MODE '%s': must be WARN or REJECT", v)
+	}
+
+	rateChangePolicy := service.RateChangePolicy{MaxJumpPercent: decimal.NewFromInt(10)}
+	if v := os.Getenv("RATE_MAX_JUMP_PERCENT"); v != "" {
+		rateChangePolicy.MaxJumpPercent, err = decimal.NewFromString(v)
+		if err != nil || rateChangePolicy.MaxJumpPercent.IsNegative() {
+			return nil, fmt.Errorf("invalid RATE_MAX_JUMP_PERCENT '%s'", v)
+		}
+	}
+
+	publicRateBoardLimit := 60
+	if v := os.Getenv("PUBLIC_RATE_BOARD_LIMIT"); v != "" {
+		publicRateBoardLimit, err = strconv.Atoi(v)
+		if err != nil || publicRateBoardLimit <= 0 {
+			return nil, fmt.Errorf("invalid PUBLIC_RATE_BOARD_LIMIT '%s'", v)
+		}
+	}
+
 	return &Config{
-		DatabaseURL: dbURL,
-		AppPort:     appPort,
+		DatabaseURL:           dbURL,
+		AppPort:               appPort,
+		BusinessLocation:      businessLocation,
+		DraftTTL:              draftTTL,
+		QuoteTTL:              quoteTTL,
+		IdempotencyKeyTTL:     idempotencyKeyTTL,
+		BranchCode:            branchCode,
+		ReceiptNumberTemplate: receiptNumberTemplate,
+		JWTSecret:             jwtSecret,
+		JWTTTL:                jwtTTL,
+		AdminUsername:         adminUsername,
+		AdminPassword:         os.Getenv("ADMIN_PASSWORD"),
+		CORSAllowOrigins:      corsAllowOrigins,
+		CBRRatesURL:           cbrRatesURL,
+		CBRImportInterval:     cbrImportInterval,
+		ReferenceRatePolicy:   referenceRatePolicy,
+		RateChangePolicy:      rateChangePolicy,
+		PublicRateBoardLimit:  publicRateBoardLimit,
 	}, nil
 }
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateJWTSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{"random", "3f9c1b7e5a2d4c6e8f0a1b2c3d4e5f60718293a4b5c6d7e8", false},
+		{"empty", "", true},
+		{"too short", "short-secret", true},
+		{"example placeholder", "change-me-to-a-long-random-secret-value", true},
+		{"placeholder in upper case", strings.ToUpper("change-me-to-a-long-random-secret-value"), true},
+		{"other placeholder", "changeme-changeme-changeme-changeme", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJWTSecret(tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateJWTSecret(%q) error = %v, wantErr %v", tt.secret, err, tt.wantErr)
+			}
+		})
+	}
+}
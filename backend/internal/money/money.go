@@ -0,0 +1,127 @@
+// Package money — расчёт и округление денежных сумм с учётом точности валюты.
+// Все суммы операций округляются здесь, чтобы записанная сумма совпадала с наличными,
+// которые можно выдать или принять. Суммы хранятся как точные десятичные числа
+// (decimal.Decimal): значения вроде 0.1 представляются без погрешности и совпадают с DECIMAL в PostgreSQL.
+package money
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// RoundingMode — способ округления сумм валюты
+type RoundingMode string
+
+const (
+	// RoundHalfEven — банковское округление до младшей единицы валюты
+	RoundHalfEven RoundingMode = "HALF_EVEN"
+	// RoundDown — отбрасывание дробной части сверх младшей единицы
+	RoundDown RoundingMode = "DOWN"
+	// RoundCash — округление вниз до кратного наименьшей купюре или монете (cash_increment)
+	RoundCash RoundingMode = "CASH"
+)
+
+// MaxMinorUnits — наибольшее число знаков после запятой, которое хранится в БД (DECIMAL(19,4))
+const MaxMinorUnits = 4
+
+// RateDecimals — число знаков после запятой в курсах (DECIMAL(19,8))
+const RateDecimals = 8
+
+// DivisionPlaces — знаков после запятой в частном до округления по правилам валюты
+const DivisionPlaces = 16
+
+// Precision — правила округления сумм в валюте
+type Precision struct {
+	MinorUnits    int32           // Число знаков после запятой
+	Mode          RoundingMode    // Способ округления
+	CashIncrement decimal.Decimal // Наименьшая купюра или монета для RoundCash
+}
+
+// RUB — точность рублёвых сумм: копейки, банковское округление
+var RUB = Precision{MinorUnits: 2, Mode: RoundHalfEven}
+
+// NewPrecision создаёт правила округления из настроек валюты.
+// cashIncrement обязателен для RoundCash и игнорируется для остальных способов.
+func NewPrecision(minorUnits int32, mode string, cashIncrement decimal.NullDecimal) (Precision, error) {
+	if minorUnits < 0 || minorUnits > MaxMinorUnits {
+		return Precision{}, fmt.Errorf("minor units must be between 0 and %d, got %d", MaxMinorUnits, minorUnits)
+	}
+	p := Precision{MinorUnits: minorUnits, Mode: RoundingMode(mode)}
+	switch p.Mode {
+	case RoundHalfEven, RoundDown:
+	case RoundCash:
+		if !cashIncrement.Valid || cashIncrement.Decimal.Sign() <= 0 {
+			return Precision{}, fmt.Errorf("cash increment must be greater than zero")
+		}
+		if !cashIncrement.Decimal.Equal(cashIncrement.Decimal.Truncate(minorUnits)) {
+			return Precision{}, fmt.Errorf("cash increment %s has more than %d decimal places", cashIncrement.Decimal, minorUnits)
+		}
+		p.CashIncrement = cashIncrement.Decimal
+	default:
+		return Precision{}, fmt.Errorf("unknown rounding mode '%s'", mode)
+	}
+	return p, nil
+}
+
+// Parse разбирает десятичную сумму или курс
+func Parse(s string) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to parse '%s' as decimal: %w", s, err)
+	}
+	return d, nil
+}
+
+// Quo делит x на y с точностью DivisionPlaces знаков; результат затем округляется по правилам валюты
+func Quo(x, y decimal.Decimal) decimal.Decimal {
+	return x.DivRound(y, DivisionPlaces)
+}
+
+// Round округляет сумму по правилам валюты
+func (p Precision) Round(x decimal.Decimal) decimal.Decimal {
+	switch p.Mode {
+	case RoundDown:
+		return x.Truncate(p.MinorUnits)
+	case RoundCash:
+		return Quo(x, p.CashIncrement).Truncate(0).Mul(p.CashIncrement)
+	default:
+		return x.RoundBank(p.MinorUnits)
+	}
+}
+
+// RoundDown округляет сумму вниз до шага валюты (младшей единицы или наименьшей купюры)
+func (p Precision) RoundDown(x decimal.Decimal) decimal.Decimal {
+	if p.Mode == RoundCash {
+		return p.Round(x)
+	}
+	return x.Truncate(p.MinorUnits)
+}
+
+// IsRounded сообщает, что сумма уже представима в валюте без округления
+func (p Precision) IsRounded(x decimal.Decimal) bool {
+	return p.Round(x).Equal(x)
+}
+
+// Format округляет сумму и печатает её с числом знаков валюты
+func (p Precision) Format(x decimal.Decimal) string {
+	return p.Round(x).StringFixed(p.MinorUnits)
+}
+
+// Describe описывает допустимый шаг суммы для сообщений об ошибках
+func (p Precision) Describe() string {
+	if p.Mode == RoundCash {
+		return "a multiple of " + p.CashIncrement.StringFixed(p.MinorUnits)
+	}
+	return fmt.Sprintf("at most %d decimal places", p.MinorUnits)
+}
+
+// RoundRate округляет курс до точности хранения
+func RoundRate(rate decimal.Decimal) decimal.Decimal {
+	return rate.RoundBank(RateDecimals)
+}
+
+// FormatRate печатает курс с точностью хранения
+func FormatRate(rate decimal.Decimal) string {
+	return rate.StringFixed(RateDecimals)
+}
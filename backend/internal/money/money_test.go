@@ -0,0 +1,172 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func mustParse(t *testing.T, s string) decimal.Decimal {
+	t.Helper()
+	d, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0.1", "0.1"},
+		{"100", "100"},
+		{"-42.50", "-42.5"},
+		{"12345678901234.5678", "12345678901234.5678"},
+		{"90.12345678", "90.12345678"},
+		{"1e3", "1000"},
+	}
+	for _, tt := range tests {
+		got := mustParse(t, tt.in)
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1,5", "1.2.3"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q): expected error", in)
+		}
+	}
+}
+
+// Сумма десятичных дробей точна, в отличие от float64
+func TestParseIsExact(t *testing.T) {
+	sum := mustParse(t, "0.1").Add(mustParse(t, "0.2"))
+	if !sum.Equal(mustParse(t, "0.3")) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", sum)
+	}
+}
+
+func TestPrecisionRound(t *testing.T) {
+	halfEven2 := Precision{MinorUnits: 2, Mode: RoundHalfEven}
+	halfEven0 := Precision{MinorUnits: 0, Mode: RoundHalfEven}
+	down2 := Precision{MinorUnits: 2, Mode: RoundDown}
+	cash5 := Precision{MinorUnits: 0, Mode: RoundCash, CashIncrement: decimal.NewFromInt(5)}
+	cash050 := Precision{MinorUnits: 2, Mode: RoundCash, CashIncrement: decimal.RequireFromString("0.05")}
+
+	tests := []struct {
+		name      string
+		precision Precision
+		in        string
+		want      string
+	}{
+		{"half even rounds half to even down", halfEven2, "2.345", "2.34"},
+		{"half even rounds half to even up", halfEven2, "2.355", "2.36"},
+		{"half even above half", halfEven2, "2.3451", "2.35"},
+		{"half even negative", halfEven2, "-2.345", "-2.34"},
+		{"half even already rounded", halfEven2, "10.1", "10.1"},
+		{"half even zero places", halfEven0, "12.5", "12"},
+		{"half even zero places odd", halfEven0, "13.5", "14"},
+		{"down drops fraction", down2, "2.349", "2.34"},
+		{"down negative towards zero", down2, "-2.349", "-2.34"},
+		{"down exact", down2, "7.10", "7.1"},
+		{"cash rounds down to banknote", cash5, "123", "120"},
+		{"cash exact multiple", cash5, "125", "125"},
+		{"cash below smallest banknote", cash5, "4.99", "0"},
+		{"cash coins", cash050, "1.07", "1.05"},
+		{"cash coins exact", cash050, "1.10", "1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.precision.Round(mustParse(t, tt.in))
+			if !got.Equal(mustParse(t, tt.want)) {
+				t.Errorf("Round(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrecisionRoundDownAndIsRounded(t *testing.T) {
+	halfEven := Precision{MinorUnits: 2, Mode: RoundHalfEven}
+	if got := halfEven.RoundDown(mustParse(t, "2.359")); !got.Equal(mustParse(t, "2.35")) {
+		t.Errorf("RoundDown = %s, want 2.35", got)
+	}
+	if !halfEven.IsRounded(mustParse(t, "2.35")) || halfEven.IsRounded(mustParse(t, "2.351")) {
+		t.Error("IsRounded does not match currency precision")
+	}
+	cash := Precision{MinorUnits: 0, Mode: RoundCash, CashIncrement: decimal.NewFromInt(10)}
+	if cash.IsRounded(mustParse(t, "15")) || !cash.IsRounded(mustParse(t, "20")) {
+		t.Error("IsRounded does not match cash increment")
+	}
+}
+
+func TestNewPrecision(t *testing.T) {
+	increment := decimal.NullDecimal{Decimal: decimal.RequireFromString("0.05"), Valid: true}
+	tests := []struct {
+		name          string
+		minorUnits    int32
+		mode          string
+		cashIncrement decimal.NullDecimal
+		wantErr       bool
+	}{
+		{"half even", 2, "HALF_EVEN", decimal.NullDecimal{}, false},
+		{"down", 3, "DOWN", decimal.NullDecimal{}, false},
+		{"cash", 2, "CASH", increment, false},
+		{"too many minor units", MaxMinorUnits + 1, "HALF_EVEN", decimal.NullDecimal{}, true},
+		{"negative minor units", -1, "HALF_EVEN", decimal.NullDecimal{}, true},
+		{"cash without increment", 2, "CASH", decimal.NullDecimal{}, true},
+		{"cash increment finer than minor units", 1, "CASH", increment, true},
+		{"unknown mode", 2, "UP", decimal.NullDecimal{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPrecision(tt.minorUnits, tt.mode, tt.cashIncrement)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewPrecision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQuo(t *testing.T) {
+	tests := []struct {
+		x, y string
+		want string
+	}{
+		{"10", "4", "2.5"},
+		{"1", "3", "0.3333333333333333"},
+		{"2", "3", "0.6666666666666667"},
+		{"9050", "90.5", "100"},
+		{"1000", "89.12345678", "11.2203906370966506"},
+	}
+	for _, tt := range tests {
+		got := Quo(mustParse(t, tt.x), mustParse(t, tt.y))
+		if !got.Equal(mustParse(t, tt.want)) {
+			t.Errorf("Quo(%s, %s) = %s, want %s", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestRoundRate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"90.5", "90.5"},
+		{"90.123456785", "90.12345678"},
+		{"90.123456775", "90.12345678"},
+		{"90.1234567851", "90.12345679"},
+		{"0.000000005", "0"},
+	}
+	for _, tt := range tests {
+		got := RoundRate(mustParse(t, tt.in))
+		if !got.Equal(mustParse(t, tt.want)) {
+			t.Errorf("RoundRate(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	if got := FormatRate(mustParse(t, "90.5")); got != "90.50000000" {
+		t.Errorf("FormatRate(90.5) = %s, want 90.50000000", got)
+	}
+}
@@ -0,0 +1,177 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"exchange_point/backend/internal/repository/sqlcgen"
+
+	"github.com/lib/pq"
+)
+
+// Количество попыток выполнить транзакцию при конфликте сериализации или взаимной блокировке
+const maxTxAttempts = 5
+
+// Коды ошибок PostgreSQL, после которых транзакцию можно безопасно повторить
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgUniqueViolation      = "23505"
+)
+
+// Уникальное ограничение звена цепочки журнала аудита: две транзакции, продолжившие цепочку от одной записи,
+// не могут зафиксироваться обе, и проигравшая повторяется
+const auditPrevHashConstraint = "audit_events_prev_hash_key"
+
+// Store объединяет sqlc-запросы и выполнение нескольких шагов как одной единицы работы
+type Store interface {
+	sqlcgen.Querier
+	// RunInTx выполняет fn в транзакции: коммит, если fn вернула nil, иначе откат.
+	// При конфликте сериализации или занятом звене журнала аудита fn вызывается повторно, поэтому она не должна иметь побочных эффектов вне БД.
+	// Повторные попытки выполняются под блокировкой AuditChainLock, взятой до начала транзакции: их снимок сделан
+	// после записей в журнал, которых ждала блокировка, и на конце цепочки они больше не конфликтуют.
+	RunInTx(ctx context.Context, fn func(q sqlcgen.Querier) error) error
+	// RunInTxLocked — RunInTx под рекомендательными блокировками locks. Блокировки берутся до начала транзакции
+	// и снимаются после её завершения: в сериализуемой транзакции снимок делается первым же запросом,
+	// и транзакция, дождавшаяся блокировки внутри себя, всё равно не увидела бы изменений предыдущей.
+	RunInTxLocked(ctx context.Context, locks []AdvisoryLock, fn func(q sqlcgen.Querier) error) error
+}
+
+// AdvisoryLock — ключ рекомендательной блокировки PostgreSQL из двух чисел (pg_advisory_lock(int, int))
+type AdvisoryLock struct {
+	Key1, Key2 int32
+}
+
+// ClientCurrencyLock — блокировка операций клиента по валюте: дневной лимит проверяется и расходуется последовательно
+func ClientCurrencyLock(clientID, currencyID int32) AdvisoryLock {
+	return AdvisoryLock{Key1: clientID, Key2: currencyID}
+}
+
+// AuditChainLock — блокировка конца цепочки журнала аудита. Транзакция берёт её перед записью в журнал
+// (до своего завершения), повторная попытка транзакции — до её начала.
+// Идентификаторы клиентов и валют начинаются с 1, поэтому ключ 0/0 не совпадает с ClientCurrencyLock.
+var AuditChainLock = AdvisoryLock{Key1: 0, Key2: 0}
+
+type SQLStore struct {
+	*sqlcgen.Queries
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *SQLStore {
+	return &SQLStore{
+		Queries: sqlcgen.New(db),
+		db:      db,
+	}
+}
+
+var _ Store = (*SQLStore)(nil)
+
+func (s *SQLStore) RunInTx(ctx context.Context, fn func(q sqlcgen.Querier) error) error {
+	return s.RunInTxLocked(ctx, nil, fn)
+}
+
+func (s *SQLStore) RunInTxLocked(ctx context.Context, locks []AdvisoryLock, fn func(q sqlcgen.Querier) error) error {
+	// Блокировки берутся в одном порядке во всех транзакциях, чтобы они не ждали друг друга по кругу.
+	// Блокировка цепочки журнала аудита — последней: внутри транзакции её ждут, уже держа остальные.
+	sorted := append([]AdvisoryLock(nil), locks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Key1 != sorted[j].Key1 {
+			return sorted[i].Key1 < sorted[j].Key1
+		}
+		return sorted[i].Key2 < sorted[j].Key2
+	})
+
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		if attempt == 2 {
+			sorted = append(sorted, AuditChainLock)
+		}
+		err = s.runLocked(ctx, sorted, fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+
+		// Небольшая пауза перед повтором, чтобы конкурирующая транзакция успела завершиться
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 20 * time.Millisecond):
+		}
+	}
+	return fmt.Errorf("transaction failed after %d attempts: %w", maxTxAttempts, err)
+}
+
+// runLocked выполняет одну попытку транзакции под сеансовыми блокировками locks
+func (s *SQLStore) runLocked(ctx context.Context, locks []AdvisoryLock, fn func(q sqlcgen.Querier) error) error {
+	if len(locks) == 0 {
+		return s.runInTx(ctx, s.db, fn)
+	}
+
+	// Сеансовые блокировки принадлежат соединению: транзакция выполняется на нём же
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get connection: %w", err)
+	}
+	defer conn.Close()
+	defer releaseLocks(conn)
+
+	q := sqlcgen.New(conn)
+	for i, lock := range locks {
+		if i > 0 && lock == locks[i-1] {
+			continue
+		}
+		if err := q.AcquireAdvisoryLock(ctx, sqlcgen.AcquireAdvisoryLockParams{Key1: lock.Key1, Key2: lock.Key2}); err != nil {
+			return fmt.Errorf("could not acquire advisory lock %d/%d: %w", lock.Key1, lock.Key2, err)
+		}
+	}
+	return s.runInTx(ctx, conn, fn)
+}
+
+// releaseLocks снимает сеансовые блокировки перед возвратом соединения в пул.
+// Если снять не удалось, соединение закрывается: блокировки сеанса снимаются вместе с ним.
+func releaseLocks(conn *sql.Conn) {
+	if err := sqlcgen.New(conn).ReleaseAdvisoryLocks(context.Background()); err != nil {
+		log.Printf("Could not release advisory locks, closing connection: %v", err)
+		conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+}
+
+// txBeginner — пул соединений или отдельное соединение, на котором начинается транзакция
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+func (s *SQLStore) runInTx(ctx context.Context, db txBeginner, fn func(q sqlcgen.Querier) error) error {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("unable to begin transaction: %w", err)
+	}
+
+	if err := fn(s.Queries.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code {
+	case pgSerializationFailure, pgDeadlockDetected:
+		return true
+	case pgUniqueViolation:
+		return pqErr.Constraint == auditPrevHashConstraint
+	}
+	return false
+}
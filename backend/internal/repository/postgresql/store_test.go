@@ -0,0 +1,58 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+
+	"exchange_point/backend/internal/repository/postgresql/pgtest"
+	"exchange_point/backend/internal/repository/sqlcgen"
+
+	"github.com/shopspring/decimal"
+)
+
+// Суммы DECIMAL(19,4) проходят через параметры sqlc и обратно без погрешности
+func TestDecimalRoundTrip(t *testing.T) {
+	store := NewStore(pgtest.NewDB(t))
+	ctx := context.Background()
+
+	values := []string{"0.1", "0.0001", "12345678901234.5678", "-12345678901234.5678", "999999999999999.9999", "90.5"}
+	for i, value := range values {
+		amount := decimal.RequireFromString(value)
+		code := string(rune('A'+i)) + "XX"
+		changed, err := store.ChangeCashBalance(ctx, sqlcgen.ChangeCashBalanceParams{CurrencyCode: code, Amount: amount})
+		if err != nil {
+			t.Fatalf("ChangeCashBalance(%s): %v", value, err)
+		}
+		stored, err := store.GetCashBalance(ctx, code)
+		if err != nil {
+			t.Fatalf("GetCashBalance(%s): %v", code, err)
+		}
+		for _, got := range []decimal.Decimal{changed.Balance, stored.Balance} {
+			if !got.Equal(amount) || got.StringFixed(4) != amount.StringFixed(4) {
+				t.Errorf("balance %s, want %s", got, value)
+			}
+		}
+	}
+}
+
+// Сумма, накопленная в базе, совпадает с точной десятичной суммой: 0.1 десять раз — ровно 1
+func TestDecimalAccumulation(t *testing.T) {
+	store := NewStore(pgtest.NewDB(t))
+	ctx := context.Background()
+
+	step := decimal.RequireFromString("0.1")
+	want := decimal.Zero
+	for i := 0; i < 10; i++ {
+		if _, err := store.ChangeCashBalance(ctx, sqlcgen.ChangeCashBalanceParams{CurrencyCode: "XXX", Amount: step}); err != nil {
+			t.Fatalf("ChangeCashBalance: %v", err)
+		}
+		want = want.Add(step)
+	}
+	balance, err := store.GetCashBalance(ctx, "XXX")
+	if err != nil {
+		t.Fatalf("GetCashBalance: %v", err)
+	}
+	if !balance.Balance.Equal(decimal.NewFromInt(1)) || !balance.Balance.Equal(want) {
+		t.Errorf("balance %s, want 1", balance.Balance)
+	}
+}
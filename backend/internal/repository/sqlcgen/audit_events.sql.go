@@ -0,0 +1,207 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: audit_events.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sqlc-dev/pqtype"
+)
+
+const createAuditEvent = `-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+    occurred_at, actor_id, actor_username, action, entity_type, entity_id,
+    before_data, after_data, ip_address, request_id, prev_hash, hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING id, occurred_at, actor_id, actor_username, action, entity_type, entity_id, before_data, after_data, ip_address, request_id, prev_hash, hash
+`
+
+type CreateAuditEventParams struct {
+	OccurredAt    time.Time             `json:"occurred_at"`
+	ActorID       sql.NullInt32         `json:"actor_id"`
+	ActorUsername sql.NullString        `json:"actor_username"`
+	Action        string                `json:"action"`
+	EntityType    string                `json:"entity_type"`
+	EntityID      string                `json:"entity_id"`
+	BeforeData    pqtype.NullRawMessage `json:"before_data"`
+	AfterData     pqtype.NullRawMessage `json:"after_data"`
+	IpAddress     sql.NullString        `json:"ip_address"`
+	RequestID     sql.NullString        `json:"request_id"`
+	PrevHash      string                `json:"prev_hash"`
+	Hash          string                `json:"hash"`
+}
+
+func (q *Queries) CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEvent,
+		arg.OccurredAt,
+		arg.ActorID,
+		arg.ActorUsername,
+		arg.Action,
+		arg.EntityType,
+		arg.EntityID,
+		arg.BeforeData,
+		arg.AfterData,
+		arg.IpAddress,
+		arg.RequestID,
+		arg.PrevHash,
+		arg.Hash,
+	)
+	var i AuditEvent
+	err := row.Scan(
+		&i.ID,
+		&i.OccurredAt,
+		&i.ActorID,
+		&i.ActorUsername,
+		&i.Action,
+		&i.EntityType,
+		&i.EntityID,
+		&i.BeforeData,
+		&i.AfterData,
+		&i.IpAddress,
+		&i.RequestID,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const getLastAuditEventHash = `-- name: GetLastAuditEventHash :one
+SELECT hash FROM audit_events
+ORDER BY id DESC
+LIMIT 1
+`
+
+// Хеш последней записи журнала — начало следующего звена цепочки
+func (q *Queries) GetLastAuditEventHash(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLastAuditEventHash)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
+const listAuditEvents = `-- name: ListAuditEvents :many
+SELECT id, occurred_at, actor_id, actor_username, action, entity_type, entity_id, before_data, after_data, ip_address, request_id, prev_hash, hash FROM audit_events
+WHERE ($3::int IS NULL OR actor_id = $3::int)
+  AND ($4::text = '' OR action = $4::text)
+  AND ($5::text = '' OR entity_type = $5::text)
+  AND ($6::text = '' OR entity_id = $6::text)
+  AND ($7::timestamptz IS NULL OR occurred_at >= $7::timestamptz)
+  AND ($8::timestamptz IS NULL OR occurred_at < $8::timestamptz)
+ORDER BY id DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListAuditEventsParams struct {
+	Limit        int32         `json:"limit"`
+	Offset       int32         `json:"offset"`
+	ActorID      sql.NullInt32 `json:"actor_id"`
+	Action       string        `json:"action"`
+	EntityType   string        `json:"entity_type"`
+	EntityID     string        `json:"entity_id"`
+	OccurredFrom sql.NullTime  `json:"occurred_from"`
+	OccurredTo   sql.NullTime  `json:"occurred_to"`
+}
+
+// Получить записи журнала, новые первыми; пустые строковые фильтры и NULL не ограничивают выборку
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEvents,
+		arg.Limit,
+		arg.Offset,
+		arg.ActorID,
+		arg.Action,
+		arg.EntityType,
+		arg.EntityID,
+		arg.OccurredFrom,
+		arg.OccurredTo,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OccurredAt,
+			&i.ActorID,
+			&i.ActorUsername,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.BeforeData,
+			&i.AfterData,
+			&i.IpAddress,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAuditEventsAfter = `-- name: ListAuditEventsAfter :many
+SELECT id, occurred_at, actor_id, actor_username, action, entity_type, entity_id, before_data, after_data, ip_address, request_id, prev_hash, hash FROM audit_events
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAuditEventsAfterParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+// Получить записи журнала по порядку, начиная после after_id: для проверки цепочки хешей
+func (q *Queries) ListAuditEventsAfter(ctx context.Context, arg ListAuditEventsAfterParams) ([]AuditEvent, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEventsAfter, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditEvent{}
+	for rows.Next() {
+		var i AuditEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OccurredAt,
+			&i.ActorID,
+			&i.ActorUsername,
+			&i.Action,
+			&i.EntityType,
+			&i.EntityID,
+			&i.BeforeData,
+			&i.AfterData,
+			&i.IpAddress,
+			&i.RequestID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,218 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: cash_balances.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+const changeCashBalance = `-- name: ChangeCashBalance :one
+INSERT INTO cash_balances (currency_code, balance)
+VALUES ($1, $2)
+ON CONFLICT (currency_code) DO UPDATE
+SET
+    balance = cash_balances.balance + EXCLUDED.balance,
+    updated_at = NOW()
+RETURNING currency_code, balance, reserve, updated_at
+`
+
+type ChangeCashBalanceParams struct {
+	CurrencyCode string          `json:"currency_code"`
+	Amount       decimal.Decimal `json:"amount"`
+}
+
+// Изменить остаток наличных на amount (со знаком).
+// Строка остатка блокируется до конца транзакции, поэтому изменения по валюте выполняются последовательно.
+func (q *Queries) ChangeCashBalance(ctx context.Context, arg ChangeCashBalanceParams) (CashBalance, error) {
+	row := q.db.QueryRowContext(ctx, changeCashBalance, arg.CurrencyCode, arg.Amount)
+	var i CashBalance
+	err := row.Scan(
+		&i.CurrencyCode,
+		&i.Balance,
+		&i.Reserve,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createCashMovement = `-- name: CreateCashMovement :one
+INSERT INTO cash_movements (
+    currency_code, movement_type, amount, balance_after, operation_id, reason, comment, shift_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, currency_code, movement_type, amount, balance_after, operation_id, reason, comment, created_at, shift_id
+`
+
+type CreateCashMovementParams struct {
+	CurrencyCode string          `json:"currency_code"`
+	MovementType string          `json:"movement_type"`
+	Amount       decimal.Decimal `json:"amount"`
+	BalanceAfter decimal.Decimal `json:"balance_after"`
+	OperationID  sql.NullInt64   `json:"operation_id"`
+	Reason       sql.NullString  `json:"reason"`
+	Comment      sql.NullString  `json:"comment"`
+	ShiftID      sql.NullInt32   `json:"shift_id"`
+}
+
+// Записать движение наличных
+func (q *Queries) CreateCashMovement(ctx context.Context, arg CreateCashMovementParams) (CashMovement, error) {
+	row := q.db.QueryRowContext(ctx, createCashMovement,
+		arg.CurrencyCode,
+		arg.MovementType,
+		arg.Amount,
+		arg.BalanceAfter,
+		arg.OperationID,
+		arg.Reason,
+		arg.Comment,
+		arg.ShiftID,
+	)
+	var i CashMovement
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyCode,
+		&i.MovementType,
+		&i.Amount,
+		&i.BalanceAfter,
+		&i.OperationID,
+		&i.Reason,
+		&i.Comment,
+		&i.CreatedAt,
+		&i.ShiftID,
+	)
+	return i, err
+}
+
+const getCashBalance = `-- name: GetCashBalance :one
+SELECT currency_code, balance, reserve, updated_at FROM cash_balances
+WHERE currency_code = $1
+`
+
+// Получить остаток наличных по валюте
+func (q *Queries) GetCashBalance(ctx context.Context, currencyCode string) (CashBalance, error) {
+	row := q.db.QueryRowContext(ctx, getCashBalance, currencyCode)
+	var i CashBalance
+	err := row.Scan(
+		&i.CurrencyCode,
+		&i.Balance,
+		&i.Reserve,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listCashBalances = `-- name: ListCashBalances :many
+SELECT currency_code, balance, reserve, updated_at FROM cash_balances
+ORDER BY currency_code
+`
+
+// Получить остатки наличных по всем валютам
+func (q *Queries) ListCashBalances(ctx context.Context) ([]CashBalance, error) {
+	rows, err := q.db.QueryContext(ctx, listCashBalances)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CashBalance{}
+	for rows.Next() {
+		var i CashBalance
+		if err := rows.Scan(
+			&i.CurrencyCode,
+			&i.Balance,
+			&i.Reserve,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCashMovements = `-- name: ListCashMovements :many
+SELECT id, currency_code, movement_type, amount, balance_after, operation_id, reason, comment, created_at, shift_id FROM cash_movements
+WHERE $3::text = '' OR currency_code = $3::text
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListCashMovementsParams struct {
+	Limit        int32  `json:"limit"`
+	Offset       int32  `json:"offset"`
+	CurrencyCode string `json:"currency_code"`
+}
+
+// Получить движения наличных, новые первыми; пустой код валюты — по всем валютам
+func (q *Queries) ListCashMovements(ctx context.Context, arg ListCashMovementsParams) ([]CashMovement, error) {
+	rows, err := q.db.QueryContext(ctx, listCashMovements, arg.Limit, arg.Offset, arg.CurrencyCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CashMovement{}
+	for rows.Next() {
+		var i CashMovement
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrencyCode,
+			&i.MovementType,
+			&i.Amount,
+			&i.BalanceAfter,
+			&i.OperationID,
+			&i.Reason,
+			&i.Comment,
+			&i.CreatedAt,
+			&i.ShiftID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setCashReserve = `-- name: SetCashReserve :one
+INSERT INTO cash_balances (currency_code, reserve)
+VALUES ($1, $2)
+ON CONFLICT (currency_code) DO UPDATE
+SET
+    reserve = EXCLUDED.reserve,
+    updated_at = NOW()
+RETURNING currency_code, balance, reserve, updated_at
+`
+
+type SetCashReserveParams struct {
+	CurrencyCode string          `json:"currency_code"`
+	Reserve      decimal.Decimal `json:"reserve"`
+}
+
+// Установить неснижаемый остаток по валюте
+func (q *Queries) SetCashReserve(ctx context.Context, arg SetCashReserveParams) (CashBalance, error) {
+	row := q.db.QueryRowContext(ctx, setCashReserve, arg.CurrencyCode, arg.Reserve)
+	var i CashBalance
+	err := row.Scan(
+		&i.CurrencyCode,
+		&i.Balance,
+		&i.Reserve,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
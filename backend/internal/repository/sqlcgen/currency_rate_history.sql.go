@@ -0,0 +1,139 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: currency_rate_history.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createCurrencyRateHistory = `-- name: CreateCurrencyRateHistory :one
+INSERT INTO currency_rate_history (
+    currency_id, buy_rate, sell_rate, source, changed_by, reason, effective_from
+) VALUES (
+    $1, $2, $3, $4, $5, $6,
+    COALESCE($7::timestamptz, CURRENT_TIMESTAMP)
+)
+RETURNING id, currency_id, buy_rate, sell_rate, effective_from, source, changed_by, reason
+`
+
+type CreateCurrencyRateHistoryParams struct {
+	CurrencyID    int32           `json:"currency_id"`
+	BuyRate       decimal.Decimal `json:"buy_rate"`
+	SellRate      decimal.Decimal `json:"sell_rate"`
+	Source        string          `json:"source"`
+	ChangedBy     sql.NullInt32   `json:"changed_by"`
+	Reason        sql.NullString  `json:"reason"`
+	EffectiveFrom sql.NullTime    `json:"effective_from"`
+}
+
+// Записать новый курс валюты; effective_from по умолчанию — время транзакции, как и currencies.last_rate_update_at,
+// для планового изменения — время, на которое оно было назначено
+func (q *Queries) CreateCurrencyRateHistory(ctx context.Context, arg CreateCurrencyRateHistoryParams) (CurrencyRateHistory, error) {
+	row := q.db.QueryRowContext(ctx, createCurrencyRateHistory,
+		arg.CurrencyID,
+		arg.BuyRate,
+		arg.SellRate,
+		arg.Source,
+		arg.ChangedBy,
+		arg.Reason,
+		arg.EffectiveFrom,
+	)
+	var i CurrencyRateHistory
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.EffectiveFrom,
+		&i.Source,
+		&i.ChangedBy,
+		&i.Reason,
+	)
+	return i, err
+}
+
+const getCurrencyRateAt = `-- name: GetCurrencyRateAt :one
+SELECT id, currency_id, buy_rate, sell_rate, effective_from, source, changed_by, reason FROM currency_rate_history
+WHERE currency_id = $1 AND effective_from <= $2::timestamptz
+ORDER BY effective_from DESC, id DESC
+LIMIT 1
+`
+
+type GetCurrencyRateAtParams struct {
+	CurrencyID int32     `json:"currency_id"`
+	At         time.Time `json:"at"`
+}
+
+// Получить курс валюты, действовавший в момент at
+func (q *Queries) GetCurrencyRateAt(ctx context.Context, arg GetCurrencyRateAtParams) (CurrencyRateHistory, error) {
+	row := q.db.QueryRowContext(ctx, getCurrencyRateAt, arg.CurrencyID, arg.At)
+	var i CurrencyRateHistory
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.EffectiveFrom,
+		&i.Source,
+		&i.ChangedBy,
+		&i.Reason,
+	)
+	return i, err
+}
+
+const listCurrencyRateHistory = `-- name: ListCurrencyRateHistory :many
+SELECT r.id, r.currency_id, r.buy_rate, r.sell_rate, r.effective_from, r.source, r.changed_by, r.reason FROM currency_rate_history r
+WHERE r.currency_id = $1::int
+  AND r.effective_from < $2::timestamptz
+  AND r.effective_from >= COALESCE((
+      SELECT MAX(h.effective_from) FROM currency_rate_history h
+      WHERE h.currency_id = $1::int AND h.effective_from <= $3::timestamptz
+  ), $3::timestamptz)
+ORDER BY r.effective_from, r.id
+`
+
+type ListCurrencyRateHistoryParams struct {
+	CurrencyID int32     `json:"currency_id"`
+	ToTime     time.Time `json:"to_time"`
+	FromTime   time.Time `json:"from_time"`
+}
+
+// Получить курсы валюты, действовавшие в периоде [from_time, to_time): включая курс, действовавший на его начало
+func (q *Queries) ListCurrencyRateHistory(ctx context.Context, arg ListCurrencyRateHistoryParams) ([]CurrencyRateHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrencyRateHistory, arg.CurrencyID, arg.ToTime, arg.FromTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CurrencyRateHistory{}
+	for rows.Next() {
+		var i CurrencyRateHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrencyID,
+			&i.BuyRate,
+			&i.SellRate,
+			&i.EffectiveFrom,
+			&i.Source,
+			&i.ChangedBy,
+			&i.Reason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
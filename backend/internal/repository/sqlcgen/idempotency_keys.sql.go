@@ -0,0 +1,245 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: idempotency_keys.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const bindIdempotencyKey = `-- name: BindIdempotencyKey :execrows
+UPDATE idempotency_keys
+SET
+    entity_type = COALESCE(entity_type, $1),
+    entity_id = COALESCE(entity_id, $2)
+WHERE user_id = $3
+  AND idempotency_key = $4
+  AND claim_number = $5
+`
+
+type BindIdempotencyKeyParams struct {
+	EntityType     sql.NullString `json:"entity_type"`
+	EntityID       sql.NullString `json:"entity_id"`
+	UserID         int32          `json:"user_id"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	ClaimNumber    int32          `json:"claim_number"`
+}
+
+// Привязать к ключу сущность, изменённую запросом. Выполняется в транзакции обработчика;
+// 0 строк — ключ уже занят повторным запросом, и транзакция должна откатиться
+func (q *Queries) BindIdempotencyKey(ctx context.Context, arg BindIdempotencyKeyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, bindIdempotencyKey,
+		arg.EntityType,
+		arg.EntityID,
+		arg.UserID,
+		arg.IdempotencyKey,
+		arg.ClaimNumber,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET
+    response_status = $1,
+    response_content_type = $2,
+    response_body = $3,
+    completed_at = NOW()
+WHERE user_id = $4
+  AND idempotency_key = $5
+  AND claim_number = $6
+`
+
+type CompleteIdempotencyKeyParams struct {
+	ResponseStatus      sql.NullInt32  `json:"response_status"`
+	ResponseContentType sql.NullString `json:"response_content_type"`
+	ResponseBody        []byte         `json:"response_body"`
+	UserID              int32          `json:"user_id"`
+	IdempotencyKey      string         `json:"idempotency_key"`
+	ClaimNumber         int32          `json:"claim_number"`
+}
+
+// Сохранить ответ на запрос с ключом идемпотентности
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, completeIdempotencyKey,
+		arg.ResponseStatus,
+		arg.ResponseContentType,
+		arg.ResponseBody,
+		arg.UserID,
+		arg.IdempotencyKey,
+		arg.ClaimNumber,
+	)
+	return err
+}
+
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (user_id, idempotency_key, request_method, request_path, request_hash, locked_until)
+VALUES (
+    $1, $2, $3, $4, $5,
+    NOW() + $6::int * INTERVAL '1 second'
+)
+ON CONFLICT (user_id, idempotency_key) DO NOTHING
+RETURNING idempotency_key, request_method, request_path, request_hash, response_status, response_content_type, response_body, created_at, completed_at, locked_until, claim_number, entity_type, entity_id, user_id
+`
+
+type CreateIdempotencyKeyParams struct {
+	UserID         int32  `json:"user_id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	RequestMethod  string `json:"request_method"`
+	RequestPath    string `json:"request_path"`
+	RequestHash    string `json:"request_hash"`
+	LeaseSeconds   int32  `json:"lease_seconds"`
+}
+
+// Занять ключ идемпотентности пользователя на lease_seconds секунд; если ключ уже есть, строка не возвращается
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, createIdempotencyKey,
+		arg.UserID,
+		arg.IdempotencyKey,
+		arg.RequestMethod,
+		arg.RequestPath,
+		arg.RequestHash,
+		arg.LeaseSeconds,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.IdempotencyKey,
+		&i.RequestMethod,
+		&i.RequestPath,
+		&i.RequestHash,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.LockedUntil,
+		&i.ClaimNumber,
+		&i.EntityType,
+		&i.EntityID,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < $1
+`
+
+// Удалить ключи старше заданного момента
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredIdempotencyKeys, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteIdempotencyKey = `-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1
+  AND idempotency_key = $2
+  AND claim_number = $3
+`
+
+type DeleteIdempotencyKeyParams struct {
+	UserID         int32  `json:"user_id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	ClaimNumber    int32  `json:"claim_number"`
+}
+
+// Освободить ключ, чтобы запрос можно было повторить
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error {
+	_, err := q.db.ExecContext(ctx, deleteIdempotencyKey, arg.UserID, arg.IdempotencyKey, arg.ClaimNumber)
+	return err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT idempotency_key, request_method, request_path, request_hash, response_status, response_content_type, response_body, created_at, completed_at, locked_until, claim_number, entity_type, entity_id, user_id FROM idempotency_keys
+WHERE user_id = $1
+  AND idempotency_key = $2
+`
+
+type GetIdempotencyKeyParams struct {
+	UserID         int32  `json:"user_id"`
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// Получить ключ идемпотентности пользователя и сохранённый ответ
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.UserID, arg.IdempotencyKey)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.IdempotencyKey,
+		&i.RequestMethod,
+		&i.RequestPath,
+		&i.RequestHash,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.LockedUntil,
+		&i.ClaimNumber,
+		&i.EntityType,
+		&i.EntityID,
+		&i.UserID,
+	)
+	return i, err
+}
+
+const reclaimIdempotencyKey = `-- name: ReclaimIdempotencyKey :one
+UPDATE idempotency_keys
+SET
+    locked_until = NOW() + $1::int * INTERVAL '1 second',
+    claim_number = claim_number + 1
+WHERE user_id = $2
+  AND idempotency_key = $3
+  AND request_hash = $4
+  AND response_status IS NULL
+  AND entity_id IS NULL
+  AND locked_until < NOW()
+RETURNING idempotency_key, request_method, request_path, request_hash, response_status, response_content_type, response_body, created_at, completed_at, locked_until, claim_number, entity_type, entity_id, user_id
+`
+
+type ReclaimIdempotencyKeyParams struct {
+	LeaseSeconds   int32  `json:"lease_seconds"`
+	UserID         int32  `json:"user_id"`
+	IdempotencyKey string `json:"idempotency_key"`
+	RequestHash    string `json:"request_hash"`
+}
+
+// Повторно занять ключ, аренда которого истекла без сохранённого ответа и без изменённой сущности
+func (q *Queries) ReclaimIdempotencyKey(ctx context.Context, arg ReclaimIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, reclaimIdempotencyKey,
+		arg.LeaseSeconds,
+		arg.UserID,
+		arg.IdempotencyKey,
+		arg.RequestHash,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.IdempotencyKey,
+		&i.RequestMethod,
+		&i.RequestPath,
+		&i.RequestHash,
+		&i.ResponseStatus,
+		&i.ResponseContentType,
+		&i.ResponseBody,
+		&i.CreatedAt,
+		&i.CompletedAt,
+		&i.LockedUntil,
+		&i.ClaimNumber,
+		&i.EntityType,
+		&i.EntityID,
+		&i.UserID,
+	)
+	return i, err
+}
@@ -7,8 +7,47 @@ package sqlcgen
 import (
 	"database/sql"
 	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sqlc-dev/pqtype"
 )
 
+type AuditEvent struct {
+	ID            int64                 `json:"id"`
+	OccurredAt    time.Time             `json:"occurred_at"`
+	ActorID       sql.NullInt32         `json:"actor_id"`
+	ActorUsername sql.NullString        `json:"actor_username"`
+	Action        string                `json:"action"`
+	EntityType    string                `json:"entity_type"`
+	EntityID      string                `json:"entity_id"`
+	BeforeData    pqtype.NullRawMessage `json:"before_data"`
+	AfterData     pqtype.NullRawMessage `json:"after_data"`
+	IpAddress     sql.NullString        `json:"ip_address"`
+	RequestID     sql.NullString        `json:"request_id"`
+	PrevHash      string                `json:"prev_hash"`
+	Hash          string                `json:"hash"`
+}
+
+type CashBalance struct {
+	CurrencyCode string          `json:"currency_code"`
+	Balance      decimal.Decimal `json:"balance"`
+	Reserve      decimal.Decimal `json:"reserve"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+type CashMovement struct {
+	ID           int64           `json:"id"`
+	CurrencyCode string          `json:"currency_code"`
+	MovementType string          `json:"movement_type"`
+	Amount       decimal.Decimal `json:"amount"`
+	BalanceAfter decimal.Decimal `json:"balance_after"`
+	OperationID  sql.NullInt64   `json:"operation_id"`
+	Reason       sql.NullString  `json:"reason"`
+	Comment      sql.NullString  `json:"comment"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ShiftID      sql.NullInt32   `json:"shift_id"`
+}
+
 type Client struct {
 	ID             int32          `json:"id"`
 	PassportNumber string         `json:"passport_number"`
@@ -18,34 +57,184 @@ type Client struct {
 }
 
 type Currency struct {
-	ID               int32        `json:"id"`
-	Code             string       `json:"code"`
-	Name             string       `json:"name"`
-	BuyRate          string       `json:"buy_rate"`
-	SellRate         string       `json:"sell_rate"`
-	LastRateUpdateAt sql.NullTime `json:"last_rate_update_at"`
-	CreatedAt        sql.NullTime `json:"created_at"`
-	UpdatedAt        sql.NullTime `json:"updated_at"`
+	ID               int32               `json:"id"`
+	Code             string              `json:"code"`
+	Name             string              `json:"name"`
+	BuyRate          decimal.Decimal     `json:"buy_rate"`
+	SellRate         decimal.Decimal     `json:"sell_rate"`
+	LastRateUpdateAt sql.NullTime        `json:"last_rate_update_at"`
+	CreatedAt        sql.NullTime        `json:"created_at"`
+	UpdatedAt        sql.NullTime        `json:"updated_at"`
+	MinorUnits       int16               `json:"minor_units"`
+	RoundingMode     string              `json:"rounding_mode"`
+	CashIncrement    decimal.NullDecimal `json:"cash_increment"`
+	IsActive         bool                `json:"is_active"`
+	InactiveReason   sql.NullString      `json:"inactive_reason"`
+	StatusChangedAt  sql.NullTime        `json:"status_changed_at"`
+	StatusChangedBy  sql.NullInt32       `json:"status_changed_by"`
+	DeletedAt        sql.NullTime        `json:"deleted_at"`
+	DeletedBy        sql.NullInt32       `json:"deleted_by"`
+}
+
+type CurrencyRateHistory struct {
+	ID            int64           `json:"id"`
+	CurrencyID    int32           `json:"currency_id"`
+	BuyRate       decimal.Decimal `json:"buy_rate"`
+	SellRate      decimal.Decimal `json:"sell_rate"`
+	EffectiveFrom time.Time       `json:"effective_from"`
+	Source        string          `json:"source"`
+	ChangedBy     sql.NullInt32   `json:"changed_by"`
+	Reason        sql.NullString  `json:"reason"`
+}
+
+type IdempotencyKey struct {
+	IdempotencyKey      string         `json:"idempotency_key"`
+	RequestMethod       string         `json:"request_method"`
+	RequestPath         string         `json:"request_path"`
+	RequestHash         string         `json:"request_hash"`
+	ResponseStatus      sql.NullInt32  `json:"response_status"`
+	ResponseContentType sql.NullString `json:"response_content_type"`
+	ResponseBody        []byte         `json:"response_body"`
+	CreatedAt           time.Time      `json:"created_at"`
+	CompletedAt         sql.NullTime   `json:"completed_at"`
+	LockedUntil         time.Time      `json:"locked_until"`
+	ClaimNumber         int32          `json:"claim_number"`
+	EntityType          sql.NullString `json:"entity_type"`
+	EntityID            sql.NullString `json:"entity_id"`
+	UserID              int32          `json:"user_id"`
 }
 
 type Operation struct {
-	ID                 int64        `json:"id"`
-	ClientID           int32        `json:"client_id"`
-	OperationType      string       `json:"operation_type"`
-	CurrencyID         int32        `json:"currency_id"`
-	AmountCurrency     string       `json:"amount_currency"`
-	AmountRub          string       `json:"amount_rub"`
-	EffectiveRate      string       `json:"effective_rate"`
-	OperationTimestamp sql.NullTime `json:"operation_timestamp"`
-	ReceiptReference   string       `json:"receipt_reference"`
-	CreatedAt          sql.NullTime `json:"created_at"`
+	ID                 int64           `json:"id"`
+	ClientID           int32           `json:"client_id"`
+	OperationType      string          `json:"operation_type"`
+	CurrencyID         int32           `json:"currency_id"`
+	AmountCurrency     decimal.Decimal `json:"amount_currency"`
+	AmountRub          decimal.Decimal `json:"amount_rub"`
+	EffectiveRate      decimal.Decimal `json:"effective_rate"`
+	OperationTimestamp sql.NullTime    `json:"operation_timestamp"`
+	ReceiptReference   *string         `json:"receipt_reference"`
+	CreatedAt          sql.NullTime    `json:"created_at"`
+	Status             string          `json:"status"`
+	ReversalOfID       sql.NullInt64   `json:"reversal_of_id"`
+	ReversalReason     sql.NullString  `json:"reversal_reason"`
+	ReversalComment    sql.NullString  `json:"reversal_comment"`
+	ReversedAt         sql.NullTime    `json:"reversed_at"`
+	ExpiresAt          sql.NullTime    `json:"expires_at"`
+	ConfirmedAt        sql.NullTime    `json:"confirmed_at"`
+	CompletedAt        sql.NullTime    `json:"completed_at"`
+	CancelledAt        sql.NullTime    `json:"cancelled_at"`
+	ExchangeGroup      sql.NullString  `json:"exchange_group"`
+	FeeFixedRub        decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub      decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub             decimal.Decimal `json:"fee_rub"`
+	FeeRuleID          sql.NullInt32   `json:"fee_rule_id"`
+	SpreadRub          decimal.Decimal `json:"spread_rub"`
+	ShiftID            sql.NullInt32   `json:"shift_id"`
+	CashierID          sql.NullInt32   `json:"cashier_id"`
+}
+
+type OperationFee struct {
+	ID            int32           `json:"id"`
+	CurrencyID    sql.NullInt32   `json:"currency_id"`
+	OperationType sql.NullString  `json:"operation_type"`
+	MinAmountRub  decimal.Decimal `json:"min_amount_rub"`
+	FixedFeeRub   decimal.Decimal `json:"fixed_fee_rub"`
+	PercentFee    decimal.Decimal `json:"percent_fee"`
+	Description   sql.NullString  `json:"description"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
 }
 
 type OperationLimit struct {
-	ID          int32          `json:"id"`
-	LimitName   string         `json:"limit_name"`
-	LimitValue  string         `json:"limit_value"`
-	Description sql.NullString `json:"description"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID          int32           `json:"id"`
+	LimitName   string          `json:"limit_name"`
+	LimitValue  decimal.Decimal `json:"limit_value"`
+	Description sql.NullString  `json:"description"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type RateQuote struct {
+	ID             string          `json:"id"`
+	ClientID       sql.NullInt32   `json:"client_id"`
+	OperationType  string          `json:"operation_type"`
+	CurrencyID     int32           `json:"currency_id"`
+	Amount         string          `json:"amount"`
+	AmountCurrency decimal.Decimal `json:"amount_currency"`
+	AmountRub      decimal.Decimal `json:"amount_rub"`
+	EffectiveRate  decimal.Decimal `json:"effective_rate"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+	UsedAt         sql.NullTime    `json:"used_at"`
+	OperationID    sql.NullInt64   `json:"operation_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type ReceiptCounter struct {
+	BranchCode   string    `json:"branch_code"`
+	BusinessDate time.Time `json:"business_date"`
+	LastNumber   int64     `json:"last_number"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ReferenceRate struct {
+	ID           int32           `json:"id"`
+	CurrencyCode string          `json:"currency_code"`
+	RateDate     time.Time       `json:"rate_date"`
+	Nominal      int32           `json:"nominal"`
+	Value        decimal.Decimal `json:"value"`
+	Rate         decimal.Decimal `json:"rate"`
+	Source       string          `json:"source"`
+	ImportedAt   time.Time       `json:"imported_at"`
+}
+
+type ScheduledRateChange struct {
+	ID            int32           `json:"id"`
+	CurrencyID    int32           `json:"currency_id"`
+	BuyRate       decimal.Decimal `json:"buy_rate"`
+	SellRate      decimal.Decimal `json:"sell_rate"`
+	EffectiveAt   time.Time       `json:"effective_at"`
+	Override      bool            `json:"override"`
+	Reason        sql.NullString  `json:"reason"`
+	Status        string          `json:"status"`
+	CreatedBy     sql.NullInt32   `json:"created_by"`
+	CreatedAt     time.Time       `json:"created_at"`
+	AppliedAt     sql.NullTime    `json:"applied_at"`
+	CancelledBy   sql.NullInt32   `json:"cancelled_by"`
+	CancelledAt   sql.NullTime    `json:"cancelled_at"`
+	FailureReason sql.NullString  `json:"failure_reason"`
+}
+
+type Shift struct {
+	ID           int32          `json:"id"`
+	CashierName  string         `json:"cashier_name"`
+	Status       string         `json:"status"`
+	OpenedAt     time.Time      `json:"opened_at"`
+	ClosedAt     sql.NullTime   `json:"closed_at"`
+	CloseComment sql.NullString `json:"close_comment"`
+	CashierID    sql.NullInt32  `json:"cashier_id"`
+}
+
+type ShiftBalance struct {
+	ShiftID          int32               `json:"shift_id"`
+	CurrencyCode     string              `json:"currency_code"`
+	OpeningBalance   decimal.Decimal     `json:"opening_balance"`
+	OperationsAmount decimal.Decimal     `json:"operations_amount"`
+	CashInAmount     decimal.Decimal     `json:"cash_in_amount"`
+	CashOutAmount    decimal.Decimal     `json:"cash_out_amount"`
+	ExpectedBalance  decimal.NullDecimal `json:"expected_balance"`
+	CountedBalance   decimal.NullDecimal `json:"counted_balance"`
+	Discrepancy      decimal.NullDecimal `json:"discrepancy"`
+}
+
+type User struct {
+	ID           int32        `json:"id"`
+	Username     string       `json:"username"`
+	PasswordHash string       `json:"-"`
+	FullName     string       `json:"full_name"`
+	Role         string       `json:"role"`
+	IsActive     bool         `json:"is_active"`
+	LastLoginAt  sql.NullTime `json:"last_login_at"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
 }
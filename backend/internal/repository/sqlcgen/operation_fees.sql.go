@@ -0,0 +1,173 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: operation_fees.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+const createOperationFee = `-- name: CreateOperationFee :one
+INSERT INTO operation_fees (
+    currency_id, operation_type, min_amount_rub, fixed_fee_rub, percent_fee, description
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, currency_id, operation_type, min_amount_rub, fixed_fee_rub, percent_fee, description, created_at, updated_at
+`
+
+type CreateOperationFeeParams struct {
+	CurrencyID    sql.NullInt32   `json:"currency_id"`
+	OperationType sql.NullString  `json:"operation_type"`
+	MinAmountRub  decimal.Decimal `json:"min_amount_rub"`
+	FixedFeeRub   decimal.Decimal `json:"fixed_fee_rub"`
+	PercentFee    decimal.Decimal `json:"percent_fee"`
+	Description   sql.NullString  `json:"description"`
+}
+
+// Создать правило комиссии
+func (q *Queries) CreateOperationFee(ctx context.Context, arg CreateOperationFeeParams) (OperationFee, error) {
+	row := q.db.QueryRowContext(ctx, createOperationFee,
+		arg.CurrencyID,
+		arg.OperationType,
+		arg.MinAmountRub,
+		arg.FixedFeeRub,
+		arg.PercentFee,
+		arg.Description,
+	)
+	var i OperationFee
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.OperationType,
+		&i.MinAmountRub,
+		&i.FixedFeeRub,
+		&i.PercentFee,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteOperationFee = `-- name: DeleteOperationFee :execrows
+DELETE FROM operation_fees
+WHERE id = $1
+`
+
+// Удалить правило комиссии
+func (q *Queries) DeleteOperationFee(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOperationFee, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getOperationFee = `-- name: GetOperationFee :one
+SELECT id, currency_id, operation_type, min_amount_rub, fixed_fee_rub, percent_fee, description, created_at, updated_at FROM operation_fees
+WHERE id = $1
+`
+
+// Получить правило комиссии по идентификатору
+func (q *Queries) GetOperationFee(ctx context.Context, id int32) (OperationFee, error) {
+	row := q.db.QueryRowContext(ctx, getOperationFee, id)
+	var i OperationFee
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.OperationType,
+		&i.MinAmountRub,
+		&i.FixedFeeRub,
+		&i.PercentFee,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listOperationFees = `-- name: ListOperationFees :many
+SELECT id, currency_id, operation_type, min_amount_rub, fixed_fee_rub, percent_fee, description, created_at, updated_at FROM operation_fees
+ORDER BY currency_id NULLS FIRST, operation_type NULLS FIRST, min_amount_rub
+`
+
+// Получить все правила комиссий
+func (q *Queries) ListOperationFees(ctx context.Context) ([]OperationFee, error) {
+	rows, err := q.db.QueryContext(ctx, listOperationFees)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OperationFee{}
+	for rows.Next() {
+		var i OperationFee
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrencyID,
+			&i.OperationType,
+			&i.MinAmountRub,
+			&i.FixedFeeRub,
+			&i.PercentFee,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOperationFee = `-- name: UpdateOperationFee :one
+UPDATE operation_fees
+SET
+    fixed_fee_rub = $1,
+    percent_fee = $2,
+    description = COALESCE($3, description),
+    updated_at = NOW()
+WHERE id = $4
+RETURNING id, currency_id, operation_type, min_amount_rub, fixed_fee_rub, percent_fee, description, created_at, updated_at
+`
+
+type UpdateOperationFeeParams struct {
+	FixedFeeRub decimal.Decimal `json:"fixed_fee_rub"`
+	PercentFee  decimal.Decimal `json:"percent_fee"`
+	Description sql.NullString  `json:"description"`
+	ID          int32           `json:"id"`
+}
+
+// Изменить размер комиссии; область действия и порог ступени не меняются
+func (q *Queries) UpdateOperationFee(ctx context.Context, arg UpdateOperationFeeParams) (OperationFee, error) {
+	row := q.db.QueryRowContext(ctx, updateOperationFee,
+		arg.FixedFeeRub,
+		arg.PercentFee,
+		arg.Description,
+		arg.ID,
+	)
+	var i OperationFee
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.OperationType,
+		&i.MinAmountRub,
+		&i.FixedFeeRub,
+		&i.PercentFee,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -8,6 +8,8 @@ package sqlcgen
 import (
 	"context"
 	"database/sql"
+
+	"github.com/shopspring/decimal"
 )
 
 const createOperationLimit = `-- name: CreateOperationLimit :one
@@ -17,9 +19,9 @@ RETURNING id, limit_name, limit_value, description, created_at, updated_at
 `
 
 type CreateOperationLimitParams struct {
-	LimitName   string         `json:"limit_name"`
-	LimitValue  string         `json:"limit_value"`
-	Description sql.NullString `json:"description"`
+	LimitName   string          `json:"limit_name"`
+	LimitValue  decimal.Decimal `json:"limit_value"`
+	Description sql.NullString  `json:"description"`
 }
 
 // Создать новое ограничение операции
@@ -37,15 +39,18 @@ func (q *Queries) CreateOperationLimit(ctx context.Context, arg CreateOperationL
 	return i, err
 }
 
-const deleteOperationLimit = `-- name: DeleteOperationLimit :exec
+const deleteOperationLimit = `-- name: DeleteOperationLimit :execrows
 DELETE FROM operation_limits
 WHERE limit_name = $1
 `
 
 // Удалить ограничение операции
-func (q *Queries) DeleteOperationLimit(ctx context.Context, limitName string) error {
-	_, err := q.db.ExecContext(ctx, deleteOperationLimit, limitName)
-	return err
+func (q *Queries) DeleteOperationLimit(ctx context.Context, limitName string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOperationLimit, limitName)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
 const getOperationLimit = `-- name: GetOperationLimit :one
@@ -106,19 +111,23 @@ func (q *Queries) ListOperationLimits(ctx context.Context) ([]OperationLimit, er
 
 const updateOperationLimit = `-- name: UpdateOperationLimit :one
 UPDATE operation_limits
-SET limit_value = $2
-WHERE limit_name = $1
+SET
+    limit_value = $1,
+    description = COALESCE($2, description),
+    updated_at = NOW()
+WHERE limit_name = $3
 RETURNING id, limit_name, limit_value, description, created_at, updated_at
 `
 
 type UpdateOperationLimitParams struct {
-	LimitName  string `json:"limit_name"`
-	LimitValue string `json:"limit_value"`
+	LimitValue  decimal.Decimal `json:"limit_value"`
+	Description sql.NullString  `json:"description"`
+	LimitName   string          `json:"limit_name"`
 }
 
 // Обновить значение ограничения операции
 func (q *Queries) UpdateOperationLimit(ctx context.Context, arg UpdateOperationLimitParams) (OperationLimit, error) {
-	row := q.db.QueryRowContext(ctx, updateOperationLimit, arg.LimitName, arg.LimitValue)
+	row := q.db.QueryRowContext(ctx, updateOperationLimit, arg.LimitValue, arg.Description, arg.LimitName)
 	var i OperationLimit
 	err := row.Scan(
 		&i.ID,
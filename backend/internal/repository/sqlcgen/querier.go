@@ -6,23 +6,194 @@ package sqlcgen
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type Querier interface {
+	// Сеансовая блокировка: берётся до начала транзакции, чтобы её снимок был сделан уже после ожидания
+	AcquireAdvisoryLock(ctx context.Context, arg AcquireAdvisoryLockParams) error
+	// Блокировка до конца транзакции. Снимок сериализуемой транзакции к этому моменту уже сделан:
+	// блокировка упорядочивает запись, но не показывает изменений, зафиксированных во время ожидания
+	AcquireAdvisoryXactLock(ctx context.Context, arg AcquireAdvisoryXactLockParams) error
+	// Привязать к ключу сущность, изменённую запросом. Выполняется в транзакции обработчика;
+	// 0 строк — ключ уже занят повторным запросом, и транзакция должна откатиться
+	BindIdempotencyKey(ctx context.Context, arg BindIdempotencyKeyParams) (int64, error)
+	CancelOperation(ctx context.Context, id int64) (Operation, error)
+	// Отменить плановое изменение курса валюты, если оно ещё не применено
+	CancelScheduledRateChange(ctx context.Context, arg CancelScheduledRateChangeParams) (ScheduledRateChange, error)
+	// Изменить остаток наличных на amount (со знаком).
+	// Строка остатка блокируется до конца транзакции, поэтому изменения по валюте выполняются последовательно.
+	ChangeCashBalance(ctx context.Context, arg ChangeCashBalanceParams) (CashBalance, error)
+	// Закрыть смену
+	CloseShift(ctx context.Context, arg CloseShiftParams) (Shift, error)
+	// Сохранить ответ на запрос с ключом идемпотентности
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error
+	// Операция из черновика относится к смене, в которой завершена: тогда же проходят наличные и выдаётся номер чека
+	CompleteOperation(ctx context.Context, arg CompleteOperationParams) (Operation, error)
+	// Время операции — момент подтверждения: по нему считается дневной лимит
+	ConfirmOperation(ctx context.Context, id int64) (Operation, error)
+	// Количество пользователей: при пустой таблице создаётся первый администратор
+	CountUsers(ctx context.Context) (int64, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
+	// Записать движение наличных
+	CreateCashMovement(ctx context.Context, arg CreateCashMovementParams) (CashMovement, error)
 	CreateClient(ctx context.Context, arg CreateClientParams) (Client, error)
+	// Одна из двух операций кросс-конвертации
+	CreateCrossExchangeLeg(ctx context.Context, arg CreateCrossExchangeLegParams) (Operation, error)
 	CreateCurrency(ctx context.Context, arg CreateCurrencyParams) (Currency, error)
+	// Записать новый курс валюты; effective_from по умолчанию — время транзакции, как и currencies.last_rate_update_at,
+	// для планового изменения — время, на которое оно было назначено
+	CreateCurrencyRateHistory(ctx context.Context, arg CreateCurrencyRateHistoryParams) (CurrencyRateHistory, error)
+	// Черновик фиксирует рассчитанные суммы и курс до подтверждения кассиром; номер чека выдаётся при завершении
+	CreateDraftOperation(ctx context.Context, arg CreateDraftOperationParams) (Operation, error)
+	// Занять ключ идемпотентности пользователя на lease_seconds секунд; если ключ уже есть, строка не возвращается
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
 	CreateOperation(ctx context.Context, arg CreateOperationParams) (Operation, error)
+	// Создать правило комиссии
+	CreateOperationFee(ctx context.Context, arg CreateOperationFeeParams) (OperationFee, error)
+	// Создать новое ограничение операции
+	CreateOperationLimit(ctx context.Context, arg CreateOperationLimitParams) (OperationLimit, error)
+	// Создать котировку с зафиксированным курсом
+	CreateRateQuote(ctx context.Context, arg CreateRateQuoteParams) (RateQuote, error)
+	// Компенсирующая операция: обратное направление с теми же суммами, курсом и комиссией
+	CreateReversalOperation(ctx context.Context, arg CreateReversalOperationParams) (Operation, error)
+	CreateScheduledRateChange(ctx context.Context, arg CreateScheduledRateChangeParams) (ScheduledRateChange, error)
+	// Создать пользователя
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	// Удалить валюту: торговля прекращается, строка остаётся для операций и истории курсов
+	DeleteCurrency(ctx context.Context, arg DeleteCurrencyParams) (Currency, error)
+	// Удалить ключи старше заданного момента
+	DeleteExpiredIdempotencyKeys(ctx context.Context, createdAt time.Time) (int64, error)
+	// Освободить ключ, чтобы запрос можно было повторить
+	DeleteIdempotencyKey(ctx context.Context, arg DeleteIdempotencyKeyParams) error
+	// Удалить правило комиссии
+	DeleteOperationFee(ctx context.Context, id int32) (int64, error)
+	// Удалить ограничение операции
+	DeleteOperationLimit(ctx context.Context, limitName string) (int64, error)
+	ExpireDraftOperations(ctx context.Context) (int64, error)
+	// Получить остаток наличных по валюте
+	GetCashBalance(ctx context.Context, currencyCode string) (CashBalance, error)
 	GetClientByID(ctx context.Context, id int32) (Client, error)
 	GetClientByPassport(ctx context.Context, passportNumber string) (Client, error)
 	GetCurrency(ctx context.Context, id int32) (Currency, error)
 	GetCurrencyByCode(ctx context.Context, code string) (Currency, error)
-	GetDailyClientForeignCurrencyVolume(ctx context.Context, arg GetDailyClientForeignCurrencyVolumeParams) (string, error)
+	// Получить курс валюты, действовавший в момент at
+	GetCurrencyRateAt(ctx context.Context, arg GetCurrencyRateAtParams) (CurrencyRateHistory, error)
+	// Объём операций клиента по валюте за бизнес-день [day_start, day_end)
+	GetDailyClientForeignCurrencyVolume(ctx context.Context, arg GetDailyClientForeignCurrencyVolumeParams) (decimal.Decimal, error)
+	// Получить ключ идемпотентности пользователя и сохранённый ответ
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	// Хеш последней записи журнала — начало следующего звена цепочки
+	GetLastAuditEventHash(ctx context.Context) (string, error)
+	// Получить открытую смену
+	GetOpenShift(ctx context.Context) (Shift, error)
+	// Получить открытую смену для закрытия: ожидает завершения проводимых в ней операций
+	GetOpenShiftForUpdate(ctx context.Context) (Shift, error)
+	GetOperation(ctx context.Context, id int64) (Operation, error)
+	GetOperationByReceiptReference(ctx context.Context, receiptReference *string) (GetOperationByReceiptReferenceRow, error)
+	// Получить правило комиссии по идентификатору
+	GetOperationFee(ctx context.Context, id int32) (OperationFee, error)
+	GetOperationForUpdate(ctx context.Context, id int64) (Operation, error)
+	// Получить ограничение операции по имени
+	GetOperationLimit(ctx context.Context, limitName string) (OperationLimit, error)
+	// posted_buy_rate и posted_sell_rate — курсы, объявленные на момент операции; NULL, если операция старше истории курсов
 	GetOperationsForAnalytics(ctx context.Context, arg GetOperationsForAnalyticsParams) ([]GetOperationsForAnalyticsRow, error)
+	// Получить котировку по идентификатору
+	GetRateQuote(ctx context.Context, id string) (RateQuote, error)
+	// Получить официальный курс валюты, действующий на дату: последний установленный не позже on_date
+	GetReferenceRate(ctx context.Context, arg GetReferenceRateParams) (ReferenceRate, error)
+	GetScheduledRateChange(ctx context.Context, id int32) (ScheduledRateChange, error)
+	// Получить смену по идентификатору
+	GetShift(ctx context.Context, id int32) (Shift, error)
+	// Суммы движений наличных за смену по валютам и типам движений
+	GetShiftCashTotals(ctx context.Context, shiftID sql.NullInt32) ([]GetShiftCashTotalsRow, error)
+	// Итоги операций смены по валютам, типам и статусам для Z-отчёта
+	GetShiftOperationTotals(ctx context.Context, shiftID sql.NullInt32) ([]GetShiftOperationTotalsRow, error)
+	// Получить пользователя по идентификатору
+	GetUser(ctx context.Context, id int32) (User, error)
+	// Получить пользователя по имени для входа
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	// Получить записи журнала, новые первыми; пустые строковые фильтры и NULL не ограничивают выборку
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	// Получить записи журнала по порядку, начиная после after_id: для проверки цепочки хешей
+	ListAuditEventsAfter(ctx context.Context, arg ListAuditEventsAfterParams) ([]AuditEvent, error)
+	// Получить остатки наличных по всем валютам
+	ListCashBalances(ctx context.Context) ([]CashBalance, error)
+	// Получить движения наличных, новые первыми; пустой код валюты — по всем валютам
+	ListCashMovements(ctx context.Context, arg ListCashMovementsParams) ([]CashMovement, error)
 	ListClients(ctx context.Context) ([]Client, error)
+	// Валюты, кроме удалённых
 	ListCurrencies(ctx context.Context) ([]Currency, error)
+	// Валюты с признаком is_active и признаком удаления deleted; незаданный признак не проверяется
+	ListCurrenciesByStatus(ctx context.Context, arg ListCurrenciesByStatusParams) ([]Currency, error)
+	// Получить курсы валюты, действовавшие в периоде [from_time, to_time): включая курс, действовавший на его начало
+	ListCurrencyRateHistory(ctx context.Context, arg ListCurrencyRateHistoryParams) ([]CurrencyRateHistory, error)
+	// Получить и заблокировать наступившие плановые изменения: все или одной валюты (currency_id).
+	// Наступившие — не позже начала текущей транзакции, то есть времени операции, которая в ней записывается.
+	ListDueScheduledRateChanges(ctx context.Context, currencyID sql.NullInt32) ([]ScheduledRateChange, error)
+	ListExchangeGroupOperationsForUpdate(ctx context.Context, exchangeGroup sql.NullString) ([]Operation, error)
+	// Получить все правила комиссий
+	ListOperationFees(ctx context.Context) ([]OperationFee, error)
+	// Получить список всех ограничений операций
+	ListOperationLimits(ctx context.Context) ([]OperationLimit, error)
 	ListOperations(ctx context.Context, arg ListOperationsParams) ([]ListOperationsRow, error)
 	ListOperationsByClientAndDateRange(ctx context.Context, arg ListOperationsByClientAndDateRangeParams) ([]Operation, error)
+	ListOperationsByExchangeGroup(ctx context.Context, exchangeGroup sql.NullString) ([]ListOperationsByExchangeGroupRow, error)
+	// Получить официальные курсы всех валют, действующие на дату
+	ListReferenceRates(ctx context.Context, onDate time.Time) ([]ReferenceRate, error)
+	// Получить плановые изменения курса валюты в порядке вступления в силу; пустой status — все статусы
+	ListScheduledRateChanges(ctx context.Context, arg ListScheduledRateChangesParams) ([]ScheduledRateChange, error)
+	// Получить сверку смены по валютам
+	ListShiftBalances(ctx context.Context, shiftID int32) ([]ShiftBalance, error)
+	// Получить смены, новые первыми
+	ListShifts(ctx context.Context, arg ListShiftsParams) ([]Shift, error)
+	// Получить всех пользователей
+	ListUsers(ctx context.Context) ([]User, error)
+	// Получить открытую смену для проведения операции.
+	// Блокировка FOR SHARE не даёт закрыть смену, пока операция не завершится.
+	LockOpenShift(ctx context.Context) (Shift, error)
+	MarkOperationReversed(ctx context.Context, id int64) (Operation, error)
+	MarkScheduledRateChangeApplied(ctx context.Context, id int32) (ScheduledRateChange, error)
+	MarkScheduledRateChangeFailed(ctx context.Context, arg MarkScheduledRateChangeFailedParams) (ScheduledRateChange, error)
+	// Выдать следующий номер чека отделения за бизнес-день.
+	// Строка счётчика блокируется до конца транзакции, поэтому номера выдаются строго по порядку.
+	NextReceiptNumber(ctx context.Context, arg NextReceiptNumberParams) (int64, error)
+	// Открыть смену кассира
+	OpenShift(ctx context.Context, arg OpenShiftParams) (Shift, error)
+	// Проверить, занят ли номер чека
+	ReceiptReferenceExists(ctx context.Context, receiptReference *string) (bool, error)
+	// Повторно занять ключ, аренда которого истекла без сохранённого ответа и без изменённой сущности
+	ReclaimIdempotencyKey(ctx context.Context, arg ReclaimIdempotencyKeyParams) (IdempotencyKey, error)
+	// Отметить успешный вход
+	RecordUserLogin(ctx context.Context, id int32) error
+	// Снять все сеансовые блокировки соединения
+	ReleaseAdvisoryLocks(ctx context.Context) error
+	// Записать сверку смены по валюте
+	SaveShiftBalance(ctx context.Context, arg SaveShiftBalanceParams) (ShiftBalance, error)
+	// Установить неснижаемый остаток по валюте
+	SetCashReserve(ctx context.Context, arg SetCashReserveParams) (CashBalance, error)
+	// Возобновить или приостановить торговлю валютой
+	SetCurrencyActive(ctx context.Context, arg SetCurrencyActiveParams) (Currency, error)
+	// Зафиксировать остатки кассы на открытие смены
+	SnapshotShiftOpeningBalances(ctx context.Context, shiftID int32) error
 	UpdateCurrency(ctx context.Context, arg UpdateCurrencyParams) (Currency, error)
+	// Изменить точность и способ округления сумм валюты
+	UpdateCurrencyRounding(ctx context.Context, arg UpdateCurrencyRoundingParams) (Currency, error)
+	// Изменить размер комиссии; область действия и порог ступени не меняются
+	UpdateOperationFee(ctx context.Context, arg UpdateOperationFeeParams) (OperationFee, error)
+	// Обновить значение ограничения операции
+	UpdateOperationLimit(ctx context.Context, arg UpdateOperationLimitParams) (OperationLimit, error)
+	// Изменить имя, роль или активность пользователя; не переданные поля не меняются
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	// Сменить пароль пользователя
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
+	// Сохранить официальный курс на дату; повторная загрузка той же даты заменяет курс
+	UpsertReferenceRate(ctx context.Context, arg UpsertReferenceRateParams) (ReferenceRate, error)
+	// Отметить котировку использованной; неиспользованная и непросроченная котировка обновляется только один раз
+	UseRateQuote(ctx context.Context, arg UseRateQuoteParams) (RateQuote, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -9,8 +9,184 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
+const acquireAdvisoryLock = `-- name: AcquireAdvisoryLock :exec
+SELECT pg_advisory_lock($1::int, $2::int)
+`
+
+type AcquireAdvisoryLockParams struct {
+	Key1 int32 `json:"key1"`
+	Key2 int32 `json:"key2"`
+}
+
+// Сеансовая блокировка: берётся до начала транзакции, чтобы её снимок был сделан уже после ожидания
+func (q *Queries) AcquireAdvisoryLock(ctx context.Context, arg AcquireAdvisoryLockParams) error {
+	_, err := q.db.ExecContext(ctx, acquireAdvisoryLock, arg.Key1, arg.Key2)
+	return err
+}
+
+const acquireAdvisoryXactLock = `-- name: AcquireAdvisoryXactLock :exec
+SELECT pg_advisory_xact_lock($1::int, $2::int)
+`
+
+type AcquireAdvisoryXactLockParams struct {
+	Key1 int32 `json:"key1"`
+	Key2 int32 `json:"key2"`
+}
+
+// Блокировка до конца транзакции. Снимок сериализуемой транзакции к этому моменту уже сделан:
+// блокировка упорядочивает запись, но не показывает изменений, зафиксированных во время ожидания
+func (q *Queries) AcquireAdvisoryXactLock(ctx context.Context, arg AcquireAdvisoryXactLockParams) error {
+	_, err := q.db.ExecContext(ctx, acquireAdvisoryXactLock, arg.Key1, arg.Key2)
+	return err
+}
+
+const cancelOperation = `-- name: CancelOperation :one
+UPDATE operations
+SET
+    status = 'CANCELLED',
+    cancelled_at = NOW()
+WHERE id = $1 AND status IN ('DRAFT', 'CONFIRMED')
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+`
+
+func (q *Queries) CancelOperation(ctx context.Context, id int64) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, cancelOperation, id)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const completeOperation = `-- name: CompleteOperation :one
+UPDATE operations
+SET
+    status = 'COMPLETED',
+    completed_at = NOW(),
+    shift_id = $1,
+    receipt_reference = $2
+WHERE id = $3 AND status = 'CONFIRMED'
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+`
+
+type CompleteOperationParams struct {
+	ShiftID          sql.NullInt32 `json:"shift_id"`
+	ReceiptReference *string       `json:"receipt_reference"`
+	ID               int64         `json:"id"`
+}
+
+// Операция из черновика относится к смене, в которой завершена: тогда же проходят наличные и выдаётся номер чека
+func (q *Queries) CompleteOperation(ctx context.Context, arg CompleteOperationParams) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, completeOperation, arg.ShiftID, arg.ReceiptReference, arg.ID)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const confirmOperation = `-- name: ConfirmOperation :one
+UPDATE operations
+SET
+    status = 'CONFIRMED',
+    confirmed_at = NOW(),
+    operation_timestamp = NOW()
+WHERE id = $1 AND status = 'DRAFT'
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+`
+
+// Время операции — момент подтверждения: по нему считается дневной лимит
+func (q *Queries) ConfirmOperation(ctx context.Context, id int64) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, confirmOperation, id)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
 const createClient = `-- name: CreateClient :one
 INSERT INTO clients (
   passport_number, full_name, phone_number
@@ -39,20 +215,104 @@ func (q *Queries) CreateClient(ctx context.Context, arg CreateClientParams) (Cli
 	return i, err
 }
 
+const createCrossExchangeLeg = `-- name: CreateCrossExchangeLeg :one
+INSERT INTO operations (
+  client_id, operation_type, currency_id, amount_currency,
+  amount_rub, effective_rate, receipt_reference, exchange_group,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+)
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+`
+
+type CreateCrossExchangeLegParams struct {
+	ClientID         int32           `json:"client_id"`
+	OperationType    string          `json:"operation_type"`
+	CurrencyID       int32           `json:"currency_id"`
+	AmountCurrency   decimal.Decimal `json:"amount_currency"`
+	AmountRub        decimal.Decimal `json:"amount_rub"`
+	EffectiveRate    decimal.Decimal `json:"effective_rate"`
+	ReceiptReference *string         `json:"receipt_reference"`
+	ExchangeGroup    sql.NullString  `json:"exchange_group"`
+	FeeFixedRub      decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub    decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub           decimal.Decimal `json:"fee_rub"`
+	FeeRuleID        sql.NullInt32   `json:"fee_rule_id"`
+	SpreadRub        decimal.Decimal `json:"spread_rub"`
+	ShiftID          sql.NullInt32   `json:"shift_id"`
+	CashierID        sql.NullInt32   `json:"cashier_id"`
+}
+
+// Одна из двух операций кросс-конвертации
+func (q *Queries) CreateCrossExchangeLeg(ctx context.Context, arg CreateCrossExchangeLegParams) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, createCrossExchangeLeg,
+		arg.ClientID,
+		arg.OperationType,
+		arg.CurrencyID,
+		arg.AmountCurrency,
+		arg.AmountRub,
+		arg.EffectiveRate,
+		arg.ReceiptReference,
+		arg.ExchangeGroup,
+		arg.FeeFixedRub,
+		arg.FeePercentRub,
+		arg.FeeRub,
+		arg.FeeRuleID,
+		arg.SpreadRub,
+		arg.ShiftID,
+		arg.CashierID,
+	)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
 const createCurrency = `-- name: CreateCurrency :one
 INSERT INTO currencies (
-    code, name, buy_rate, sell_rate
+    code, name, buy_rate, sell_rate, minor_units, rounding_mode, cash_increment
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6, $7
 )
-RETURNING id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at
+RETURNING id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by
 `
 
 type CreateCurrencyParams struct {
-	Code     string `json:"code"`
-	Name     string `json:"name"`
-	BuyRate  string `json:"buy_rate"`
-	SellRate string `json:"sell_rate"`
+	Code          string              `json:"code"`
+	Name          string              `json:"name"`
+	BuyRate       decimal.Decimal     `json:"buy_rate"`
+	SellRate      decimal.Decimal     `json:"sell_rate"`
+	MinorUnits    int16               `json:"minor_units"`
+	RoundingMode  string              `json:"rounding_mode"`
+	CashIncrement decimal.NullDecimal `json:"cash_increment"`
 }
 
 func (q *Queries) CreateCurrency(ctx context.Context, arg CreateCurrencyParams) (Currency, error) {
@@ -61,6 +321,9 @@ func (q *Queries) CreateCurrency(ctx context.Context, arg CreateCurrencyParams)
 		arg.Name,
 		arg.BuyRate,
 		arg.SellRate,
+		arg.MinorUnits,
+		arg.RoundingMode,
+		arg.CashIncrement,
 	)
 	var i Currency
 	err := row.Scan(
@@ -72,6 +335,94 @@ func (q *Queries) CreateCurrency(ctx context.Context, arg CreateCurrencyParams)
 		&i.LastRateUpdateAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MinorUnits,
+		&i.RoundingMode,
+		&i.CashIncrement,
+		&i.IsActive,
+		&i.InactiveReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const createDraftOperation = `-- name: CreateDraftOperation :one
+INSERT INTO operations (
+  client_id, operation_type, currency_id, amount_currency,
+  amount_rub, effective_rate, status, expires_at,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, 'DRAFT', $7, $8, $9, $10, $11, $12, $13, $14
+)
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+`
+
+type CreateDraftOperationParams struct {
+	ClientID       int32           `json:"client_id"`
+	OperationType  string          `json:"operation_type"`
+	CurrencyID     int32           `json:"currency_id"`
+	AmountCurrency decimal.Decimal `json:"amount_currency"`
+	AmountRub      decimal.Decimal `json:"amount_rub"`
+	EffectiveRate  decimal.Decimal `json:"effective_rate"`
+	ExpiresAt      sql.NullTime    `json:"expires_at"`
+	FeeFixedRub    decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub  decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub         decimal.Decimal `json:"fee_rub"`
+	FeeRuleID      sql.NullInt32   `json:"fee_rule_id"`
+	SpreadRub      decimal.Decimal `json:"spread_rub"`
+	ShiftID        sql.NullInt32   `json:"shift_id"`
+	CashierID      sql.NullInt32   `json:"cashier_id"`
+}
+
+// Черновик фиксирует рассчитанные суммы и курс до подтверждения кассиром; номер чека выдаётся при завершении
+func (q *Queries) CreateDraftOperation(ctx context.Context, arg CreateDraftOperationParams) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, createDraftOperation,
+		arg.ClientID,
+		arg.OperationType,
+		arg.CurrencyID,
+		arg.AmountCurrency,
+		arg.AmountRub,
+		arg.EffectiveRate,
+		arg.ExpiresAt,
+		arg.FeeFixedRub,
+		arg.FeePercentRub,
+		arg.FeeRub,
+		arg.FeeRuleID,
+		arg.SpreadRub,
+		arg.ShiftID,
+		arg.CashierID,
+	)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
 	)
 	return i, err
 }
@@ -79,21 +430,29 @@ func (q *Queries) CreateCurrency(ctx context.Context, arg CreateCurrencyParams)
 const createOperation = `-- name: CreateOperation :one
 INSERT INTO operations (
   client_id, operation_type, currency_id, amount_currency,
-  amount_rub, effective_rate, receipt_reference
+  amount_rub, effective_rate, receipt_reference,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 )
-RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
 `
 
 type CreateOperationParams struct {
-	ClientID         int32  `json:"client_id"`
-	OperationType    string `json:"operation_type"`
-	CurrencyID       int32  `json:"currency_id"`
-	AmountCurrency   string `json:"amount_currency"`
-	AmountRub        string `json:"amount_rub"`
-	EffectiveRate    string `json:"effective_rate"`
-	ReceiptReference string `json:"receipt_reference"`
+	ClientID         int32           `json:"client_id"`
+	OperationType    string          `json:"operation_type"`
+	CurrencyID       int32           `json:"currency_id"`
+	AmountCurrency   decimal.Decimal `json:"amount_currency"`
+	AmountRub        decimal.Decimal `json:"amount_rub"`
+	EffectiveRate    decimal.Decimal `json:"effective_rate"`
+	ReceiptReference *string         `json:"receipt_reference"`
+	FeeFixedRub      decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub    decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub           decimal.Decimal `json:"fee_rub"`
+	FeeRuleID        sql.NullInt32   `json:"fee_rule_id"`
+	SpreadRub        decimal.Decimal `json:"spread_rub"`
+	ShiftID          sql.NullInt32   `json:"shift_id"`
+	CashierID        sql.NullInt32   `json:"cashier_id"`
 }
 
 func (q *Queries) CreateOperation(ctx context.Context, arg CreateOperationParams) (Operation, error) {
@@ -105,6 +464,99 @@ func (q *Queries) CreateOperation(ctx context.Context, arg CreateOperationParams
 		arg.AmountRub,
 		arg.EffectiveRate,
 		arg.ReceiptReference,
+		arg.FeeFixedRub,
+		arg.FeePercentRub,
+		arg.FeeRub,
+		arg.FeeRuleID,
+		arg.SpreadRub,
+		arg.ShiftID,
+		arg.CashierID,
+	)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const createReversalOperation = `-- name: CreateReversalOperation :one
+INSERT INTO operations (
+  client_id, operation_type, currency_id, amount_currency,
+  amount_rub, effective_rate, receipt_reference,
+  status, reversal_of_id, reversal_reason, reversal_comment,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, 'REVERSAL', $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+)
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+`
+
+type CreateReversalOperationParams struct {
+	ClientID         int32           `json:"client_id"`
+	OperationType    string          `json:"operation_type"`
+	CurrencyID       int32           `json:"currency_id"`
+	AmountCurrency   decimal.Decimal `json:"amount_currency"`
+	AmountRub        decimal.Decimal `json:"amount_rub"`
+	EffectiveRate    decimal.Decimal `json:"effective_rate"`
+	ReceiptReference *string         `json:"receipt_reference"`
+	ReversalOfID     sql.NullInt64   `json:"reversal_of_id"`
+	ReversalReason   sql.NullString  `json:"reversal_reason"`
+	ReversalComment  sql.NullString  `json:"reversal_comment"`
+	FeeFixedRub      decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub    decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub           decimal.Decimal `json:"fee_rub"`
+	FeeRuleID        sql.NullInt32   `json:"fee_rule_id"`
+	SpreadRub        decimal.Decimal `json:"spread_rub"`
+	ShiftID          sql.NullInt32   `json:"shift_id"`
+	CashierID        sql.NullInt32   `json:"cashier_id"`
+}
+
+// Компенсирующая операция: обратное направление с теми же суммами, курсом и комиссией
+func (q *Queries) CreateReversalOperation(ctx context.Context, arg CreateReversalOperationParams) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, createReversalOperation,
+		arg.ClientID,
+		arg.OperationType,
+		arg.CurrencyID,
+		arg.AmountCurrency,
+		arg.AmountRub,
+		arg.EffectiveRate,
+		arg.ReceiptReference,
+		arg.ReversalOfID,
+		arg.ReversalReason,
+		arg.ReversalComment,
+		arg.FeeFixedRub,
+		arg.FeePercentRub,
+		arg.FeeRub,
+		arg.FeeRuleID,
+		arg.SpreadRub,
+		arg.ShiftID,
+		arg.CashierID,
 	)
 	var i Operation
 	err := row.Scan(
@@ -118,10 +570,84 @@ func (q *Queries) CreateOperation(ctx context.Context, arg CreateOperationParams
 		&i.OperationTimestamp,
 		&i.ReceiptReference,
 		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
 	)
 	return i, err
 }
 
+const deleteCurrency = `-- name: DeleteCurrency :one
+UPDATE currencies
+SET
+    is_active = FALSE,
+    deleted_at = CURRENT_TIMESTAMP,
+    deleted_by = $1,
+    status_changed_at = CURRENT_TIMESTAMP,
+    status_changed_by = $1
+WHERE code = $2 AND deleted_at IS NULL
+RETURNING id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by
+`
+
+type DeleteCurrencyParams struct {
+	DeletedBy sql.NullInt32 `json:"deleted_by"`
+	Code      string        `json:"code"`
+}
+
+// Удалить валюту: торговля прекращается, строка остаётся для операций и истории курсов
+func (q *Queries) DeleteCurrency(ctx context.Context, arg DeleteCurrencyParams) (Currency, error) {
+	row := q.db.QueryRowContext(ctx, deleteCurrency, arg.DeletedBy, arg.Code)
+	var i Currency
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.LastRateUpdateAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MinorUnits,
+		&i.RoundingMode,
+		&i.CashIncrement,
+		&i.IsActive,
+		&i.InactiveReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const expireDraftOperations = `-- name: ExpireDraftOperations :execrows
+UPDATE operations
+SET status = 'EXPIRED'
+WHERE status = 'DRAFT' AND expires_at < NOW()
+`
+
+func (q *Queries) ExpireDraftOperations(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expireDraftOperations)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getClientByID = `-- name: GetClientByID :one
 SELECT id, passport_number, full_name, phone_number, created_at FROM clients
 WHERE id = $1 LIMIT 1
@@ -159,7 +685,7 @@ func (q *Queries) GetClientByPassport(ctx context.Context, passportNumber string
 }
 
 const getCurrency = `-- name: GetCurrency :one
-SELECT id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at FROM currencies
+SELECT id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by FROM currencies
 WHERE id = $1 LIMIT 1
 `
 
@@ -175,12 +701,21 @@ func (q *Queries) GetCurrency(ctx context.Context, id int32) (Currency, error) {
 		&i.LastRateUpdateAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MinorUnits,
+		&i.RoundingMode,
+		&i.CashIncrement,
+		&i.IsActive,
+		&i.InactiveReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
 	)
 	return i, err
 }
 
 const getCurrencyByCode = `-- name: GetCurrencyByCode :one
-SELECT id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at FROM currencies
+SELECT id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by FROM currencies
 WHERE code = $1 LIMIT 1
 `
 
@@ -196,35 +731,211 @@ func (q *Queries) GetCurrencyByCode(ctx context.Context, code string) (Currency,
 		&i.LastRateUpdateAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MinorUnits,
+		&i.RoundingMode,
+		&i.CashIncrement,
+		&i.IsActive,
+		&i.InactiveReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
 	)
 	return i, err
 }
 
 const getDailyClientForeignCurrencyVolume = `-- name: GetDailyClientForeignCurrencyVolume :one
-SELECT COALESCE(SUM(CASE
-                WHEN o.operation_type = 'CLIENT_SELLS_TO_EXCHANGE' THEN o.amount_currency
-                WHEN o.operation_type = 'CLIENT_BUYS_FROM_EXCHANGE' THEN o.amount_currency
-                ELSE 0
-              END), 0.00)::DECIMAL(19,4) AS total_volume -- Приведение типа для sqlc
+SELECT COALESCE(SUM(o.amount_currency), 0.00)::DECIMAL(19,4) AS total_volume -- Приведение типа для sqlc
 FROM operations o
 WHERE o.client_id = $1
-  AND DATE(o.operation_timestamp) = DATE($2::timestamptz)
-  AND o.currency_id = $3
+  AND o.currency_id = $2
+  AND o.operation_timestamp >= $3::timestamptz
+  AND o.operation_timestamp < $4::timestamptz
+  AND o.status IN ('CONFIRMED', 'COMPLETED')
 `
 
 type GetDailyClientForeignCurrencyVolumeParams struct {
 	ClientID          int32     `json:"client_id"`
-	OperationDate     time.Time `json:"operation_date"`
 	ForeignCurrencyID int32     `json:"foreign_currency_id"`
+	DayStart          time.Time `json:"day_start"`
+	DayEnd            time.Time `json:"day_end"`
 }
 
-func (q *Queries) GetDailyClientForeignCurrencyVolume(ctx context.Context, arg GetDailyClientForeignCurrencyVolumeParams) (string, error) {
-	row := q.db.QueryRowContext(ctx, getDailyClientForeignCurrencyVolume, arg.ClientID, arg.OperationDate, arg.ForeignCurrencyID)
-	var total_volume string
+// Объём операций клиента по валюте за бизнес-день [day_start, day_end)
+func (q *Queries) GetDailyClientForeignCurrencyVolume(ctx context.Context, arg GetDailyClientForeignCurrencyVolumeParams) (decimal.Decimal, error) {
+	row := q.db.QueryRowContext(ctx, getDailyClientForeignCurrencyVolume,
+		arg.ClientID,
+		arg.ForeignCurrencyID,
+		arg.DayStart,
+		arg.DayEnd,
+	)
+	var total_volume decimal.Decimal
 	err := row.Scan(&total_volume)
 	return total_volume, err
 }
 
+const getOperation = `-- name: GetOperation :one
+SELECT id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id FROM operations
+WHERE id = $1
+`
+
+func (q *Queries) GetOperation(ctx context.Context, id int64) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, getOperation, id)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const getOperationByReceiptReference = `-- name: GetOperationByReceiptReference :one
+SELECT
+    o.id,
+    o.client_id,
+    c.full_name AS client_name,
+    c.passport_number AS client_passport_number,
+    o.operation_type,
+    cur.code AS currency_code,
+    cur.minor_units AS currency_minor_units,
+    o.amount_currency,
+    o.amount_rub,
+    o.effective_rate,
+    o.operation_timestamp,
+    o.receipt_reference,
+    o.status,
+    o.reversal_of_id,
+    o.reversal_reason,
+    orig.receipt_reference AS original_receipt_reference,
+    o.exchange_group,
+    o.fee_fixed_rub,
+    o.fee_percent_rub,
+    o.fee_rub
+FROM operations o
+JOIN clients c ON o.client_id = c.id
+JOIN currencies cur ON o.currency_id = cur.id
+LEFT JOIN operations orig ON o.reversal_of_id = orig.id
+WHERE o.receipt_reference = $1
+LIMIT 1
+`
+
+type GetOperationByReceiptReferenceRow struct {
+	ID                       int64           `json:"id"`
+	ClientID                 int32           `json:"client_id"`
+	ClientName               string          `json:"client_name"`
+	ClientPassportNumber     string          `json:"client_passport_number"`
+	OperationType            string          `json:"operation_type"`
+	CurrencyCode             string          `json:"currency_code"`
+	CurrencyMinorUnits       int16           `json:"currency_minor_units"`
+	AmountCurrency           decimal.Decimal `json:"amount_currency"`
+	AmountRub                decimal.Decimal `json:"amount_rub"`
+	EffectiveRate            decimal.Decimal `json:"effective_rate"`
+	OperationTimestamp       sql.NullTime    `json:"operation_timestamp"`
+	ReceiptReference         *string         `json:"receipt_reference"`
+	Status                   string          `json:"status"`
+	ReversalOfID             sql.NullInt64   `json:"reversal_of_id"`
+	ReversalReason           sql.NullString  `json:"reversal_reason"`
+	OriginalReceiptReference *string         `json:"original_receipt_reference"`
+	ExchangeGroup            sql.NullString  `json:"exchange_group"`
+	FeeFixedRub              decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub            decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub                   decimal.Decimal `json:"fee_rub"`
+}
+
+func (q *Queries) GetOperationByReceiptReference(ctx context.Context, receiptReference *string) (GetOperationByReceiptReferenceRow, error) {
+	row := q.db.QueryRowContext(ctx, getOperationByReceiptReference, receiptReference)
+	var i GetOperationByReceiptReferenceRow
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.ClientName,
+		&i.ClientPassportNumber,
+		&i.OperationType,
+		&i.CurrencyCode,
+		&i.CurrencyMinorUnits,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.OriginalReceiptReference,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+	)
+	return i, err
+}
+
+const getOperationForUpdate = `-- name: GetOperationForUpdate :one
+SELECT id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id FROM operations
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetOperationForUpdate(ctx context.Context, id int64) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, getOperationForUpdate, id)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
 const getOperationsForAnalytics = `-- name: GetOperationsForAnalytics :many
 SELECT 
     o.id,
@@ -238,16 +949,30 @@ SELECT
     o.amount_rub,
     o.effective_rate,
     o.operation_timestamp,
-    o.receipt_reference
+    o.receipt_reference,
+    o.fee_rub,
+    o.spread_rub,
+    posted.buy_rate AS posted_buy_rate,
+    posted.sell_rate AS posted_sell_rate
 FROM 
     operations o
 JOIN 
     clients c ON o.client_id = c.id
 JOIN                 
     currencies cur ON o.currency_id = cur.id
+LEFT JOIN currency_rate_history posted ON posted.id = (
+    SELECT h.id
+    FROM currency_rate_history h
+    WHERE h.currency_id = o.currency_id AND h.effective_from <= o.operation_timestamp
+    ORDER BY h.effective_from DESC, h.id DESC
+    LIMIT 1
+)
 WHERE 
     o.operation_timestamp >= $1::timestamptz 
     AND o.operation_timestamp <= $2::timestamptz
+    -- В доход входят только завершённые операции: подтверждённую ещё можно отменить.
+    -- Черновики, отменённые, сторнированные операции и сторно в итоги не входят
+    AND o.status = 'COMPLETED'
 ORDER BY 
     o.operation_timestamp ASC
 `
@@ -258,20 +983,25 @@ type GetOperationsForAnalyticsParams struct {
 }
 
 type GetOperationsForAnalyticsRow struct {
-	ID                 int64        `json:"id"`
-	ClientID           int32        `json:"client_id"`
-	ClientName         string       `json:"client_name"`
-	OperationType      string       `json:"operation_type"`
-	CurrencyID         int32        `json:"currency_id"`
-	CurrencyCode       string       `json:"currency_code"`
-	CurrencyName       string       `json:"currency_name"`
-	AmountCurrency     string       `json:"amount_currency"`
-	AmountRub          string       `json:"amount_rub"`
-	EffectiveRate      string       `json:"effective_rate"`
-	OperationTimestamp sql.NullTime `json:"operation_timestamp"`
-	ReceiptReference   string       `json:"receipt_reference"`
+	ID                 int64               `json:"id"`
+	ClientID           int32               `json:"client_id"`
+	ClientName         string              `json:"client_name"`
+	OperationType      string              `json:"operation_type"`
+	CurrencyID         int32               `json:"currency_id"`
+	CurrencyCode       string              `json:"currency_code"`
+	CurrencyName       string              `json:"currency_name"`
+	AmountCurrency     decimal.Decimal     `json:"amount_currency"`
+	AmountRub          decimal.Decimal     `json:"amount_rub"`
+	EffectiveRate      decimal.Decimal     `json:"effective_rate"`
+	OperationTimestamp sql.NullTime        `json:"operation_timestamp"`
+	ReceiptReference   *string             `json:"receipt_reference"`
+	FeeRub             decimal.Decimal     `json:"fee_rub"`
+	SpreadRub          decimal.Decimal     `json:"spread_rub"`
+	PostedBuyRate      decimal.NullDecimal `json:"posted_buy_rate"`
+	PostedSellRate     decimal.NullDecimal `json:"posted_sell_rate"`
 }
 
+// posted_buy_rate и posted_sell_rate — курсы, объявленные на момент операции; NULL, если операция старше истории курсов
 func (q *Queries) GetOperationsForAnalytics(ctx context.Context, arg GetOperationsForAnalyticsParams) ([]GetOperationsForAnalyticsRow, error) {
 	rows, err := q.db.QueryContext(ctx, getOperationsForAnalytics, arg.StartDate, arg.EndDate)
 	if err != nil {
@@ -294,6 +1024,10 @@ func (q *Queries) GetOperationsForAnalytics(ctx context.Context, arg GetOperatio
 			&i.EffectiveRate,
 			&i.OperationTimestamp,
 			&i.ReceiptReference,
+			&i.FeeRub,
+			&i.SpreadRub,
+			&i.PostedBuyRate,
+			&i.PostedSellRate,
 		); err != nil {
 			return nil, err
 		}
@@ -343,10 +1077,12 @@ func (q *Queries) ListClients(ctx context.Context) ([]Client, error) {
 }
 
 const listCurrencies = `-- name: ListCurrencies :many
-SELECT id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at FROM currencies
+SELECT id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by FROM currencies
+WHERE deleted_at IS NULL
 ORDER BY code
 `
 
+// Валюты, кроме удалённых
 func (q *Queries) ListCurrencies(ctx context.Context) ([]Currency, error) {
 	rows, err := q.db.QueryContext(ctx, listCurrencies)
 	if err != nil {
@@ -365,6 +1101,127 @@ func (q *Queries) ListCurrencies(ctx context.Context) ([]Currency, error) {
 			&i.LastRateUpdateAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.MinorUnits,
+			&i.RoundingMode,
+			&i.CashIncrement,
+			&i.IsActive,
+			&i.InactiveReason,
+			&i.StatusChangedAt,
+			&i.StatusChangedBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCurrenciesByStatus = `-- name: ListCurrenciesByStatus :many
+SELECT id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by FROM currencies
+WHERE ($1::boolean IS NULL OR is_active = $1::boolean)
+  AND ($2::boolean IS NULL OR (deleted_at IS NOT NULL) = $2::boolean)
+ORDER BY code
+`
+
+type ListCurrenciesByStatusParams struct {
+	IsActive sql.NullBool `json:"is_active"`
+	Deleted  sql.NullBool `json:"deleted"`
+}
+
+// Валюты с признаком is_active и признаком удаления deleted; незаданный признак не проверяется
+func (q *Queries) ListCurrenciesByStatus(ctx context.Context, arg ListCurrenciesByStatusParams) ([]Currency, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrenciesByStatus, arg.IsActive, arg.Deleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Currency{}
+	for rows.Next() {
+		var i Currency
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Name,
+			&i.BuyRate,
+			&i.SellRate,
+			&i.LastRateUpdateAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.MinorUnits,
+			&i.RoundingMode,
+			&i.CashIncrement,
+			&i.IsActive,
+			&i.InactiveReason,
+			&i.StatusChangedAt,
+			&i.StatusChangedBy,
+			&i.DeletedAt,
+			&i.DeletedBy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExchangeGroupOperationsForUpdate = `-- name: ListExchangeGroupOperationsForUpdate :many
+SELECT id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id FROM operations
+WHERE exchange_group = $1
+ORDER BY id
+FOR UPDATE
+`
+
+func (q *Queries) ListExchangeGroupOperationsForUpdate(ctx context.Context, exchangeGroup sql.NullString) ([]Operation, error) {
+	rows, err := q.db.QueryContext(ctx, listExchangeGroupOperationsForUpdate, exchangeGroup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Operation{}
+	for rows.Next() {
+		var i Operation
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientID,
+			&i.OperationType,
+			&i.CurrencyID,
+			&i.AmountCurrency,
+			&i.AmountRub,
+			&i.EffectiveRate,
+			&i.OperationTimestamp,
+			&i.ReceiptReference,
+			&i.CreatedAt,
+			&i.Status,
+			&i.ReversalOfID,
+			&i.ReversalReason,
+			&i.ReversalComment,
+			&i.ReversedAt,
+			&i.ExpiresAt,
+			&i.ConfirmedAt,
+			&i.CompletedAt,
+			&i.CancelledAt,
+			&i.ExchangeGroup,
+			&i.FeeFixedRub,
+			&i.FeePercentRub,
+			&i.FeeRub,
+			&i.FeeRuleID,
+			&i.SpreadRub,
+			&i.ShiftID,
+			&i.CashierID,
 		); err != nil {
 			return nil, err
 		}
@@ -387,14 +1244,24 @@ SELECT
     c.passport_number AS client_passport_number,
     o.operation_type,
     cur.code AS currency_code,
+    cur.minor_units AS currency_minor_units,
     o.amount_currency,
     o.amount_rub,
     o.effective_rate,
     o.operation_timestamp,
-    o.receipt_reference
+    o.receipt_reference,
+    o.status,
+    o.reversal_of_id,
+    o.reversal_reason,
+    orig.receipt_reference AS original_receipt_reference,
+    o.exchange_group,
+    o.fee_fixed_rub,
+    o.fee_percent_rub,
+    o.fee_rub
 FROM operations o
 JOIN clients c ON o.client_id = c.id
 JOIN currencies cur ON o.currency_id = cur.id
+LEFT JOIN operations orig ON o.reversal_of_id = orig.id
 ORDER BY o.operation_timestamp DESC
 LIMIT $1 OFFSET $2
 `
@@ -405,17 +1272,26 @@ type ListOperationsParams struct {
 }
 
 type ListOperationsRow struct {
-	ID                   int64        `json:"id"`
-	ClientID             int32        `json:"client_id"`
-	ClientName           string       `json:"client_name"`
-	ClientPassportNumber string       `json:"client_passport_number"`
-	OperationType        string       `json:"operation_type"`
-	CurrencyCode         string       `json:"currency_code"`
-	AmountCurrency       string       `json:"amount_currency"`
-	AmountRub            string       `json:"amount_rub"`
-	EffectiveRate        string       `json:"effective_rate"`
-	OperationTimestamp   sql.NullTime `json:"operation_timestamp"`
-	ReceiptReference     string       `json:"receipt_reference"`
+	ID                       int64           `json:"id"`
+	ClientID                 int32           `json:"client_id"`
+	ClientName               string          `json:"client_name"`
+	ClientPassportNumber     string          `json:"client_passport_number"`
+	OperationType            string          `json:"operation_type"`
+	CurrencyCode             string          `json:"currency_code"`
+	CurrencyMinorUnits       int16           `json:"currency_minor_units"`
+	AmountCurrency           decimal.Decimal `json:"amount_currency"`
+	AmountRub                decimal.Decimal `json:"amount_rub"`
+	EffectiveRate            decimal.Decimal `json:"effective_rate"`
+	OperationTimestamp       sql.NullTime    `json:"operation_timestamp"`
+	ReceiptReference         *string         `json:"receipt_reference"`
+	Status                   string          `json:"status"`
+	ReversalOfID             sql.NullInt64   `json:"reversal_of_id"`
+	ReversalReason           sql.NullString  `json:"reversal_reason"`
+	OriginalReceiptReference *string         `json:"original_receipt_reference"`
+	ExchangeGroup            sql.NullString  `json:"exchange_group"`
+	FeeFixedRub              decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub            decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub                   decimal.Decimal `json:"fee_rub"`
 }
 
 func (q *Queries) ListOperations(ctx context.Context, arg ListOperationsParams) ([]ListOperationsRow, error) {
@@ -434,11 +1310,20 @@ func (q *Queries) ListOperations(ctx context.Context, arg ListOperationsParams)
 			&i.ClientPassportNumber,
 			&i.OperationType,
 			&i.CurrencyCode,
+			&i.CurrencyMinorUnits,
 			&i.AmountCurrency,
 			&i.AmountRub,
 			&i.EffectiveRate,
 			&i.OperationTimestamp,
 			&i.ReceiptReference,
+			&i.Status,
+			&i.ReversalOfID,
+			&i.ReversalReason,
+			&i.OriginalReceiptReference,
+			&i.ExchangeGroup,
+			&i.FeeFixedRub,
+			&i.FeePercentRub,
+			&i.FeeRub,
 		); err != nil {
 			return nil, err
 		}
@@ -454,7 +1339,7 @@ func (q *Queries) ListOperations(ctx context.Context, arg ListOperationsParams)
 }
 
 const listOperationsByClientAndDateRange = `-- name: ListOperationsByClientAndDateRange :many
-SELECT id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at FROM operations
+SELECT id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id FROM operations
 WHERE client_id = $1
 AND operation_timestamp >= $2 -- date_from
 AND operation_timestamp <= $3 -- date_to
@@ -487,6 +1372,120 @@ func (q *Queries) ListOperationsByClientAndDateRange(ctx context.Context, arg Li
 			&i.OperationTimestamp,
 			&i.ReceiptReference,
 			&i.CreatedAt,
+			&i.Status,
+			&i.ReversalOfID,
+			&i.ReversalReason,
+			&i.ReversalComment,
+			&i.ReversedAt,
+			&i.ExpiresAt,
+			&i.ConfirmedAt,
+			&i.CompletedAt,
+			&i.CancelledAt,
+			&i.ExchangeGroup,
+			&i.FeeFixedRub,
+			&i.FeePercentRub,
+			&i.FeeRub,
+			&i.FeeRuleID,
+			&i.SpreadRub,
+			&i.ShiftID,
+			&i.CashierID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOperationsByExchangeGroup = `-- name: ListOperationsByExchangeGroup :many
+SELECT
+    o.id,
+    o.client_id,
+    c.full_name AS client_name,
+    c.passport_number AS client_passport_number,
+    o.operation_type,
+    cur.code AS currency_code,
+    cur.minor_units AS currency_minor_units,
+    o.amount_currency,
+    o.amount_rub,
+    o.effective_rate,
+    o.operation_timestamp,
+    o.receipt_reference,
+    o.status,
+    o.reversal_of_id,
+    o.reversal_reason,
+    orig.receipt_reference AS original_receipt_reference,
+    o.exchange_group,
+    o.fee_fixed_rub,
+    o.fee_percent_rub,
+    o.fee_rub
+FROM operations o
+JOIN clients c ON o.client_id = c.id
+JOIN currencies cur ON o.currency_id = cur.id
+LEFT JOIN operations orig ON o.reversal_of_id = orig.id
+WHERE o.exchange_group = $1
+ORDER BY o.id
+`
+
+type ListOperationsByExchangeGroupRow struct {
+	ID                       int64           `json:"id"`
+	ClientID                 int32           `json:"client_id"`
+	ClientName               string          `json:"client_name"`
+	ClientPassportNumber     string          `json:"client_passport_number"`
+	OperationType            string          `json:"operation_type"`
+	CurrencyCode             string          `json:"currency_code"`
+	CurrencyMinorUnits       int16           `json:"currency_minor_units"`
+	AmountCurrency           decimal.Decimal `json:"amount_currency"`
+	AmountRub                decimal.Decimal `json:"amount_rub"`
+	EffectiveRate            decimal.Decimal `json:"effective_rate"`
+	OperationTimestamp       sql.NullTime    `json:"operation_timestamp"`
+	ReceiptReference         *string         `json:"receipt_reference"`
+	Status                   string          `json:"status"`
+	ReversalOfID             sql.NullInt64   `json:"reversal_of_id"`
+	ReversalReason           sql.NullString  `json:"reversal_reason"`
+	OriginalReceiptReference *string         `json:"original_receipt_reference"`
+	ExchangeGroup            sql.NullString  `json:"exchange_group"`
+	FeeFixedRub              decimal.Decimal `json:"fee_fixed_rub"`
+	FeePercentRub            decimal.Decimal `json:"fee_percent_rub"`
+	FeeRub                   decimal.Decimal `json:"fee_rub"`
+}
+
+func (q *Queries) ListOperationsByExchangeGroup(ctx context.Context, exchangeGroup sql.NullString) ([]ListOperationsByExchangeGroupRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOperationsByExchangeGroup, exchangeGroup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOperationsByExchangeGroupRow{}
+	for rows.Next() {
+		var i ListOperationsByExchangeGroupRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ClientID,
+			&i.ClientName,
+			&i.ClientPassportNumber,
+			&i.OperationType,
+			&i.CurrencyCode,
+			&i.CurrencyMinorUnits,
+			&i.AmountCurrency,
+			&i.AmountRub,
+			&i.EffectiveRate,
+			&i.OperationTimestamp,
+			&i.ReceiptReference,
+			&i.Status,
+			&i.ReversalOfID,
+			&i.ReversalReason,
+			&i.OriginalReceiptReference,
+			&i.ExchangeGroup,
+			&i.FeeFixedRub,
+			&i.FeePercentRub,
+			&i.FeeRub,
 		); err != nil {
 			return nil, err
 		}
@@ -501,6 +1500,109 @@ func (q *Queries) ListOperationsByClientAndDateRange(ctx context.Context, arg Li
 	return items, nil
 }
 
+const markOperationReversed = `-- name: MarkOperationReversed :one
+UPDATE operations
+SET
+    status = 'REVERSED',
+    reversed_at = NOW()
+WHERE id = $1 AND status = 'COMPLETED'
+RETURNING id, client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, operation_timestamp, receipt_reference, created_at, status, reversal_of_id, reversal_reason, reversal_comment, reversed_at, expires_at, confirmed_at, completed_at, cancelled_at, exchange_group, fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+`
+
+func (q *Queries) MarkOperationReversed(ctx context.Context, id int64) (Operation, error) {
+	row := q.db.QueryRowContext(ctx, markOperationReversed, id)
+	var i Operation
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.OperationTimestamp,
+		&i.ReceiptReference,
+		&i.CreatedAt,
+		&i.Status,
+		&i.ReversalOfID,
+		&i.ReversalReason,
+		&i.ReversalComment,
+		&i.ReversedAt,
+		&i.ExpiresAt,
+		&i.ConfirmedAt,
+		&i.CompletedAt,
+		&i.CancelledAt,
+		&i.ExchangeGroup,
+		&i.FeeFixedRub,
+		&i.FeePercentRub,
+		&i.FeeRub,
+		&i.FeeRuleID,
+		&i.SpreadRub,
+		&i.ShiftID,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const releaseAdvisoryLocks = `-- name: ReleaseAdvisoryLocks :exec
+SELECT pg_advisory_unlock_all()
+`
+
+// Снять все сеансовые блокировки соединения
+func (q *Queries) ReleaseAdvisoryLocks(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, releaseAdvisoryLocks)
+	return err
+}
+
+const setCurrencyActive = `-- name: SetCurrencyActive :one
+UPDATE currencies
+SET
+    is_active = $1,
+    inactive_reason = $2,
+    status_changed_at = CURRENT_TIMESTAMP,
+    status_changed_by = $3
+WHERE code = $4
+RETURNING id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by
+`
+
+type SetCurrencyActiveParams struct {
+	IsActive       bool           `json:"is_active"`
+	InactiveReason sql.NullString `json:"inactive_reason"`
+	ChangedBy      sql.NullInt32  `json:"changed_by"`
+	Code           string         `json:"code"`
+}
+
+// Возобновить или приостановить торговлю валютой
+func (q *Queries) SetCurrencyActive(ctx context.Context, arg SetCurrencyActiveParams) (Currency, error) {
+	row := q.db.QueryRowContext(ctx, setCurrencyActive,
+		arg.IsActive,
+		arg.InactiveReason,
+		arg.ChangedBy,
+		arg.Code,
+	)
+	var i Currency
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.LastRateUpdateAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MinorUnits,
+		&i.RoundingMode,
+		&i.CashIncrement,
+		&i.IsActive,
+		&i.InactiveReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
 const updateCurrency = `-- name: UpdateCurrency :one
 UPDATE currencies
 SET 
@@ -508,13 +1610,13 @@ SET
     sell_rate = $3,
     last_rate_update_at = NOW()
 WHERE code = $1
-RETURNING id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at
+RETURNING id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by
 `
 
 type UpdateCurrencyParams struct {
-	Code     string `json:"code"`
-	BuyRate  string `json:"buy_rate"`
-	SellRate string `json:"sell_rate"`
+	Code     string          `json:"code"`
+	BuyRate  decimal.Decimal `json:"buy_rate"`
+	SellRate decimal.Decimal `json:"sell_rate"`
 }
 
 func (q *Queries) UpdateCurrency(ctx context.Context, arg UpdateCurrencyParams) (Currency, error) {
@@ -529,6 +1631,63 @@ func (q *Queries) UpdateCurrency(ctx context.Context, arg UpdateCurrencyParams)
 		&i.LastRateUpdateAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.MinorUnits,
+		&i.RoundingMode,
+		&i.CashIncrement,
+		&i.IsActive,
+		&i.InactiveReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
+	)
+	return i, err
+}
+
+const updateCurrencyRounding = `-- name: UpdateCurrencyRounding :one
+UPDATE currencies
+SET
+    minor_units = $2,
+    rounding_mode = $3,
+    cash_increment = $4
+WHERE code = $1
+RETURNING id, code, name, buy_rate, sell_rate, last_rate_update_at, created_at, updated_at, minor_units, rounding_mode, cash_increment, is_active, inactive_reason, status_changed_at, status_changed_by, deleted_at, deleted_by
+`
+
+type UpdateCurrencyRoundingParams struct {
+	Code          string              `json:"code"`
+	MinorUnits    int16               `json:"minor_units"`
+	RoundingMode  string              `json:"rounding_mode"`
+	CashIncrement decimal.NullDecimal `json:"cash_increment"`
+}
+
+// Изменить точность и способ округления сумм валюты
+func (q *Queries) UpdateCurrencyRounding(ctx context.Context, arg UpdateCurrencyRoundingParams) (Currency, error) {
+	row := q.db.QueryRowContext(ctx, updateCurrencyRounding,
+		arg.Code,
+		arg.MinorUnits,
+		arg.RoundingMode,
+		arg.CashIncrement,
+	)
+	var i Currency
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Name,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.LastRateUpdateAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MinorUnits,
+		&i.RoundingMode,
+		&i.CashIncrement,
+		&i.IsActive,
+		&i.InactiveReason,
+		&i.StatusChangedAt,
+		&i.StatusChangedBy,
+		&i.DeletedAt,
+		&i.DeletedBy,
 	)
 	return i, err
 }
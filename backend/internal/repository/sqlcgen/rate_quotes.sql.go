@@ -0,0 +1,130 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: rate_quotes.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createRateQuote = `-- name: CreateRateQuote :one
+INSERT INTO rate_quotes (
+    id, client_id, operation_type, currency_id, amount,
+    amount_currency, amount_rub, effective_rate, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING id, client_id, operation_type, currency_id, amount, amount_currency, amount_rub, effective_rate, expires_at, used_at, operation_id, created_at
+`
+
+type CreateRateQuoteParams struct {
+	ID             string          `json:"id"`
+	ClientID       sql.NullInt32   `json:"client_id"`
+	OperationType  string          `json:"operation_type"`
+	CurrencyID     int32           `json:"currency_id"`
+	Amount         string          `json:"amount"`
+	AmountCurrency decimal.Decimal `json:"amount_currency"`
+	AmountRub      decimal.Decimal `json:"amount_rub"`
+	EffectiveRate  decimal.Decimal `json:"effective_rate"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+}
+
+// Создать котировку с зафиксированным курсом
+func (q *Queries) CreateRateQuote(ctx context.Context, arg CreateRateQuoteParams) (RateQuote, error) {
+	row := q.db.QueryRowContext(ctx, createRateQuote,
+		arg.ID,
+		arg.ClientID,
+		arg.OperationType,
+		arg.CurrencyID,
+		arg.Amount,
+		arg.AmountCurrency,
+		arg.AmountRub,
+		arg.EffectiveRate,
+		arg.ExpiresAt,
+	)
+	var i RateQuote
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.Amount,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.OperationID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRateQuote = `-- name: GetRateQuote :one
+SELECT id, client_id, operation_type, currency_id, amount, amount_currency, amount_rub, effective_rate, expires_at, used_at, operation_id, created_at FROM rate_quotes
+WHERE id = $1
+`
+
+// Получить котировку по идентификатору
+func (q *Queries) GetRateQuote(ctx context.Context, id string) (RateQuote, error) {
+	row := q.db.QueryRowContext(ctx, getRateQuote, id)
+	var i RateQuote
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.Amount,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.OperationID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useRateQuote = `-- name: UseRateQuote :one
+UPDATE rate_quotes
+SET
+    used_at = NOW(),
+    operation_id = $2
+WHERE id = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING id, client_id, operation_type, currency_id, amount, amount_currency, amount_rub, effective_rate, expires_at, used_at, operation_id, created_at
+`
+
+type UseRateQuoteParams struct {
+	ID          string        `json:"id"`
+	OperationID sql.NullInt64 `json:"operation_id"`
+}
+
+// Отметить котировку использованной; неиспользованная и непросроченная котировка обновляется только один раз
+func (q *Queries) UseRateQuote(ctx context.Context, arg UseRateQuoteParams) (RateQuote, error) {
+	row := q.db.QueryRowContext(ctx, useRateQuote, arg.ID, arg.OperationID)
+	var i RateQuote
+	err := row.Scan(
+		&i.ID,
+		&i.ClientID,
+		&i.OperationType,
+		&i.CurrencyID,
+		&i.Amount,
+		&i.AmountCurrency,
+		&i.AmountRub,
+		&i.EffectiveRate,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.OperationID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
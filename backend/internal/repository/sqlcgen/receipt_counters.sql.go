@@ -0,0 +1,49 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: receipt_counters.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"time"
+)
+
+const nextReceiptNumber = `-- name: NextReceiptNumber :one
+INSERT INTO receipt_counters (branch_code, business_date, last_number)
+VALUES ($1, $2, 1)
+ON CONFLICT (branch_code, business_date) DO UPDATE
+SET
+    last_number = receipt_counters.last_number + 1,
+    updated_at = NOW()
+RETURNING last_number
+`
+
+type NextReceiptNumberParams struct {
+	BranchCode   string    `json:"branch_code"`
+	BusinessDate time.Time `json:"business_date"`
+}
+
+// Выдать следующий номер чека отделения за бизнес-день.
+// Строка счётчика блокируется до конца транзакции, поэтому номера выдаются строго по порядку.
+func (q *Queries) NextReceiptNumber(ctx context.Context, arg NextReceiptNumberParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, nextReceiptNumber, arg.BranchCode, arg.BusinessDate)
+	var last_number int64
+	err := row.Scan(&last_number)
+	return last_number, err
+}
+
+const receiptReferenceExists = `-- name: ReceiptReferenceExists :one
+SELECT EXISTS (
+    SELECT 1 FROM operations WHERE receipt_reference = $1
+)
+`
+
+// Проверить, занят ли номер чека
+func (q *Queries) ReceiptReferenceExists(ctx context.Context, receiptReference *string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, receiptReferenceExists, receiptReference)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,129 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: reference_rates.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const getReferenceRate = `-- name: GetReferenceRate :one
+SELECT id, currency_code, rate_date, nominal, value, rate, source, imported_at FROM reference_rates
+WHERE currency_code = $1 AND rate_date <= $2::date
+ORDER BY rate_date DESC
+LIMIT 1
+`
+
+type GetReferenceRateParams struct {
+	CurrencyCode string    `json:"currency_code"`
+	OnDate       time.Time `json:"on_date"`
+}
+
+// Получить официальный курс валюты, действующий на дату: последний установленный не позже on_date
+func (q *Queries) GetReferenceRate(ctx context.Context, arg GetReferenceRateParams) (ReferenceRate, error) {
+	row := q.db.QueryRowContext(ctx, getReferenceRate, arg.CurrencyCode, arg.OnDate)
+	var i ReferenceRate
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyCode,
+		&i.RateDate,
+		&i.Nominal,
+		&i.Value,
+		&i.Rate,
+		&i.Source,
+		&i.ImportedAt,
+	)
+	return i, err
+}
+
+const listReferenceRates = `-- name: ListReferenceRates :many
+SELECT DISTINCT ON (currency_code) id, currency_code, rate_date, nominal, value, rate, source, imported_at FROM reference_rates
+WHERE rate_date <= $1::date
+ORDER BY currency_code, rate_date DESC
+`
+
+// Получить официальные курсы всех валют, действующие на дату
+func (q *Queries) ListReferenceRates(ctx context.Context, onDate time.Time) ([]ReferenceRate, error) {
+	rows, err := q.db.QueryContext(ctx, listReferenceRates, onDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReferenceRate{}
+	for rows.Next() {
+		var i ReferenceRate
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrencyCode,
+			&i.RateDate,
+			&i.Nominal,
+			&i.Value,
+			&i.Rate,
+			&i.Source,
+			&i.ImportedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertReferenceRate = `-- name: UpsertReferenceRate :one
+INSERT INTO reference_rates (
+    currency_code, rate_date, nominal, value, rate, source
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (currency_code, rate_date) DO UPDATE
+SET nominal = EXCLUDED.nominal,
+    value = EXCLUDED.value,
+    rate = EXCLUDED.rate,
+    source = EXCLUDED.source,
+    imported_at = CURRENT_TIMESTAMP
+RETURNING id, currency_code, rate_date, nominal, value, rate, source, imported_at
+`
+
+type UpsertReferenceRateParams struct {
+	CurrencyCode string          `json:"currency_code"`
+	RateDate     time.Time       `json:"rate_date"`
+	Nominal      int32           `json:"nominal"`
+	Value        decimal.Decimal `json:"value"`
+	Rate         decimal.Decimal `json:"rate"`
+	Source       string          `json:"source"`
+}
+
+// Сохранить официальный курс на дату; повторная загрузка той же даты заменяет курс
+func (q *Queries) UpsertReferenceRate(ctx context.Context, arg UpsertReferenceRateParams) (ReferenceRate, error) {
+	row := q.db.QueryRowContext(ctx, upsertReferenceRate,
+		arg.CurrencyCode,
+		arg.RateDate,
+		arg.Nominal,
+		arg.Value,
+		arg.Rate,
+		arg.Source,
+	)
+	var i ReferenceRate
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyCode,
+		&i.RateDate,
+		&i.Nominal,
+		&i.Value,
+		&i.Rate,
+		&i.Source,
+		&i.ImportedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,289 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: scheduled_rate_changes.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const cancelScheduledRateChange = `-- name: CancelScheduledRateChange :one
+UPDATE scheduled_rate_changes
+SET status = 'CANCELLED', cancelled_by = $3, cancelled_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND currency_id = $2 AND status = 'PENDING'
+RETURNING id, currency_id, buy_rate, sell_rate, effective_at, override, reason, status, created_by, created_at, applied_at, cancelled_by, cancelled_at, failure_reason
+`
+
+type CancelScheduledRateChangeParams struct {
+	ID          int32         `json:"id"`
+	CurrencyID  int32         `json:"currency_id"`
+	CancelledBy sql.NullInt32 `json:"cancelled_by"`
+}
+
+// Отменить плановое изменение курса валюты, если оно ещё не применено
+func (q *Queries) CancelScheduledRateChange(ctx context.Context, arg CancelScheduledRateChangeParams) (ScheduledRateChange, error) {
+	row := q.db.QueryRowContext(ctx, cancelScheduledRateChange, arg.ID, arg.CurrencyID, arg.CancelledBy)
+	var i ScheduledRateChange
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.EffectiveAt,
+		&i.Override,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.AppliedAt,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.FailureReason,
+	)
+	return i, err
+}
+
+const createScheduledRateChange = `-- name: CreateScheduledRateChange :one
+INSERT INTO scheduled_rate_changes (
+    currency_id, buy_rate, sell_rate, effective_at, override, reason, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, currency_id, buy_rate, sell_rate, effective_at, override, reason, status, created_by, created_at, applied_at, cancelled_by, cancelled_at, failure_reason
+`
+
+type CreateScheduledRateChangeParams struct {
+	CurrencyID  int32           `json:"currency_id"`
+	BuyRate     decimal.Decimal `json:"buy_rate"`
+	SellRate    decimal.Decimal `json:"sell_rate"`
+	EffectiveAt time.Time       `json:"effective_at"`
+	Override    bool            `json:"override"`
+	Reason      sql.NullString  `json:"reason"`
+	CreatedBy   sql.NullInt32   `json:"created_by"`
+}
+
+func (q *Queries) CreateScheduledRateChange(ctx context.Context, arg CreateScheduledRateChangeParams) (ScheduledRateChange, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledRateChange,
+		arg.CurrencyID,
+		arg.BuyRate,
+		arg.SellRate,
+		arg.EffectiveAt,
+		arg.Override,
+		arg.Reason,
+		arg.CreatedBy,
+	)
+	var i ScheduledRateChange
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.EffectiveAt,
+		&i.Override,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.AppliedAt,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.FailureReason,
+	)
+	return i, err
+}
+
+const getScheduledRateChange = `-- name: GetScheduledRateChange :one
+SELECT id, currency_id, buy_rate, sell_rate, effective_at, override, reason, status, created_by, created_at, applied_at, cancelled_by, cancelled_at, failure_reason FROM scheduled_rate_changes
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetScheduledRateChange(ctx context.Context, id int32) (ScheduledRateChange, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledRateChange, id)
+	var i ScheduledRateChange
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.EffectiveAt,
+		&i.Override,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.AppliedAt,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.FailureReason,
+	)
+	return i, err
+}
+
+const listDueScheduledRateChanges = `-- name: ListDueScheduledRateChanges :many
+SELECT id, currency_id, buy_rate, sell_rate, effective_at, override, reason, status, created_by, created_at, applied_at, cancelled_by, cancelled_at, failure_reason FROM scheduled_rate_changes
+WHERE status = 'PENDING'
+  AND effective_at <= CURRENT_TIMESTAMP
+  AND ($1::int IS NULL OR currency_id = $1::int)
+ORDER BY effective_at, id
+FOR UPDATE
+`
+
+// Получить и заблокировать наступившие плановые изменения: все или одной валюты (currency_id).
+// Наступившие — не позже начала текущей транзакции, то есть времени операции, которая в ней записывается.
+func (q *Queries) ListDueScheduledRateChanges(ctx context.Context, currencyID sql.NullInt32) ([]ScheduledRateChange, error) {
+	rows, err := q.db.QueryContext(ctx, listDueScheduledRateChanges, currencyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledRateChange{}
+	for rows.Next() {
+		var i ScheduledRateChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrencyID,
+			&i.BuyRate,
+			&i.SellRate,
+			&i.EffectiveAt,
+			&i.Override,
+			&i.Reason,
+			&i.Status,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.AppliedAt,
+			&i.CancelledBy,
+			&i.CancelledAt,
+			&i.FailureReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledRateChanges = `-- name: ListScheduledRateChanges :many
+SELECT id, currency_id, buy_rate, sell_rate, effective_at, override, reason, status, created_by, created_at, applied_at, cancelled_by, cancelled_at, failure_reason FROM scheduled_rate_changes
+WHERE currency_id = $1
+  AND ($2::text = '' OR status = $2::text)
+ORDER BY effective_at, id
+`
+
+type ListScheduledRateChangesParams struct {
+	CurrencyID int32  `json:"currency_id"`
+	Status     string `json:"status"`
+}
+
+// Получить плановые изменения курса валюты в порядке вступления в силу; пустой status — все статусы
+func (q *Queries) ListScheduledRateChanges(ctx context.Context, arg ListScheduledRateChangesParams) ([]ScheduledRateChange, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledRateChanges, arg.CurrencyID, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledRateChange{}
+	for rows.Next() {
+		var i ScheduledRateChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.CurrencyID,
+			&i.BuyRate,
+			&i.SellRate,
+			&i.EffectiveAt,
+			&i.Override,
+			&i.Reason,
+			&i.Status,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.AppliedAt,
+			&i.CancelledBy,
+			&i.CancelledAt,
+			&i.FailureReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markScheduledRateChangeApplied = `-- name: MarkScheduledRateChangeApplied :one
+UPDATE scheduled_rate_changes
+SET status = 'APPLIED', applied_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'PENDING'
+RETURNING id, currency_id, buy_rate, sell_rate, effective_at, override, reason, status, created_by, created_at, applied_at, cancelled_by, cancelled_at, failure_reason
+`
+
+func (q *Queries) MarkScheduledRateChangeApplied(ctx context.Context, id int32) (ScheduledRateChange, error) {
+	row := q.db.QueryRowContext(ctx, markScheduledRateChangeApplied, id)
+	var i ScheduledRateChange
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.EffectiveAt,
+		&i.Override,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.AppliedAt,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.FailureReason,
+	)
+	return i, err
+}
+
+const markScheduledRateChangeFailed = `-- name: MarkScheduledRateChangeFailed :one
+UPDATE scheduled_rate_changes
+SET status = 'FAILED', failure_reason = $2
+WHERE id = $1 AND status = 'PENDING'
+RETURNING id, currency_id, buy_rate, sell_rate, effective_at, override, reason, status, created_by, created_at, applied_at, cancelled_by, cancelled_at, failure_reason
+`
+
+type MarkScheduledRateChangeFailedParams struct {
+	ID            int32          `json:"id"`
+	FailureReason sql.NullString `json:"failure_reason"`
+}
+
+func (q *Queries) MarkScheduledRateChangeFailed(ctx context.Context, arg MarkScheduledRateChangeFailedParams) (ScheduledRateChange, error) {
+	row := q.db.QueryRowContext(ctx, markScheduledRateChangeFailed, arg.ID, arg.FailureReason)
+	var i ScheduledRateChange
+	err := row.Scan(
+		&i.ID,
+		&i.CurrencyID,
+		&i.BuyRate,
+		&i.SellRate,
+		&i.EffectiveAt,
+		&i.Override,
+		&i.Reason,
+		&i.Status,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.AppliedAt,
+		&i.CancelledBy,
+		&i.CancelledAt,
+		&i.FailureReason,
+	)
+	return i, err
+}
@@ -0,0 +1,411 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: shifts.sql
+
+package sqlcgen
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+const closeShift = `-- name: CloseShift :one
+UPDATE shifts
+SET
+    status = 'CLOSED',
+    closed_at = NOW(),
+    close_comment = $1
+WHERE id = $2 AND status = 'OPEN'
+RETURNING id, cashier_name, status, opened_at, closed_at, close_comment, cashier_id
+`
+
+type CloseShiftParams struct {
+	CloseComment sql.NullString `json:"close_comment"`
+	ID           int32          `json:"id"`
+}
+
+// Закрыть смену
+func (q *Queries) CloseShift(ctx context.Context, arg CloseShiftParams) (Shift, error) {
+	row := q.db.QueryRowContext(ctx, closeShift, arg.CloseComment, arg.ID)
+	var i Shift
+	err := row.Scan(
+		&i.ID,
+		&i.CashierName,
+		&i.Status,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.CloseComment,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const getOpenShift = `-- name: GetOpenShift :one
+SELECT id, cashier_name, status, opened_at, closed_at, close_comment, cashier_id FROM shifts
+WHERE status = 'OPEN'
+`
+
+// Получить открытую смену
+func (q *Queries) GetOpenShift(ctx context.Context) (Shift, error) {
+	row := q.db.QueryRowContext(ctx, getOpenShift)
+	var i Shift
+	err := row.Scan(
+		&i.ID,
+		&i.CashierName,
+		&i.Status,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.CloseComment,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const getOpenShiftForUpdate = `-- name: GetOpenShiftForUpdate :one
+SELECT id, cashier_name, status, opened_at, closed_at, close_comment, cashier_id FROM shifts
+WHERE status = 'OPEN'
+FOR UPDATE
+`
+
+// Получить открытую смену для закрытия: ожидает завершения проводимых в ней операций
+func (q *Queries) GetOpenShiftForUpdate(ctx context.Context) (Shift, error) {
+	row := q.db.QueryRowContext(ctx, getOpenShiftForUpdate)
+	var i Shift
+	err := row.Scan(
+		&i.ID,
+		&i.CashierName,
+		&i.Status,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.CloseComment,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const getShift = `-- name: GetShift :one
+SELECT id, cashier_name, status, opened_at, closed_at, close_comment, cashier_id FROM shifts
+WHERE id = $1
+`
+
+// Получить смену по идентификатору
+func (q *Queries) GetShift(ctx context.Context, id int32) (Shift, error) {
+	row := q.db.QueryRowContext(ctx, getShift, id)
+	var i Shift
+	err := row.Scan(
+		&i.ID,
+		&i.CashierName,
+		&i.Status,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.CloseComment,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const getShiftCashTotals = `-- name: GetShiftCashTotals :many
+SELECT
+    currency_code,
+    movement_type,
+    SUM(amount)::DECIMAL(19,4) AS total_amount -- Приведение типа для sqlc
+FROM cash_movements
+WHERE shift_id = $1
+GROUP BY currency_code, movement_type
+ORDER BY currency_code, movement_type
+`
+
+type GetShiftCashTotalsRow struct {
+	CurrencyCode string          `json:"currency_code"`
+	MovementType string          `json:"movement_type"`
+	TotalAmount  decimal.Decimal `json:"total_amount"`
+}
+
+// Суммы движений наличных за смену по валютам и типам движений
+func (q *Queries) GetShiftCashTotals(ctx context.Context, shiftID sql.NullInt32) ([]GetShiftCashTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getShiftCashTotals, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetShiftCashTotalsRow{}
+	for rows.Next() {
+		var i GetShiftCashTotalsRow
+		if err := rows.Scan(&i.CurrencyCode, &i.MovementType, &i.TotalAmount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getShiftOperationTotals = `-- name: GetShiftOperationTotals :many
+SELECT
+    cur.code AS currency_code,
+    o.operation_type,
+    o.status,
+    COUNT(*) AS operations_count,
+    SUM(o.amount_currency)::DECIMAL(19,4) AS amount_currency, -- Приведение типа для sqlc
+    SUM(o.amount_rub)::DECIMAL(19,4) AS amount_rub,
+    SUM(o.fee_rub)::DECIMAL(19,4) AS fee_rub
+FROM operations o
+JOIN currencies cur ON o.currency_id = cur.id
+WHERE o.shift_id = $1
+  AND o.status IN ('COMPLETED', 'REVERSED', 'REVERSAL')
+GROUP BY cur.code, o.operation_type, o.status
+ORDER BY cur.code, o.operation_type, o.status
+`
+
+type GetShiftOperationTotalsRow struct {
+	CurrencyCode    string          `json:"currency_code"`
+	OperationType   string          `json:"operation_type"`
+	Status          string          `json:"status"`
+	OperationsCount int64           `json:"operations_count"`
+	AmountCurrency  decimal.Decimal `json:"amount_currency"`
+	AmountRub       decimal.Decimal `json:"amount_rub"`
+	FeeRub          decimal.Decimal `json:"fee_rub"`
+}
+
+// Итоги операций смены по валютам, типам и статусам для Z-отчёта
+func (q *Queries) GetShiftOperationTotals(ctx context.Context, shiftID sql.NullInt32) ([]GetShiftOperationTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getShiftOperationTotals, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetShiftOperationTotalsRow{}
+	for rows.Next() {
+		var i GetShiftOperationTotalsRow
+		if err := rows.Scan(
+			&i.CurrencyCode,
+			&i.OperationType,
+			&i.Status,
+			&i.OperationsCount,
+			&i.AmountCurrency,
+			&i.AmountRub,
+			&i.FeeRub,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShiftBalances = `-- name: ListShiftBalances :many
+SELECT shift_id, currency_code, opening_balance, operations_amount, cash_in_amount, cash_out_amount, expected_balance, counted_balance, discrepancy FROM shift_balances
+WHERE shift_id = $1
+ORDER BY currency_code
+`
+
+// Получить сверку смены по валютам
+func (q *Queries) ListShiftBalances(ctx context.Context, shiftID int32) ([]ShiftBalance, error) {
+	rows, err := q.db.QueryContext(ctx, listShiftBalances, shiftID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ShiftBalance{}
+	for rows.Next() {
+		var i ShiftBalance
+		if err := rows.Scan(
+			&i.ShiftID,
+			&i.CurrencyCode,
+			&i.OpeningBalance,
+			&i.OperationsAmount,
+			&i.CashInAmount,
+			&i.CashOutAmount,
+			&i.ExpectedBalance,
+			&i.CountedBalance,
+			&i.Discrepancy,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listShifts = `-- name: ListShifts :many
+SELECT id, cashier_name, status, opened_at, closed_at, close_comment, cashier_id FROM shifts
+ORDER BY opened_at DESC, id DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListShiftsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// Получить смены, новые первыми
+func (q *Queries) ListShifts(ctx context.Context, arg ListShiftsParams) ([]Shift, error) {
+	rows, err := q.db.QueryContext(ctx, listShifts, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Shift{}
+	for rows.Next() {
+		var i Shift
+		if err := rows.Scan(
+			&i.ID,
+			&i.CashierName,
+			&i.Status,
+			&i.OpenedAt,
+			&i.ClosedAt,
+			&i.CloseComment,
+			&i.CashierID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOpenShift = `-- name: LockOpenShift :one
+SELECT id, cashier_name, status, opened_at, closed_at, close_comment, cashier_id FROM shifts
+WHERE status = 'OPEN'
+FOR SHARE
+`
+
+// Получить открытую смену для проведения операции.
+// Блокировка FOR SHARE не даёт закрыть смену, пока операция не завершится.
+func (q *Queries) LockOpenShift(ctx context.Context) (Shift, error) {
+	row := q.db.QueryRowContext(ctx, lockOpenShift)
+	var i Shift
+	err := row.Scan(
+		&i.ID,
+		&i.CashierName,
+		&i.Status,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.CloseComment,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const openShift = `-- name: OpenShift :one
+INSERT INTO shifts (cashier_id, cashier_name)
+VALUES ($1, $2)
+RETURNING id, cashier_name, status, opened_at, closed_at, close_comment, cashier_id
+`
+
+type OpenShiftParams struct {
+	CashierID   sql.NullInt32 `json:"cashier_id"`
+	CashierName string        `json:"cashier_name"`
+}
+
+// Открыть смену кассира
+func (q *Queries) OpenShift(ctx context.Context, arg OpenShiftParams) (Shift, error) {
+	row := q.db.QueryRowContext(ctx, openShift, arg.CashierID, arg.CashierName)
+	var i Shift
+	err := row.Scan(
+		&i.ID,
+		&i.CashierName,
+		&i.Status,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.CloseComment,
+		&i.CashierID,
+	)
+	return i, err
+}
+
+const saveShiftBalance = `-- name: SaveShiftBalance :one
+INSERT INTO shift_balances (
+    shift_id, currency_code, opening_balance, operations_amount, cash_in_amount, cash_out_amount,
+    expected_balance, counted_balance, discrepancy
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (shift_id, currency_code) DO UPDATE
+SET
+    operations_amount = EXCLUDED.operations_amount,
+    cash_in_amount = EXCLUDED.cash_in_amount,
+    cash_out_amount = EXCLUDED.cash_out_amount,
+    expected_balance = EXCLUDED.expected_balance,
+    counted_balance = EXCLUDED.counted_balance,
+    discrepancy = EXCLUDED.discrepancy
+RETURNING shift_id, currency_code, opening_balance, operations_amount, cash_in_amount, cash_out_amount, expected_balance, counted_balance, discrepancy
+`
+
+type SaveShiftBalanceParams struct {
+	ShiftID          int32               `json:"shift_id"`
+	CurrencyCode     string              `json:"currency_code"`
+	OpeningBalance   decimal.Decimal     `json:"opening_balance"`
+	OperationsAmount decimal.Decimal     `json:"operations_amount"`
+	CashInAmount     decimal.Decimal     `json:"cash_in_amount"`
+	CashOutAmount    decimal.Decimal     `json:"cash_out_amount"`
+	ExpectedBalance  decimal.NullDecimal `json:"expected_balance"`
+	CountedBalance   decimal.NullDecimal `json:"counted_balance"`
+	Discrepancy      decimal.NullDecimal `json:"discrepancy"`
+}
+
+// Записать сверку смены по валюте
+func (q *Queries) SaveShiftBalance(ctx context.Context, arg SaveShiftBalanceParams) (ShiftBalance, error) {
+	row := q.db.QueryRowContext(ctx, saveShiftBalance,
+		arg.ShiftID,
+		arg.CurrencyCode,
+		arg.OpeningBalance,
+		arg.OperationsAmount,
+		arg.CashInAmount,
+		arg.CashOutAmount,
+		arg.ExpectedBalance,
+		arg.CountedBalance,
+		arg.Discrepancy,
+	)
+	var i ShiftBalance
+	err := row.Scan(
+		&i.ShiftID,
+		&i.CurrencyCode,
+		&i.OpeningBalance,
+		&i.OperationsAmount,
+		&i.CashInAmount,
+		&i.CashOutAmount,
+		&i.ExpectedBalance,
+		&i.CountedBalance,
+		&i.Discrepancy,
+	)
+	return i, err
+}
+
+const snapshotShiftOpeningBalances = `-- name: SnapshotShiftOpeningBalances :exec
+INSERT INTO shift_balances (shift_id, currency_code, opening_balance)
+SELECT $1, currency_code, balance
+FROM cash_balances
+`
+
+// Зафиксировать остатки кассы на открытие смены
+func (q *Queries) SnapshotShiftOpeningBalances(ctx context.Context, shiftID int32) error {
+	_, err := q.db.ExecContext(ctx, snapshotShiftOpeningBalances, shiftID)
+	return err
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sqlc-dev/pqtype"
+)
+
+// Действия, записываемые в журнал аудита (audit_events.action)
+const (
+	AuditAuthLogin           = "auth.login"
+	AuditAuthPasswordChange  = "auth.password_change"
+	AuditUserCreate          = "user.create"
+	AuditUserUpdate          = "user.update"
+	AuditUserPasswordReset   = "user.password_reset"
+	AuditClientCreate        = "client.create"
+	AuditCurrencyCreate      = "currency.create"
+	AuditCurrencyUpdate      = "currency.update"
+	AuditCurrencyRounding    = "currency.rounding_update"
+	AuditCurrencyActivate    = "currency.activate"
+	AuditCurrencyDeactivate  = "currency.deactivate"
+	AuditCurrencyDelete      = "currency.delete"
+	AuditRateScheduleCreate  = "rate_schedule.create"
+	AuditRateScheduleCancel  = "rate_schedule.cancel"
+	AuditRateScheduleApply   = "rate_schedule.apply"
+	AuditRateScheduleFail    = "rate_schedule.fail"
+	AuditReferenceRateImport = "reference_rate.import"
+	AuditOperationCreate     = "operation.create"
+	AuditOperationDraft      = "operation.draft_create"
+	AuditOperationConfirm    = "operation.confirm"
+	AuditOperationComplete   = "operation.complete"
+	AuditOperationCancel     = "operation.cancel"
+	AuditOperationReverse    = "operation.reverse"
+	AuditQuoteCreate         = "quote.create"
+	AuditLimitCreate         = "limit.create"
+	AuditLimitUpdate         = "limit.update"
+	AuditLimitDelete         = "limit.delete"
+	AuditFeeCreate           = "fee.create"
+	AuditFeeUpdate           = "fee.update"
+	AuditFeeDelete           = "fee.delete"
+	AuditShiftOpen           = "shift.open"
+	AuditShiftClose          = "shift.close"
+	AuditCashReserveUpdate   = "cash.reserve_update"
+	AuditCashIn              = "cash.in"
+	AuditCashOut             = "cash.out"
+)
+
+// Типы сущностей в журнале аудита (audit_events.entity_type)
+const (
+	AuditEntityUser           = "user"
+	AuditEntityClient         = "client"
+	AuditEntityCurrency       = "currency"
+	AuditEntityOperation      = "operation"
+	AuditEntityRateQuote      = "rate_quote"
+	AuditEntityOperationLimit = "operation_limit"
+	AuditEntityOperationFee   = "operation_fee"
+	AuditEntityShift          = "shift"
+	AuditEntityCashBalance    = "cash_balance"
+	AuditEntityReferenceRate  = "reference_rate"
+	AuditEntityRateSchedule   = "scheduled_rate_change"
+)
+
+// AuditGenesisHash — prev_hash первой записи журнала
+var AuditGenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// AuditRecord — изменение, которое нужно записать в журнал аудита
+type AuditRecord struct {
+	ActorID       sql.NullInt32
+	ActorUsername sql.NullString
+	Action        string
+	EntityType    string
+	EntityID      string
+	Before        interface{} // Состояние до изменения; nil — сущность создаётся
+	After         interface{} // Состояние после изменения; nil — сущность удаляется
+	IPAddress     string
+	RequestID     string
+}
+
+// AppendAuditEvent добавляет запись в конец цепочки журнала аудита.
+// Вызывается в транзакции Store.RunInTx. Блокировка конца цепочки держится до завершения транзакции,
+// поэтому записи в журнал выполняются по одной. Если снимок транзакции сделан до записи, которой ждала
+// блокировка, новая запись продолжит устаревшее звено: UNIQUE(prev_hash) не даст её зафиксировать,
+// и Store повторит транзакцию.
+func AppendAuditEvent(ctx context.Context, q sqlcgen.Querier, rec AuditRecord) (sqlcgen.AuditEvent, error) {
+	lock := postgresql.AuditChainLock
+	if err := q.AcquireAdvisoryXactLock(ctx, sqlcgen.AcquireAdvisoryXactLockParams{Key1: lock.Key1, Key2: lock.Key2}); err != nil {
+		return sqlcgen.AuditEvent{}, fmt.Errorf("could not lock audit chain: %w", err)
+	}
+	prevHash, err := q.GetLastAuditEventHash(ctx)
+	if err == sql.ErrNoRows {
+		prevHash = AuditGenesisHash
+	} else if err != nil {
+		return sqlcgen.AuditEvent{}, fmt.Errorf("could not load last audit event: %w", err)
+	}
+
+	event := sqlcgen.AuditEvent{
+		// PostgreSQL хранит время с точностью до микросекунды: хеш считается от сохранённого значения
+		OccurredAt:    time.Now().UTC().Truncate(time.Microsecond),
+		ActorID:       rec.ActorID,
+		ActorUsername: rec.ActorUsername,
+		Action:        rec.Action,
+		EntityType:    rec.EntityType,
+		EntityID:      rec.EntityID,
+		IpAddress:     sql.NullString{String: rec.IPAddress, Valid: rec.IPAddress != ""},
+		RequestID:     sql.NullString{String: rec.RequestID, Valid: rec.RequestID != ""},
+		PrevHash:      prevHash,
+	}
+	if event.BeforeData, err = auditJSON(rec.Before); err != nil {
+		return sqlcgen.AuditEvent{}, err
+	}
+	if event.AfterData, err = auditJSON(rec.After); err != nil {
+		return sqlcgen.AuditEvent{}, err
+	}
+	if event.Hash, err = AuditEventHash(event); err != nil {
+		return sqlcgen.AuditEvent{}, err
+	}
+
+	return q.CreateAuditEvent(ctx, sqlcgen.CreateAuditEventParams{
+		OccurredAt:    event.OccurredAt,
+		ActorID:       event.ActorID,
+		ActorUsername: event.ActorUsername,
+		Action:        event.Action,
+		EntityType:    event.EntityType,
+		EntityID:      event.EntityID,
+		BeforeData:    event.BeforeData,
+		AfterData:     event.AfterData,
+		IpAddress:     event.IpAddress,
+		RequestID:     event.RequestID,
+		PrevHash:      event.PrevHash,
+		Hash:          event.Hash,
+	})
+}
+
+// auditJSON сериализует состояние сущности в каноническом виде; nil — отсутствие состояния
+func auditJSON(v interface{}) (pqtype.NullRawMessage, error) {
+	if v == nil {
+		return pqtype.NullRawMessage{}, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return pqtype.NullRawMessage{}, fmt.Errorf("could not serialize audit data: %w", err)
+	}
+	if bytes.Equal(raw, []byte("null")) {
+		return pqtype.NullRawMessage{}, nil
+	}
+	canonical, err := canonicalJSON(raw)
+	if err != nil {
+		return pqtype.NullRawMessage{}, err
+	}
+	return pqtype.NullRawMessage{RawMessage: canonical, Valid: true}, nil
+}
+
+// canonicalJSON приводит JSON к виду, не зависящему от форматирования: JSONB хранит документ
+// с другими пробелами и порядком ключей, поэтому хеш считается от повторной сериализации
+// с упорядоченными ключами и числами в исходной записи.
+func canonicalJSON(raw []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid audit data: %w", err)
+	}
+	return json.Marshal(v)
+}
+
+// auditHashInput — поля записи журнала, от которых считается хеш, в фиксированном порядке
+type auditHashInput struct {
+	PrevHash      string          `json:"prev_hash"`
+	OccurredAt    string          `json:"occurred_at"`
+	ActorID       *int32          `json:"actor_id"`
+	ActorUsername *string         `json:"actor_username"`
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      string          `json:"entity_id"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	IPAddress     *string         `json:"ip_address"`
+	RequestID     *string         `json:"request_id"`
+}
+
+// AuditEventHash возвращает SHA-256 записи журнала вместе с хешем предыдущей записи
+func AuditEventHash(e sqlcgen.AuditEvent) (string, error) {
+	input := auditHashInput{
+		PrevHash:   e.PrevHash,
+		OccurredAt: e.OccurredAt.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+	}
+	if e.ActorID.Valid {
+		input.ActorID = &e.ActorID.Int32
+	}
+	if e.ActorUsername.Valid {
+		input.ActorUsername = &e.ActorUsername.String
+	}
+	if e.IpAddress.Valid {
+		input.IPAddress = &e.IpAddress.String
+	}
+	if e.RequestID.Valid {
+		input.RequestID = &e.RequestID.String
+	}
+	var err error
+	if e.BeforeData.Valid {
+		if input.Before, err = canonicalJSON(e.BeforeData.RawMessage); err != nil {
+			return "", err
+		}
+	}
+	if e.AfterData.Valid {
+		if input.After, err = canonicalJSON(e.AfterData.RawMessage); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Количество записей, загружаемых за один запрос при проверке цепочки
+const auditVerifyBatchSize = 500
+
+// AuditChainReport — результат проверки цепочки хешей журнала аудита
+type AuditChainReport struct {
+	Valid         bool   `json:"valid"`
+	CheckedEvents int64  `json:"checked_events"`
+	LastEventID   int64  `json:"last_event_id"`
+	HeadHash      string `json:"head_hash"`                 // Хеш последней проверенной записи
+	BrokenEventID int64  `json:"broken_event_id,omitempty"` // Первая запись, на которой цепочка нарушена
+	Problem       string `json:"problem,omitempty"`
+}
+
+// VerifyAuditChain проходит журнал от первой записи и проверяет, что каждая запись ссылается
+// на хеш предыдущей и что её собственный хеш соответствует содержимому.
+// Удаление записей с конца цепочки так не обнаружить: для этого аудитор сверяет head_hash
+// с ранее сохранённым значением.
+func VerifyAuditChain(ctx context.Context, q sqlcgen.Querier) (AuditChainReport, error) {
+	report := AuditChainReport{Valid: true, HeadHash: AuditGenesisHash}
+	for {
+		events, err := q.ListAuditEventsAfter(ctx, sqlcgen.ListAuditEventsAfterParams{
+			AfterID:   report.LastEventID,
+			BatchSize: auditVerifyBatchSize,
+		})
+		if err != nil {
+			return report, fmt.Errorf("could not load audit events: %w", err)
+		}
+		for _, e := range events {
+			if e.PrevHash != report.HeadHash {
+				return brokenChain(report, e.ID, "prev_hash does not match the hash of the previous event"), nil
+			}
+			hash, err := AuditEventHash(e)
+			if err != nil {
+				return brokenChain(report, e.ID, err.Error()), nil
+			}
+			if hash != e.Hash {
+				return brokenChain(report, e.ID, "event content does not match its hash"), nil
+			}
+			report.CheckedEvents++
+			report.LastEventID = e.ID
+			report.HeadHash = e.Hash
+		}
+		if len(events) < auditVerifyBatchSize {
+			return report, nil
+		}
+	}
+}
+
+func brokenChain(report AuditChainReport, eventID int64, problem string) AuditChainReport {
+	report.Valid = false
+	report.BrokenEventID = eventID
+	report.Problem = problem
+	return report
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/postgresql/pgtest"
+	"exchange_point/backend/internal/repository/sqlcgen"
+)
+
+// Параллельные транзакции дописывают записи в журнал по очереди: ни одна не отклоняется,
+// и цепочка хешей после них остаётся целой
+func TestAppendAuditEventConcurrent(t *testing.T) {
+	store := postgresql.NewStore(pgtest.NewDB(t))
+	ctx := context.Background()
+
+	const appends = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, appends)
+	for i := 0; i < appends; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- store.RunInTx(ctx, func(q sqlcgen.Querier) error {
+				_, err := AppendAuditEvent(ctx, q, AuditRecord{
+					ActorUsername: sql.NullString{String: "tester", Valid: true},
+					Action:        "TEST",
+					EntityType:    "test",
+					EntityID:      fmt.Sprint(i),
+					After:         map[string]int{"n": i},
+				})
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("append: %v", err)
+		}
+	}
+
+	report, err := VerifyAuditChain(ctx, store)
+	if err != nil {
+		t.Fatalf("VerifyAuditChain: %v", err)
+	}
+	if !report.Valid {
+		t.Fatalf("audit chain is broken at event %d: %s", report.BrokenEventID, report.Problem)
+	}
+	if report.CheckedEvents != appends {
+		t.Errorf("checked %d events, want %d", report.CheckedEvents, appends)
+	}
+}
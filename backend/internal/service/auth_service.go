@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Роли пользователей (users.role)
+const (
+	RoleCashier    = "CASHIER"    // Проводит операции и ведёт смену
+	RoleSupervisor = "SUPERVISOR" // Старший кассир: курсы, лимиты, комиссии, сторно
+	RoleAdmin      = "ADMIN"      // Всё, что может старший кассир, и управление пользователями
+	RoleAuditor    = "AUDITOR"    // Только чтение
+)
+
+// IsValidRole сообщает, известна ли роль
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleCashier, RoleSupervisor, RoleAdmin, RoleAuditor:
+		return true
+	}
+	return false
+}
+
+// Минимальная длина пароля
+const MinPasswordLength = 8
+
+// bcrypt учитывает только первые 72 байта пароля
+const maxPasswordLength = 72
+
+// ValidatePassword проверяет требования к новому паролю
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+	}
+	return nil
+}
+
+// HashPassword возвращает bcrypt-хеш пароля
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword сравнивает пароль с bcrypt-хешем
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// TokenClaims — содержимое JWT: идентификатор пользователя в sub, имя и роль
+type TokenClaims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// UserID возвращает идентификатор пользователя из sub
+func (c *TokenClaims) UserID() (int32, error) {
+	id, err := strconv.ParseInt(c.Subject, 10, 32)
+	return int32(id), err
+}
+
+// AuthService выпускает и проверяет JWT, подписанные HMAC-SHA256
+type AuthService struct {
+	secret   []byte
+	tokenTTL time.Duration
+}
+
+func NewAuthService(secret string, tokenTTL time.Duration) *AuthService {
+	return &AuthService{secret: []byte(secret), tokenTTL: tokenTTL}
+}
+
+// Билет на поток Server-Sent Events: EventSource не передаёт заголовки, поэтому билет идёт в адресе запроса.
+// Он годится только для подключения к потоку и живёт минуту, чтобы попавший в журналы адрес быстро устаревал.
+const (
+	streamTicketAudience = "event-stream"
+	streamTicketTTL      = time.Minute
+)
+
+// errStreamTicketAsToken — билет на поток предъявлен вместо токена доступа
+var errStreamTicketAsToken = errors.New("stream ticket cannot be used as an access token")
+
+// IssueToken выпускает токен пользователя и возвращает его вместе со сроком действия
+func (s *AuthService) IssueToken(user sqlcgen.User) (string, time.Time, error) {
+	return s.issue(user, s.tokenTTL, nil)
+}
+
+// IssueStreamTicket выпускает билет на подключение пользователя к потоку Server-Sent Events
+func (s *AuthService) IssueStreamTicket(user sqlcgen.User) (string, time.Time, error) {
+	return s.issue(user, streamTicketTTL, jwt.ClaimStrings{streamTicketAudience})
+}
+
+func (s *AuthService) issue(user sqlcgen.User, ttl time.Duration, audience jwt.ClaimStrings) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := TokenClaims{
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(int(user.ID)),
+			Audience:  audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ParseToken проверяет подпись и срок действия токена доступа; билеты на поток не принимаются
+func (s *AuthService) ParseToken(token string) (*TokenClaims, error) {
+	claims, err := s.parse(token)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims.Audience) > 0 {
+		return nil, errStreamTicketAsToken
+	}
+	return claims, nil
+}
+
+// ParseStreamTicket проверяет подпись, срок действия и назначение билета на поток
+func (s *AuthService) ParseStreamTicket(ticket string) (*TokenClaims, error) {
+	return s.parse(ticket, jwt.WithAudience(streamTicketAudience))
+}
+
+func (s *AuthService) parse(token string, opts ...jwt.ParserOption) (*TokenClaims, error) {
+	claims := new(TokenClaims)
+	opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// EnsureAdminUser создаёт администратора, если в системе ещё нет ни одного пользователя.
+// Без пароля администратор не создаётся: войти в систему будет нельзя, пока его не зададут.
+func EnsureAdminUser(ctx context.Context, q sqlcgen.Querier, username, password string) error {
+	count, err := q.CountUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("could not count users: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	if password == "" {
+		log.Println("Warning: no users exist and ADMIN_PASSWORD is not set, nobody can log in")
+		return nil
+	}
+	if err := ValidatePassword(password); err != nil {
+		return fmt.Errorf("invalid ADMIN_PASSWORD: %w", err)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, err = q.CreateUser(ctx, sqlcgen.CreateUserParams{
+		Username:     username,
+		PasswordHash: hash,
+		FullName:     "Administrator",
+		Role:         RoleAdmin,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create admin user: %w", err)
+	}
+	log.Printf("Created initial admin user '%s'", username)
+	return nil
+}
+
+// ErrInvalidCredentials — неверное имя пользователя или пароль
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// Authenticate проверяет имя и пароль активного пользователя
+func Authenticate(ctx context.Context, q sqlcgen.Querier, username, password string) (sqlcgen.User, error) {
+	user, err := q.GetUserByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, ErrInvalidCredentials
+		}
+		return user, err
+	}
+	if !user.IsActive || !CheckPassword(user.PasswordHash, password) {
+		return sqlcgen.User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
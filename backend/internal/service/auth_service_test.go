@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"exchange_point/backend/internal/repository/sqlcgen"
+)
+
+// Билет на поток не подходит как токен доступа, а токен доступа — как билет
+func TestStreamTicketIsSinglePurpose(t *testing.T) {
+	auth := NewAuthService("secret", time.Hour)
+	user := sqlcgen.User{ID: 7, Username: "cashier", Role: RoleCashier}
+
+	token, _, err := auth.IssueToken(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket, expiresAt, err := auth.IssueStreamTicket(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl := time.Until(expiresAt); ttl > streamTicketTTL {
+		t.Errorf("ticket lives %s, want at most %s", ttl, streamTicketTTL)
+	}
+
+	if _, err := auth.ParseToken(token); err != nil {
+		t.Errorf("access token rejected: %v", err)
+	}
+	claims, err := auth.ParseStreamTicket(ticket)
+	if err != nil {
+		t.Fatalf("stream ticket rejected: %v", err)
+	}
+	if id, err := claims.UserID(); err != nil || id != user.ID {
+		t.Errorf("ticket user %d (%v), want %d", id, err, user.ID)
+	}
+	if _, err := auth.ParseToken(ticket); err == nil {
+		t.Error("stream ticket accepted as access token")
+	}
+	if _, err := auth.ParseStreamTicket(token); err == nil {
+		t.Error("access token accepted as stream ticket")
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"log"
+	"time"
+)
+
+// runPeriodically запускает task каждые interval до отмены ctx.
+// task возвращает число обработанных записей, оно попадает в лог.
+func runPeriodically(ctx context.Context, interval time.Duration, name string, task func(ctx context.Context) (int64, error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				affected, err := task(ctx)
+				if err != nil {
+					log.Printf("Error in background task %s: %v", name, err)
+					continue
+				}
+				if affected > 0 {
+					log.Printf("Background task %s processed %d rows", name, affected)
+				}
+			}
+		}
+	}()
+}
+
+// StartDraftExpiry периодически переводит просроченные черновики операций в статус EXPIRED.
+// Останавливается при отмене ctx.
+func StartDraftExpiry(ctx context.Context, q sqlcgen.Querier, interval time.Duration) {
+	runPeriodically(ctx, interval, "draft expiry", q.ExpireDraftOperations)
+}
+
+// StartIdempotencyKeyCleanup периодически удаляет ключи идемпотентности старше ttl
+func StartIdempotencyKeyCleanup(ctx context.Context, q sqlcgen.Querier, interval, ttl time.Duration) {
+	runPeriodically(ctx, interval, "idempotency key cleanup", func(ctx context.Context) (int64, error) {
+		return q.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(-ttl))
+	})
+}
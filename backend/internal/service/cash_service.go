@@ -0,0 +1,68 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// Код рубля в остатках кассы: рубль не хранится в currencies
+const CashCurrencyRUB = "RUB"
+
+// Типы движений наличных (cash_movements.movement_type)
+const (
+	CashMovementOperation = "OPERATION" // Операция с клиентом
+	CashMovementReversal  = "REVERSAL"  // Сторно операции
+	CashMovementIn        = "CASH_IN"   // Подкрепление кассы
+	CashMovementOut       = "CASH_OUT"  // Инкассация или сдача в банк
+)
+
+// Причины подкрепления кассы
+var CashInReasons = map[string]string{
+	"BANK_DELIVERY":   "Delivery from bank",
+	"OPENING_BALANCE": "Opening balance",
+	"ADJUSTMENT":      "Count adjustment",
+}
+
+// Причины изъятия наличных из кассы
+var CashOutReasons = map[string]string{
+	"COLLECTION":   "Collection",
+	"BANK_DEPOSIT": "Deposit to bank",
+	"ADJUSTMENT":   "Count adjustment",
+}
+
+// CashFlow — изменение остатка наличных одной валюты: положительное — приход, отрицательное — расход
+type CashFlow struct {
+	CurrencyCode string
+	Amount       decimal.Decimal
+}
+
+// OperationCashFlows возвращает движения наличных по операции обмена.
+// Клиент, продающий валюту, сдаёт amount_currency и получает amount_rub за вычетом комиссии;
+// покупающий получает amount_currency и платит amount_rub вместе с комиссией.
+// Движения упорядочены по коду валюты, чтобы остатки блокировались в одном порядке.
+func OperationCashFlows(operationType, currencyCode string, amountCurrency, amountRub, feeRub decimal.Decimal) []CashFlow {
+	var flows []CashFlow
+	if operationType == OperationClientSells {
+		flows = []CashFlow{
+			{CurrencyCode: currencyCode, Amount: amountCurrency},
+			{CurrencyCode: CashCurrencyRUB, Amount: amountRub.Sub(feeRub).Neg()},
+		}
+	} else {
+		flows = []CashFlow{
+			{CurrencyCode: currencyCode, Amount: amountCurrency.Neg()},
+			{CurrencyCode: CashCurrencyRUB, Amount: amountRub.Add(feeRub)},
+		}
+	}
+	sort.Slice(flows, func(i, j int) bool { return flows[i].CurrencyCode < flows[j].CurrencyCode })
+	return flows
+}
+
+// ReverseCashFlows возвращает движения, компенсирующие flows: сторно возвращает кассу в исходное состояние
+func ReverseCashFlows(flows []CashFlow) []CashFlow {
+	reversed := make([]CashFlow, len(flows))
+	for i, flow := range flows {
+		reversed[i] = CashFlow{CurrencyCode: flow.CurrencyCode, Amount: flow.Amount.Neg()}
+	}
+	return reversed
+}
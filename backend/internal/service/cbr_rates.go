@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"exchange_point/backend/internal/money"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCBRDailyURL — адрес ежедневных официальных курсов ЦБ РФ
+const DefaultCBRDailyURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+
+// Наибольший размер ответа ЦБ: в XML_daily около полусотни валют, это несколько килобайт
+const maxCBRResponseSize = 1 << 20
+
+// CBRRate — официальный курс одной валюты
+type CBRRate struct {
+	Code    string          // Буквенный код ISO 4217
+	Name    string          // Название валюты
+	Nominal int32           // Количество единиц валюты, за которое указан курс
+	Value   decimal.Decimal // Курс за Nominal единиц
+	Rate    decimal.Decimal // Курс за одну единицу
+}
+
+// CBRDaily — официальные курсы ЦБ РФ на дату
+type CBRDaily struct {
+	Date  time.Time // Дата, с которой действуют курсы
+	Rates []CBRRate
+}
+
+type cbrValCurs struct {
+	Date    string `xml:"Date,attr"`
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Name     string `xml:"Name"`
+		Nominal  string `xml:"Nominal"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+// ParseCBRDaily разбирает XML в формате XML_daily.asp (кодировка windows-1251 или UTF-8).
+// Не обращается к сети: годится и для ответа ЦБ, и для сохранённого файла.
+func ParseCBRDaily(r io.Reader) (CBRDaily, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = cbrCharsetReader
+
+	var doc cbrValCurs
+	if err := decoder.Decode(&doc); err != nil {
+		return CBRDaily{}, fmt.Errorf("invalid CBR XML: %w", err)
+	}
+
+	date, err := time.Parse("02.01.2006", strings.TrimSpace(doc.Date))
+	if err != nil {
+		return CBRDaily{}, fmt.Errorf("invalid ValCurs date '%s'", doc.Date)
+	}
+	daily := CBRDaily{Date: date, Rates: make([]CBRRate, 0, len(doc.Valutes))}
+	seen := make(map[string]bool, len(doc.Valutes))
+	for _, v := range doc.Valutes {
+		code := strings.ToUpper(strings.TrimSpace(v.CharCode))
+		if len(code) != 3 {
+			return CBRDaily{}, fmt.Errorf("invalid CharCode '%s'", v.CharCode)
+		}
+		if seen[code] {
+			return CBRDaily{}, fmt.Errorf("duplicate CharCode %s", code)
+		}
+		seen[code] = true
+
+		nominal, err := strconv.ParseInt(strings.TrimSpace(v.Nominal), 10, 32)
+		if err != nil || nominal <= 0 {
+			return CBRDaily{}, fmt.Errorf("invalid Nominal '%s' for %s", v.Nominal, code)
+		}
+		// ЦБ записывает дробную часть через запятую
+		value, err := decimal.NewFromString(strings.Replace(strings.TrimSpace(v.Value), ",", ".", 1))
+		if err != nil || !value.IsPositive() {
+			return CBRDaily{}, fmt.Errorf("invalid Value '%s' for %s", v.Value, code)
+		}
+		daily.Rates = append(daily.Rates, CBRRate{
+			Code:    code,
+			Name:    strings.TrimSpace(v.Name),
+			Nominal: int32(nominal),
+			Value:   value,
+			Rate:    money.RoundRate(money.Quo(value, decimal.NewFromInt(nominal))),
+		})
+	}
+	if len(daily.Rates) == 0 {
+		return CBRDaily{}, errors.New("CBR XML contains no rates")
+	}
+	return daily, nil
+}
+
+// cbrCharsetReader перекодирует windows-1251, в которой ЦБ отдаёт XML_daily, в UTF-8
+func cbrCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8":
+		return input, nil
+	case "windows-1251", "cp1251":
+		return &windows1251Reader{src: bufio.NewReader(input)}, nil
+	}
+	return nil, fmt.Errorf("unsupported charset %s", charset)
+}
+
+// windows1251High — символы windows-1251 с кодами 0x80–0xBF; 0xC0–0xFF — буквы А–я подряд
+var windows1251High = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', utf8.RuneError, '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	'\u00A0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '\u00AD', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+type windows1251Reader struct {
+	src     *bufio.Reader
+	pending []byte // Часть UTF-8 последовательности, не поместившаяся в предыдущий Read
+}
+
+func (r *windows1251Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.pending) > 0 {
+			copied := copy(p[n:], r.pending)
+			r.pending = r.pending[copied:]
+			n += copied
+			continue
+		}
+		b, err := r.src.ReadByte()
+		if err != nil {
+			if n > 0 && err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+		var ch rune
+		switch {
+		case b < 0x80:
+			p[n] = b
+			n++
+			continue
+		case b < 0xC0:
+			ch = windows1251High[b-0x80]
+		default:
+			ch = 'А' + rune(b-0xC0)
+		}
+		var buf [utf8.UTFMax]byte
+		r.pending = append(r.pending[:0], buf[:utf8.EncodeRune(buf[:], ch)]...)
+	}
+	return n, nil
+}
+
+// CBRClient загружает официальные курсы с сайта ЦБ РФ или совместимого адреса
+type CBRClient struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewCBRClient(url string) *CBRClient {
+	return &CBRClient{url: url, httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// URL возвращает адрес, с которого загружаются курсы на дату; нулевая дата — последние установленные курсы
+func (c *CBRClient) URL(date time.Time) (string, error) {
+	u, err := url.Parse(c.url)
+	if err != nil {
+		return "", err
+	}
+	if !date.IsZero() {
+		query := u.Query()
+		query.Set("date_req", date.Format("02/01/2006"))
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+// FetchDaily загружает и разбирает курсы на дату. ЦБ возвращает последние курсы, установленные
+// не позже запрошенной даты, поэтому дата результата может быть раньше.
+func (c *CBRClient) FetchDaily(ctx context.Context, date time.Time) (CBRDaily, string, error) {
+	source, err := c.URL(date)
+	if err != nil {
+		return CBRDaily{}, "", fmt.Errorf("invalid CBR URL: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return CBRDaily{}, source, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return CBRDaily{}, source, fmt.Errorf("could not fetch CBR rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return CBRDaily{}, source, fmt.Errorf("could not fetch CBR rates: %s", resp.Status)
+	}
+	daily, err := ParseCBRDaily(io.LimitReader(resp.Body, maxCBRResponseSize))
+	return daily, source, err
+}
+
+// ImportReferenceRates сохраняет официальные курсы; повторный импорт той же даты заменяет курсы
+func ImportReferenceRates(ctx context.Context, q sqlcgen.Querier, daily CBRDaily, source string) ([]sqlcgen.ReferenceRate, error) {
+	// source хранится в reference_rates.source
+	if len(source) > 255 {
+		source = strings.ToValidUTF8(source[:255], "")
+	}
+	rates := make([]sqlcgen.ReferenceRate, 0, len(daily.Rates))
+	for _, r := range daily.Rates {
+		rate, err := q.UpsertReferenceRate(ctx, sqlcgen.UpsertReferenceRateParams{
+			CurrencyCode: r.Code,
+			RateDate:     daily.Date,
+			Nominal:      r.Nominal,
+			Value:        r.Value,
+			Rate:         r.Rate,
+			Source:       source,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not save reference rate %s: %w", r.Code, err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// StartReferenceRateImport периодически загружает последние официальные курсы ЦБ
+func StartReferenceRateImport(ctx context.Context, q sqlcgen.Querier, client *CBRClient, interval time.Duration) {
+	runPeriodically(ctx, interval, "reference rate import", func(ctx context.Context) (int64, error) {
+		daily, source, err := client.FetchDaily(ctx, time.Time{})
+		if err != nil {
+			return 0, err
+		}
+		rates, err := ImportReferenceRates(ctx, q, daily, source)
+		return int64(len(rates)), err
+	})
+}
+
+// ReferenceRatePolicy — допустимое отклонение курсов пункта обмена от официального курса ЦБ
+type ReferenceRatePolicy struct {
+	MaxDeviationPercent decimal.Decimal // Ноль — проверка отключена
+	Reject              bool            // Отклонять изменение курса; иначе только предупреждать
+}
+
+// Enabled сообщает, включена ли проверка отклонения
+func (p ReferenceRatePolicy) Enabled() bool {
+	return p.MaxDeviationPercent.IsPositive()
+}
+
+// CheckReferenceRate сверяет курсы валюты с официальным курсом ЦБ, действующим на дату on.
+// Если официальный курс не загружен или проверка отключена, отклонений нет.
+func CheckReferenceRate(ctx context.Context, q sqlcgen.Querier, policy ReferenceRatePolicy, code string, on time.Time, buyRate, sellRate decimal.Decimal) ([]ReferenceRateDeviation, error) {
+	if !policy.Enabled() {
+		return nil, nil
+	}
+	reference, err := q.GetReferenceRate(ctx, sqlcgen.GetReferenceRateParams{CurrencyCode: code, OnDate: on})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not load reference rate: %w", err)
+	}
+	return policy.Check(buyRate, sellRate, reference), nil
+}
+
+// ReferenceRateDeviation — курс пункта обмена, вышедший за коридор вокруг официального курса
+type ReferenceRateDeviation struct {
+	Side                string          `json:"side"` // BUY или SELL
+	Rate                decimal.Decimal `json:"rate"`
+	ReferenceRate       decimal.Decimal `json:"reference_rate"`
+	ReferenceDate       string          `json:"reference_date"`
+	DeviationPercent    decimal.Decimal `json:"deviation_percent"`
+	MaxDeviationPercent decimal.Decimal `json:"max_deviation_percent"`
+}
+
+// Check возвращает курсы покупки и продажи, отклоняющиеся от официального больше допустимого
+func (p ReferenceRatePolicy) Check(buyRate, sellRate decimal.Decimal, reference sqlcgen.ReferenceRate) []ReferenceRateDeviation {
+	if !p.Enabled() || !reference.Rate.IsPositive() {
+		return nil
+	}
+	var deviations []ReferenceRateDeviation
+	for _, side := range []struct {
+		name string
+		rate decimal.Decimal
+	}{{"BUY", buyRate}, {"SELL", sellRate}} {
+		deviation := money.Quo(side.rate.Sub(reference.Rate), reference.Rate).Mul(decimal.NewFromInt(100)).Abs()
+		if deviation.GreaterThan(p.MaxDeviationPercent) {
+			deviations = append(deviations, ReferenceRateDeviation{
+				Side:                side.name,
+				Rate:                side.rate,
+				ReferenceRate:       reference.Rate,
+				ReferenceDate:       reference.RateDate.Format("2006-01-02"),
+				DeviationPercent:    deviation.Round(2),
+				MaxDeviationPercent: p.MaxDeviationPercent,
+			})
+		}
+	}
+	return deviations
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"exchange_point/backend/internal/repository/sqlcgen"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseCBRDailyFixture(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "XML_daily_cp1251.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	daily, err := ParseCBRDaily(f)
+	if err != nil {
+		t.Fatalf("ParseCBRDaily: %v", err)
+	}
+	if want := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC); !daily.Date.Equal(want) {
+		t.Errorf("date %s, want %s", daily.Date, want)
+	}
+
+	tests := []struct {
+		code    string
+		name    string
+		nominal int32
+		value   string
+		rate    string
+	}{
+		{"USD", "Доллар США", 1, "81.1265", "81.1265"},
+		{"EUR", "Евро", 1, "94.5532", "94.5532"},
+		{"JPY", "Японских иен", 100, "53.4417", "0.534417"},
+		{"HUF", "Форинтов", 100, "24.1193", "0.241193"},
+		{"KZT", "Тенге", 100, "15.0127", "0.150127"},
+	}
+	if len(daily.Rates) != len(tests) {
+		t.Fatalf("got %d rates, want %d", len(daily.Rates), len(tests))
+	}
+	for i, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			got := daily.Rates[i]
+			if got.Code != tt.code || got.Name != tt.name || got.Nominal != tt.nominal {
+				t.Errorf("got %s %q nominal %d, want %s %q nominal %d", got.Code, got.Name, got.Nominal, tt.code, tt.name, tt.nominal)
+			}
+			if !got.Value.Equal(decimal.RequireFromString(tt.value)) {
+				t.Errorf("value %s, want %s", got.Value, tt.value)
+			}
+			if !got.Rate.Equal(decimal.RequireFromString(tt.rate)) {
+				t.Errorf("rate %s, want %s", got.Rate, tt.rate)
+			}
+		})
+	}
+}
+
+func TestParseCBRDailyMalformed(t *testing.T) {
+	const header = `<?xml version="1.0" encoding="UTF-8"?>`
+	tests := []struct {
+		name    string
+		fixture string // Файл в testdata; пустой — разбирается doc
+		doc     string
+		wantErr string
+	}{
+		{name: "truncated", fixture: "XML_daily_truncated.xml", wantErr: "invalid CBR XML"},
+		{name: "value with two commas", fixture: "XML_daily_bad_value.xml", wantErr: "invalid Value"},
+		{name: "zero nominal", fixture: "XML_daily_bad_nominal.xml", wantErr: "invalid Nominal"},
+		{name: "invalid date", doc: header + `<ValCurs Date="2026-10-17"><Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>81,1265</Value></Valute></ValCurs>`, wantErr: "invalid ValCurs date"},
+		{name: "invalid code", doc: header + `<ValCurs Date="17.10.2026"><Valute><CharCode>US</CharCode><Nominal>1</Nominal><Value>81,1265</Value></Valute></ValCurs>`, wantErr: "invalid CharCode"},
+		{name: "duplicate code", doc: header + `<ValCurs Date="17.10.2026"><Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>81,1265</Value></Valute><Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>81,2</Value></Valute></ValCurs>`, wantErr: "duplicate CharCode"},
+		{name: "negative value", doc: header + `<ValCurs Date="17.10.2026"><Valute><CharCode>USD</CharCode><Nominal>1</Nominal><Value>-81,1265</Value></Valute></ValCurs>`, wantErr: "invalid Value"},
+		{name: "no rates", doc: header + `<ValCurs Date="17.10.2026"></ValCurs>`, wantErr: "no rates"},
+		{name: "unsupported charset", doc: `<?xml version="1.0" encoding="KOI8-R"?><ValCurs Date="17.10.2026"></ValCurs>`, wantErr: "unsupported charset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := []byte(tt.doc)
+			if tt.fixture != "" {
+				var err error
+				if doc, err = os.ReadFile(filepath.Join("testdata", tt.fixture)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := ParseCBRDaily(strings.NewReader(string(doc)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// referenceRateQuerier возвращает один официальный курс; остальные методы Querier не вызываются
+type referenceRateQuerier struct {
+	sqlcgen.Querier
+	rate sqlcgen.ReferenceRate
+	err  error
+}
+
+func (q referenceRateQuerier) GetReferenceRate(ctx context.Context, arg sqlcgen.GetReferenceRateParams) (sqlcgen.ReferenceRate, error) {
+	return q.rate, q.err
+}
+
+func TestCheckReferenceRate(t *testing.T) {
+	reference := sqlcgen.ReferenceRate{
+		CurrencyCode: "USD",
+		RateDate:     time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+		Rate:         decimal.NewFromInt(80),
+	}
+	found := referenceRateQuerier{rate: reference}
+	warn := ReferenceRatePolicy{MaxDeviationPercent: decimal.NewFromInt(10)}
+	reject := ReferenceRatePolicy{MaxDeviationPercent: decimal.NewFromInt(10), Reject: true}
+
+	tests := []struct {
+		name      string
+		q         sqlcgen.Querier
+		policy    ReferenceRatePolicy
+		buy, sell string
+		wantSides []string
+		wantErr   bool
+	}{
+		{name: "inside corridor", q: found, policy: warn, buy: "85", sell: "75"},
+		{name: "on the boundary", q: found, policy: warn, buy: "88", sell: "72"},
+		{name: "buy above corridor", q: found, policy: warn, buy: "88.01", sell: "79", wantSides: []string{"BUY"}},
+		{name: "sell below corridor", q: found, policy: warn, buy: "81", sell: "71.99", wantSides: []string{"SELL"}},
+		{name: "both outside", q: found, policy: warn, buy: "100", sell: "60", wantSides: []string{"BUY", "SELL"}},
+		{name: "reject mode reports the same deviations", q: found, policy: reject, buy: "100", sell: "79", wantSides: []string{"BUY"}},
+		{name: "check disabled", q: found, policy: ReferenceRatePolicy{}, buy: "100", sell: "60"},
+		{name: "no reference rate", q: referenceRateQuerier{err: sql.ErrNoRows}, policy: warn, buy: "100", sell: "60"},
+		{name: "database error", q: referenceRateQuerier{err: errors.New("connection lost")}, policy: warn, buy: "80", sell: "80", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deviations, err := CheckReferenceRate(context.Background(), tt.q, tt.policy, "USD", reference.RateDate,
+				decimal.RequireFromString(tt.buy), decimal.RequireFromString(tt.sell))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, wantErr %v", err, tt.wantErr)
+			}
+			var sides []string
+			for _, d := range deviations {
+				sides = append(sides, d.Side)
+				if d.ReferenceDate != "2026-10-17" || !d.MaxDeviationPercent.Equal(tt.policy.MaxDeviationPercent) {
+					t.Errorf("deviation %+v does not describe the reference rate", d)
+				}
+			}
+			if strings.Join(sides, ",") != strings.Join(tt.wantSides, ",") {
+				t.Errorf("deviations %v, want %v", sides, tt.wantSides)
+			}
+		})
+	}
+
+	deviations, _ := CheckReferenceRate(context.Background(), found, warn, "USD", reference.RateDate, decimal.NewFromInt(100), decimal.NewFromInt(80))
+	if len(deviations) != 1 || !deviations[0].DeviationPercent.Equal(decimal.NewFromInt(25)) {
+		t.Errorf("deviations %+v, want BUY by 25%%", deviations)
+	}
+}
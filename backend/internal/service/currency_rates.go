@@ -0,0 +1,94 @@
+package service
+
+import (
+	"exchange_point/backend/internal/money"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Источники изменения курса (currency_rate_history.source)
+const (
+	RateSourceInitial   = "INITIAL"   // Курс, действовавший на момент появления истории курсов
+	RateSourceCreate    = "CREATE"    // Курс при создании валюты
+	RateSourceManual    = "MANUAL"    // Изменение курса через UpdateCurrency
+	RateSourceScheduled = "SCHEDULED" // Плановое изменение курса, применённое планировщиком
+)
+
+// OperationRate возвращает курс, по которому проводится операция данного типа:
+// клиент продаёт валюту по sell_rate, покупает — по buy_rate
+func OperationRate(operationType string, buyRate, sellRate decimal.Decimal) decimal.Decimal {
+	if operationType == OperationClientSells {
+		return sellRate
+	}
+	return buyRate
+}
+
+// Коды нарушений при проверке курсов (RateViolation.Code)
+const (
+	RateViolationNotPositive    = "RATE_NOT_POSITIVE"        // Курс не больше нуля
+	RateViolationInvertedSpread = "RATE_SPREAD_INVERTED"     // buy_rate ниже sell_rate: пункт теряет на каждой паре сделок
+	RateViolationJumpTooLarge   = "RATE_JUMP_TOO_LARGE"      // Скачок относительно прежнего курса больше допустимого
+	RateViolationReasonRequired = "OVERRIDE_REASON_REQUIRED" // Подтверждение скачка без причины
+)
+
+// Наибольшая длина причины изменения курса (currency_rate_history.reason)
+const MaxRateChangeReasonLength = 255
+
+// RateViolation — нарушение правил курса, возвращается клиенту в ответе 422
+type RateViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidateRates проверяет курсы покупки и продажи валюты сами по себе: оба больше нуля,
+// buy_rate (по нему клиент покупает валюту) не ниже sell_rate (по нему пункт её покупает)
+func ValidateRates(buyRate, sellRate decimal.Decimal) []RateViolation {
+	var violations []RateViolation
+	if !buyRate.IsPositive() {
+		violations = append(violations, RateViolation{Field: "buy_rate", Code: RateViolationNotPositive, Message: "buy_rate must be greater than zero"})
+	}
+	if !sellRate.IsPositive() {
+		violations = append(violations, RateViolation{Field: "sell_rate", Code: RateViolationNotPositive, Message: "sell_rate must be greater than zero"})
+	}
+	if len(violations) == 0 && buyRate.LessThan(sellRate) {
+		violations = append(violations, RateViolation{
+			Field:   "buy_rate",
+			Code:    RateViolationInvertedSpread,
+			Message: fmt.Sprintf("buy_rate %s is below sell_rate %s", buyRate, sellRate),
+		})
+	}
+	return violations
+}
+
+// RateChangePolicy ограничивает скачок курса относительно прежнего
+type RateChangePolicy struct {
+	MaxJumpPercent decimal.Decimal // Ноль — скачок не ограничен
+}
+
+// CheckJump возвращает курсы, изменившиеся относительно прежних больше чем на MaxJumpPercent
+func (p RateChangePolicy) CheckJump(previousBuy, previousSell, buyRate, sellRate decimal.Decimal) []RateViolation {
+	if !p.MaxJumpPercent.IsPositive() {
+		return nil
+	}
+	var violations []RateViolation
+	for _, side := range []struct {
+		field          string
+		previous, rate decimal.Decimal
+	}{{"buy_rate", previousBuy, buyRate}, {"sell_rate", previousSell, sellRate}} {
+		if !side.previous.IsPositive() {
+			continue
+		}
+		jump := money.Quo(side.rate.Sub(side.previous), side.previous).Mul(decimal.NewFromInt(100)).Abs()
+		if jump.GreaterThan(p.MaxJumpPercent) {
+			violations = append(violations, RateViolation{
+				Field: side.field,
+				Code:  RateViolationJumpTooLarge,
+				Message: fmt.Sprintf("%s changes from %s to %s (%s%%), more than %s%%; a supervisor must set override with override_reason",
+					side.field, side.previous, side.rate, jump.Round(2), p.MaxJumpPercent),
+			})
+		}
+	}
+	return violations
+}
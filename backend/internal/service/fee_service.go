@@ -0,0 +1,45 @@
+package service
+
+import (
+	"exchange_point/backend/internal/repository/sqlcgen"
+
+	"github.com/shopspring/decimal"
+)
+
+// feeScopeRank возвращает специфичность области действия правила комиссии для операции:
+// 3 — валюта и тип, 2 — валюта, 1 — тип, 0 — все операции, -1 — правило не подходит.
+func feeScopeRank(fee sqlcgen.OperationFee, currencyID int32, operationType string) int {
+	if fee.CurrencyID.Valid && fee.CurrencyID.Int32 != currencyID {
+		return -1
+	}
+	if fee.OperationType.Valid && fee.OperationType.String != operationType {
+		return -1
+	}
+	rank := 0
+	if fee.CurrencyID.Valid {
+		rank += 2
+	}
+	if fee.OperationType.Valid {
+		rank++
+	}
+	return rank
+}
+
+// ResolveOperationFee выбирает правило комиссии для операции на сумму amountRub.
+// Берётся наиболее специфичная область (валюта+тип, валюта, тип, все операции),
+// в ней — ступень с наибольшим порогом min_amount_rub, не превышающим сумму.
+// Если подходящего правила нет, комиссия не взимается.
+func ResolveOperationFee(fees []sqlcgen.OperationFee, currencyID int32, operationType string, amountRub decimal.Decimal) (sqlcgen.OperationFee, bool) {
+	var best sqlcgen.OperationFee
+	bestRank := -1
+	for _, fee := range fees {
+		rank := feeScopeRank(fee, currencyID, operationType)
+		if rank < 0 || rank < bestRank || fee.MinAmountRub.GreaterThan(amountRub) {
+			continue
+		}
+		if rank > bestRank || fee.MinAmountRub.GreaterThan(best.MinAmountRub) {
+			best, bestRank = fee, rank
+		}
+	}
+	return best, bestRank >= 0
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"fmt"
+	"strings"
+)
+
+// Базовые имена лимитов из таблицы operation_limits
+const (
+	LimitDailyCurrencyVolume   = "daily_currency_volume"
+	LimitSingleOperationAmount = "single_operation_amount"
+)
+
+// Типы операций, для которых можно задать отдельный лимит
+var limitOperationTypes = map[string]bool{
+	OperationClientSells: true,
+	OperationClientBuys:  true,
+}
+
+// Разделитель между базовым именем лимита и его областью действия.
+// Например: "daily_currency_volume:USD:CLIENT_BUYS_FROM_EXCHANGE".
+const limitScopeSeparator = ":"
+
+// LimitName строит имя лимита с областью действия.
+// Пустые currencyCode и operationType означают "для всех".
+func LimitName(base, currencyCode, operationType string) string {
+	parts := []string{base}
+	if currencyCode != "" {
+		parts = append(parts, currencyCode)
+	}
+	if operationType != "" {
+		parts = append(parts, operationType)
+	}
+	return strings.Join(parts, limitScopeSeparator)
+}
+
+// ParseLimitName разбирает имя лимита на базовое имя, код валюты и тип операции.
+// Возвращает ошибку для неизвестных лимитов и типов операций.
+func ParseLimitName(name string) (base, currencyCode, operationType string, err error) {
+	parts := strings.Split(name, limitScopeSeparator)
+	for _, p := range parts {
+		if p == "" {
+			return "", "", "", fmt.Errorf("invalid limit name '%s'", name)
+		}
+	}
+	base = parts[0]
+	if base != LimitDailyCurrencyVolume && base != LimitSingleOperationAmount {
+		return "", "", "", fmt.Errorf("unknown limit '%s'", base)
+	}
+
+	switch len(parts) {
+	case 1:
+	case 2:
+		if limitOperationTypes[parts[1]] {
+			operationType = parts[1]
+		} else {
+			currencyCode = parts[1]
+		}
+	case 3:
+		currencyCode, operationType = parts[1], parts[2]
+		if !limitOperationTypes[operationType] {
+			return "", "", "", fmt.Errorf("unknown operation type '%s'", operationType)
+		}
+	default:
+		return "", "", "", fmt.Errorf("invalid limit name '%s'", name)
+	}
+
+	if currencyCode != "" && (currencyCode != strings.ToUpper(currencyCode) || len(currencyCode) > 10) {
+		return "", "", "", fmt.Errorf("invalid currency code '%s'", currencyCode)
+	}
+	return base, currencyCode, operationType, nil
+}
+
+// ResolveOperationLimit выбирает наиболее специфичный лимит для валюты и типа операции.
+// Порядок поиска: валюта+тип, валюта, тип, общий лимит.
+func ResolveOperationLimit(limits []sqlcgen.OperationLimit, base, currencyCode, operationType string) (sqlcgen.OperationLimit, bool) {
+	byName := make(map[string]sqlcgen.OperationLimit, len(limits))
+	for _, l := range limits {
+		byName[l.LimitName] = l
+	}
+
+	candidates := []string{
+		LimitName(base, currencyCode, operationType),
+		LimitName(base, currencyCode, ""),
+		LimitName(base, "", operationType),
+		LimitName(base, "", ""),
+	}
+	for _, name := range candidates {
+		if l, ok := byName[name]; ok {
+			return l, true
+		}
+	}
+	return sqlcgen.OperationLimit{}, false
+}
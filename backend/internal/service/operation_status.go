@@ -0,0 +1,45 @@
+package service
+
+// Типы операций обмена (operations.operation_type)
+const (
+	OperationClientSells = "CLIENT_SELLS_TO_EXCHANGE"
+	OperationClientBuys  = "CLIENT_BUYS_FROM_EXCHANGE"
+	// Кросс-конвертация одной валюты в другую. В БД записывается двумя операциями
+	// (продажа исходной валюты и покупка целевой) с общим exchange_group.
+	OperationCrossExchange = "CROSS_CURRENCY_EXCHANGE"
+)
+
+// Статусы операций (operations.status)
+const (
+	OperationStatusDraft     = "DRAFT"     // Черновик: суммы и курс зафиксированы, наличные ещё не приняты
+	OperationStatusConfirmed = "CONFIRMED" // Наличные пересчитаны, клиент подписал
+	OperationStatusCompleted = "COMPLETED" // Проведённая операция
+	OperationStatusCancelled = "CANCELLED" // Отменена до завершения
+	OperationStatusExpired   = "EXPIRED"   // Черновик не подтверждён вовремя
+	OperationStatusReversed  = "REVERSED"  // Сторнированная операция, запись сохраняется для аудита
+	OperationStatusReversal  = "REVERSAL"  // Компенсирующая операция (сторно)
+)
+
+// Статусы смены кассира (shifts.status)
+const (
+	ShiftStatusOpen   = "OPEN"
+	ShiftStatusClosed = "CLOSED"
+)
+
+// Коды причин сторнирования и их описание для чека
+var ReversalReasons = map[string]string{
+	"CASHIER_ERROR":  "Cashier error",
+	"WRONG_AMOUNT":   "Wrong amount",
+	"WRONG_CURRENCY": "Wrong currency",
+	"WRONG_CLIENT":   "Wrong client",
+	"CLIENT_REQUEST": "Client request",
+	"OTHER":          "Other",
+}
+
+// OppositeOperationType возвращает тип операции обратного направления
+func OppositeOperationType(operationType string) string {
+	if operationType == OperationClientSells {
+		return OperationClientBuys
+	}
+	return OperationClientSells
+}
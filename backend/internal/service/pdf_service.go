@@ -2,11 +2,13 @@ package service
 
 import (
 	"bytes"
+	"exchange_point/backend/internal/money"
 	"exchange_point/backend/internal/repository/sqlcgen"
 	"fmt"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
+	"github.com/shopspring/decimal"
 )
 
 type PdfService struct{}
@@ -15,7 +17,8 @@ func NewPdfService() *PdfService {
 	return &PdfService{}
 }
 
-func (s *PdfService) GenerateReceiptFromOperation(operation sqlcgen.ListOperationsRow) ([]byte, error) {
+// newReceiptDocument создаёт документ чека с заголовком и шапкой таблицы
+func newReceiptDocument(title, receiptReference string) *gofpdf.Fpdf {
 	pdf := gofpdf.New("P", "mm", "A6", "")
 	// Регистрируем пользовательский шрифт, расположенный в папке fonts
 	// pdf.AddUTF8Font("TDACond", "", "backend/fonts/TDATextCondensed.ttf")
@@ -23,12 +26,12 @@ func (s *PdfService) GenerateReceiptFromOperation(operation sqlcgen.ListOperatio
 	pdf.SetFont("Arial", "", 10)
 
 	// Title
-	pdf.Cell(95, 7, "Receipt")
+	pdf.Cell(95, 7, title)
 	pdf.Ln(8)
 
 	// Subtitle with receipt number
 	pdf.SetFont("Arial", "", 8)
-	pdf.Cell(95, 6, fmt.Sprintf("Receipt No: %s", operation.ReceiptReference))
+	pdf.Cell(95, 6, fmt.Sprintf("Receipt No: %s", receiptReference))
 	pdf.Ln(7)
 
 	// Operation info
@@ -41,43 +44,77 @@ func (s *PdfService) GenerateReceiptFromOperation(operation sqlcgen.ListOperatio
 	pdf.CellFormat(65, 6, "Value", "1", 1, "L", true, 0, "")
 
 	pdf.SetFillColor(255, 255, 255)
+	return pdf
+}
 
-	// Date and time
-	pdf.CellFormat(30, 5, "Date & Time", "1", 0, "L", false, 0, "")
-	pdf.CellFormat(65, 5, operation.OperationTimestamp.Time.Format("02.01.2006, 15:04"), "1", 1, "L", false, 0, "")
+// receiptRow добавляет строку "параметр — значение" в таблицу чека
+func receiptRow(pdf *gofpdf.Fpdf, label, value string) {
+	pdf.CellFormat(30, 5, label, "1", 0, "L", false, 0, "")
+	pdf.CellFormat(65, 5, value, "1", 1, "L", false, 0, "")
+}
 
-	// Operation type
-	pdf.CellFormat(30, 5, "Operation Type", "1", 0, "L", false, 0, "")
-	operationType := "Client buys currency"
-	if operation.OperationType == "CLIENT_SELLS_TO_EXCHANGE" {
-		operationType = "Client sells currency"
+// operationTypeLabel возвращает описание типа операции для чека
+func operationTypeLabel(operationType string) string {
+	if operationType == OperationClientSells {
+		return "Client sells currency"
 	}
-	pdf.CellFormat(65, 5, operationType, "1", 1, "L", false, 0, "")
+	return "Client buys currency"
+}
 
-	// Client
-	pdf.CellFormat(30, 5, "Client", "1", 0, "L", false, 0, "")
-	pdf.CellFormat(65, 5, operation.ClientName, "1", 1, "L", false, 0, "")
+// writeOperationRows добавляет в чек общие сведения об операции
+func writeOperationRows(pdf *gofpdf.Fpdf, operation sqlcgen.ListOperationsRow) {
+	receiptRow(pdf, "Date & Time", operation.OperationTimestamp.Time.Format("02.01.2006, 15:04"))
+	receiptRow(pdf, "Operation Type", operationTypeLabel(operation.OperationType))
+	receiptRow(pdf, "Client", operation.ClientName)
+	receiptRow(pdf, "Passport", operation.ClientPassportNumber)
+	receiptRow(pdf, "Currency", operation.CurrencyCode)
+	receiptRow(pdf, "Amount (currency)", currencyAmount(operation))
+	receiptRow(pdf, "Amount (RUB)", rubAmount(operation.AmountRub))
+	receiptRow(pdf, "Exchange Rate", money.FormatRate(operation.EffectiveRate))
+	writeFeeRows(pdf, operation)
+}
 
-	// Passport
-	pdf.CellFormat(30, 5, "Passport", "1", 0, "L", false, 0, "")
-	pdf.CellFormat(65, 5, operation.ClientPassportNumber, "1", 1, "L", false, 0, "")
+// currencyAmount печатает сумму операции в валюте с числом знаков валюты
+func currencyAmount(operation sqlcgen.ListOperationsRow) string {
+	return fmt.Sprintf("%s %s", operation.AmountCurrency.StringFixed(int32(operation.CurrencyMinorUnits)), operation.CurrencyCode)
+}
 
-	// Currency
-	pdf.CellFormat(30, 5, "Currency", "1", 0, "L", false, 0, "")
-	pdf.CellFormat(65, 5, operation.CurrencyCode, "1", 1, "L", false, 0, "")
+// rubAmount печатает рублёвую сумму
+func rubAmount(amount decimal.Decimal) string {
+	return money.RUB.Format(amount) + " RUB"
+}
 
-	// Amount in currency
-	pdf.CellFormat(30, 5, "Amount (currency)", "1", 0, "L", false, 0, "")
-	pdf.CellFormat(65, 5, fmt.Sprintf("%s %s", operation.AmountCurrency, operation.CurrencyCode), "1", 1, "L", false, 0, "")
+// writeFeeRows добавляет в чек комиссию и итоговую сумму к выплате или оплате в рублях
+func writeFeeRows(pdf *gofpdf.Fpdf, operation sqlcgen.ListOperationsRow) {
+	if operation.FeeRub.IsZero() {
+		return
+	}
+	if !operation.FeeFixedRub.IsZero() {
+		receiptRow(pdf, "Fee (fixed)", rubAmount(operation.FeeFixedRub))
+	}
+	if !operation.FeePercentRub.IsZero() {
+		receiptRow(pdf, "Fee (percent)", rubAmount(operation.FeePercentRub))
+	}
+	receiptRow(pdf, "Fee total", rubAmount(operation.FeeRub))
 
-	// Amount in RUB
-	pdf.CellFormat(30, 5, "Amount (RUB)", "1", 0, "L", false, 0, "")
-	pdf.CellFormat(65, 5, fmt.Sprintf("%s RUB", operation.AmountRub), "1", 1, "L", false, 0, "")
+	// Комиссия удерживается из выплаты клиенту или доплачивается им сверх суммы обмена
+	if operation.OperationType == OperationClientSells {
+		receiptRow(pdf, "Paid to client", rubAmount(operation.AmountRub.Sub(operation.FeeRub)))
+	} else {
+		receiptRow(pdf, "Paid by client", rubAmount(operation.AmountRub.Add(operation.FeeRub)))
+	}
+}
 
-	// Exchange rate
-	pdf.CellFormat(30, 5, "Exchange Rate", "1", 0, "L", false, 0, "")
-	pdf.CellFormat(65, 5, operation.EffectiveRate, "1", 1, "L", false, 0, "")
+// stringValue — номер чека или пустая строка, если номер ещё не выдан
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
 
+// finishReceipt добавляет реквизиты, подписи и возвращает готовый PDF
+func finishReceipt(pdf *gofpdf.Fpdf) ([]byte, error) {
 	// Company info
 	pdf.Ln(5)
 	pdf.SetFont("Arial", "", 6)
@@ -107,3 +144,210 @@ func (s *PdfService) GenerateReceiptFromOperation(operation sqlcgen.ListOperatio
 
 	return buf.Bytes(), nil
 }
+
+func (s *PdfService) GenerateReceiptFromOperation(operation sqlcgen.ListOperationsRow) ([]byte, error) {
+	pdf := newReceiptDocument("Receipt", stringValue(operation.ReceiptReference))
+	writeOperationRows(pdf, operation)
+
+	// Сторнированная операция печатается с отметкой о статусе: номер чека есть только у завершённых операций
+	if operation.Status != OperationStatusCompleted {
+		receiptRow(pdf, "Status", operation.Status)
+	}
+
+	return finishReceipt(pdf)
+}
+
+// GenerateReversalReceipt формирует чек компенсирующей операции (сторно)
+func (s *PdfService) GenerateReversalReceipt(reversal sqlcgen.ListOperationsRow) ([]byte, error) {
+	pdf := newReceiptDocument("Reversal Receipt", stringValue(reversal.ReceiptReference))
+
+	receiptRow(pdf, "Reverses Receipt", stringValue(reversal.OriginalReceiptReference))
+	reason := ReversalReasons[reversal.ReversalReason.String]
+	if reason == "" {
+		reason = reversal.ReversalReason.String
+	}
+	receiptRow(pdf, "Reason", reason)
+	writeOperationRows(pdf, reversal)
+
+	return finishReceipt(pdf)
+}
+
+// crossExchangeChange — рубли, оставшиеся у клиента после обеих частей кросс-конвертации
+func crossExchangeChange(sellLeg, buyLeg sqlcgen.ListOperationsRow) decimal.Decimal {
+	return sellLeg.AmountRub.Sub(sellLeg.FeeRub).Sub(buyLeg.AmountRub).Sub(buyLeg.FeeRub)
+}
+
+// GenerateCrossExchangeReceipt формирует общий чек кросс-конвертации по двум операциям:
+// продаже исходной валюты за рубли и покупке целевой валюты на эти рубли
+func (s *PdfService) GenerateCrossExchangeReceipt(legs []sqlcgen.ListOperationsRow) ([]byte, error) {
+	var sellLeg, buyLeg *sqlcgen.ListOperationsRow
+	for i := range legs {
+		switch legs[i].OperationType {
+		case OperationClientSells:
+			sellLeg = &legs[i]
+		case OperationClientBuys:
+			buyLeg = &legs[i]
+		}
+	}
+	if sellLeg == nil || buyLeg == nil {
+		return nil, fmt.Errorf("cross-currency exchange must have a sell and a buy leg, got %d operations", len(legs))
+	}
+
+	pdf := newReceiptDocument("Currency Exchange Receipt", sellLeg.ExchangeGroup.String)
+
+	receiptRow(pdf, "Date & Time", sellLeg.OperationTimestamp.Time.Format("02.01.2006, 15:04"))
+	receiptRow(pdf, "Operation Type", fmt.Sprintf("Exchange %s to %s", sellLeg.CurrencyCode, buyLeg.CurrencyCode))
+	receiptRow(pdf, "Client", sellLeg.ClientName)
+	receiptRow(pdf, "Passport", sellLeg.ClientPassportNumber)
+
+	// Первая конвертация: исходная валюта в рубли
+	receiptRow(pdf, "Client gives", currencyAmount(*sellLeg))
+	receiptRow(pdf, sellLeg.CurrencyCode+"/RUB Rate", money.FormatRate(sellLeg.EffectiveRate))
+	receiptRow(pdf, "Amount (RUB)", rubAmount(sellLeg.AmountRub))
+	if !sellLeg.FeeRub.IsZero() {
+		receiptRow(pdf, "Fee ("+sellLeg.CurrencyCode+"/RUB)", rubAmount(sellLeg.FeeRub))
+	}
+
+	// Вторая конвертация: рубли в целевую валюту
+	if !buyLeg.FeeRub.IsZero() {
+		receiptRow(pdf, "Fee ("+buyLeg.CurrencyCode+"/RUB)", rubAmount(buyLeg.FeeRub))
+	}
+	receiptRow(pdf, buyLeg.CurrencyCode+"/RUB Rate", money.FormatRate(buyLeg.EffectiveRate))
+	receiptRow(pdf, "Converted (RUB)", rubAmount(buyLeg.AmountRub))
+	receiptRow(pdf, "Client receives", currencyAmount(*buyLeg))
+
+	// Рубли, которых не хватило на наименьшую единицу целевой валюты, выдаются клиенту сдачей
+	if change := crossExchangeChange(*sellLeg, *buyLeg); change.Sign() > 0 {
+		receiptRow(pdf, "Change (RUB)", rubAmount(change))
+	}
+
+	receiptRow(pdf, "Operations", fmt.Sprintf("%s, %s", stringValue(sellLeg.ReceiptReference), stringValue(buyLeg.ReceiptReference)))
+	if sellLeg.Status != OperationStatusCompleted {
+		receiptRow(pdf, "Status", sellLeg.Status)
+	}
+
+	return finishReceipt(pdf)
+}
+
+// reportAmount печатает сумму отчёта: два знака, если больше не нужно, иначе все четыре
+func reportAmount(amount decimal.Decimal) string {
+	if amount.Equal(amount.Truncate(2)) {
+		return amount.StringFixed(2)
+	}
+	return amount.StringFixed(4)
+}
+
+// reportTable печатает строку таблицы отчёта с колонками заданной ширины
+func reportTable(pdf *gofpdf.Fpdf, widths []float64, values []string, header bool) {
+	for i, value := range values {
+		align := "R"
+		if i == 0 || header {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 5, value, "1", 0, align, header, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// GenerateZReport формирует отчёт о закрытии смены (Z-отчёт): итоги операций
+// по валютам и сверку кассы с расхождениями между ожидаемыми и пересчитанными остатками
+func (s *PdfService) GenerateZReport(shift sqlcgen.Shift, operations []sqlcgen.GetShiftOperationTotalsRow, balances []sqlcgen.ShiftBalance) ([]byte, error) {
+	if shift.Status != ShiftStatusClosed {
+		return nil, fmt.Errorf("shift %d is %s: Z-report is available only for a closed shift", shift.ID, shift.Status)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "", 12)
+	pdf.Cell(190, 8, fmt.Sprintf("Z-Report, Shift No: %d", shift.ID))
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "", 8)
+	pdf.Cell(190, 5, fmt.Sprintf("Cashier: %s", shift.CashierName))
+	pdf.Ln(5)
+	pdf.Cell(190, 5, fmt.Sprintf("Opened: %s", shift.OpenedAt.Format("02.01.2006, 15:04")))
+	pdf.Ln(5)
+	pdf.Cell(190, 5, fmt.Sprintf("Closed: %s", shift.ClosedAt.Time.Format("02.01.2006, 15:04")))
+	pdf.Ln(5)
+	if shift.CloseComment.Valid {
+		pdf.Cell(190, 5, fmt.Sprintf("Comment: %s", shift.CloseComment.String))
+		pdf.Ln(5)
+	}
+	pdf.Ln(3)
+
+	pdf.SetDrawColor(180, 180, 180)
+	pdf.SetFillColor(240, 240, 240)
+
+	// Итоги операций: сторнированные операции показываются отдельно от проведённых
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(190, 6, "Operations")
+	pdf.Ln(7)
+	pdf.SetFont("Arial", "", 7)
+	operationWidths := []float64{20, 40, 26, 16, 30, 30, 28}
+	reportTable(pdf, operationWidths, []string{"Currency", "Operation Type", "Status", "Count", "Amount", "Amount (RUB)", "Fee (RUB)"}, true)
+	var totalCount int64
+	totalFee := decimal.Zero
+	for _, row := range operations {
+		reportTable(pdf, operationWidths, []string{
+			row.CurrencyCode,
+			operationTypeLabel(row.OperationType),
+			row.Status,
+			fmt.Sprintf("%d", row.OperationsCount),
+			reportAmount(row.AmountCurrency),
+			reportAmount(row.AmountRub),
+			reportAmount(row.FeeRub),
+		}, false)
+		totalCount += row.OperationsCount
+		if row.Status != OperationStatusReversal {
+			totalFee = totalFee.Add(row.FeeRub)
+		}
+	}
+	if len(operations) == 0 {
+		pdf.CellFormat(190, 5, "No operations", "1", 1, "L", false, 0, "")
+	}
+	pdf.Ln(2)
+	pdf.Cell(190, 5, fmt.Sprintf("Operations total: %d, fees charged: %s", totalCount, rubAmount(totalFee)))
+	pdf.Ln(8)
+
+	// Сверка кассы: расхождение — пересчитанный остаток минус ожидаемый
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(190, 6, "Cash Reconciliation")
+	pdf.Ln(7)
+	pdf.SetFont("Arial", "", 7)
+	balanceWidths := []float64{20, 24, 24, 24, 24, 24, 24, 26}
+	reportTable(pdf, balanceWidths, []string{"Currency", "Opening", "Operations", "Cash in", "Cash out", "Expected", "Counted", "Discrepancy"}, true)
+	discrepancies := 0
+	for _, balance := range balances {
+		reportTable(pdf, balanceWidths, []string{
+			balance.CurrencyCode,
+			reportAmount(balance.OpeningBalance),
+			reportAmount(balance.OperationsAmount),
+			reportAmount(balance.CashInAmount),
+			reportAmount(balance.CashOutAmount),
+			reportAmount(balance.ExpectedBalance.Decimal),
+			reportAmount(balance.CountedBalance.Decimal),
+			reportAmount(balance.Discrepancy.Decimal),
+		}, false)
+		if !balance.Discrepancy.Decimal.IsZero() {
+			discrepancies++
+		}
+	}
+	pdf.Ln(2)
+	if discrepancies == 0 {
+		pdf.Cell(190, 5, "Cash matches expected balances")
+	} else {
+		pdf.Cell(190, 5, fmt.Sprintf("Discrepancies found in %d currencies", discrepancies))
+	}
+	pdf.Ln(12)
+
+	pdf.Cell(95, 7, "Cashier: ________________")
+	pdf.Cell(95, 7, "Supervisor: ________________")
+	pdf.Ln(8)
+	pdf.Cell(190, 4, fmt.Sprintf("Printed: %s", time.Now().Format("02.01.2006, 15:04")))
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
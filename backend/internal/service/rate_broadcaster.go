@@ -0,0 +1,59 @@
+package service
+
+import (
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"sync"
+)
+
+// Сколько изменений курса может ждать отправки одному подписчику
+const rateSubscriberBuffer = 32
+
+// RateBroadcaster рассылает изменения курсов всем подписчикам.
+// Publish никогда не блокируется: подписчик, не успевающий забирать изменения, отключается
+// (его канал закрывается), клиент переподключается и получает актуальный снимок курсов.
+// Подписчики живут в памяти процесса: с несколькими экземплярами сервера каждый рассылает только свои изменения.
+type RateBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan sqlcgen.Currency]struct{}
+}
+
+func NewRateBroadcaster() *RateBroadcaster {
+	return &RateBroadcaster{subscribers: make(map[chan sqlcgen.Currency]struct{})}
+}
+
+// Subscribe возвращает канал изменений курсов и функцию отписки.
+// Канал закрывается при отписке или при переполнении буфера.
+func (b *RateBroadcaster) Subscribe() (<-chan sqlcgen.Currency, func()) {
+	ch := make(chan sqlcgen.Currency, rateSubscriberBuffer)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() { b.drop(ch) }
+}
+
+// Publish отправляет валюты с новыми курсами всем подписчикам. Вызывается после фиксации транзакции.
+func (b *RateBroadcaster) Publish(currencies ...sqlcgen.Currency) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+subscribers:
+	for ch := range b.subscribers {
+		for _, currency := range currencies {
+			select {
+			case ch <- currency:
+			default:
+				delete(b.subscribers, ch)
+				close(ch)
+				continue subscribers
+			}
+		}
+	}
+}
+
+func (b *RateBroadcaster) drop(ch chan sqlcgen.Currency) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Плейсхолдеры шаблона номера чека
+const (
+	receiptPlaceholderBranch = "{BRANCH}" // Код отделения
+	receiptPlaceholderDate   = "{DATE}"   // Бизнес-день, YYYYMMDD
+	receiptPlaceholderSeq    = "{SEQ}"    // Порядковый номер за день, дополненный нулями
+	receiptPlaceholderType   = "{TYPE}"   // Код типа операции
+	receiptPlaceholderCheck  = "{CHECK}"  // Контрольная цифра
+)
+
+// DefaultReceiptNumberTemplate — шаблон номера чека по умолчанию, например "MSK01-20261018-000042-7"
+const DefaultReceiptNumberTemplate = "{BRANCH}-{DATE}-{SEQ}-{CHECK}"
+
+// Минимальная ширина порядкового номера в чеке
+const receiptSeqWidth = 6
+
+// Сколько занятых номеров подряд пропускается, прежде чем выдача номера считается невозможной
+const maxReceiptNumberAttempts = 10
+
+// ErrReceiptNumberTaken — maxReceiptNumberAttempts номеров подряд уже записаны у других операций
+var ErrReceiptNumberTaken = errors.New("receipt number is already taken")
+
+// Коды типов операций в номере чека
+var receiptTypeCodes = map[string]string{
+	OperationClientSells:    "SEL",
+	OperationClientBuys:     "BUY",
+	OperationCrossExchange:  "EXC",
+	OperationStatusReversal: "REV",
+}
+
+// ValidateReceiptNumberTemplate проверяет шаблон номера чека.
+// Номер должен однозначно определяться отделением, днём и порядковым номером.
+func ValidateReceiptNumberTemplate(template string) error {
+	for _, p := range []string{receiptPlaceholderBranch, receiptPlaceholderDate, receiptPlaceholderSeq} {
+		if strings.Count(template, p) != 1 {
+			return fmt.Errorf("receipt number template must contain %s exactly once", p)
+		}
+	}
+	return nil
+}
+
+// ReceiptNumbering выдаёт номера чеков: сквозная нумерация без пропусков в пределах отделения и бизнес-дня
+type ReceiptNumbering struct {
+	branchCode string
+	template   string
+	location   *time.Location // Часовой пояс пункта обмена для границ бизнес-дня
+}
+
+// NewReceiptNumbering создаёт генератор номеров; шаблон должен пройти ValidateReceiptNumberTemplate
+func NewReceiptNumbering(branchCode, template string, location *time.Location) *ReceiptNumbering {
+	return &ReceiptNumbering{branchCode: branchCode, template: template, location: location}
+}
+
+// Next выдаёт следующий номер чека для операции operationType.
+// Вызывается в транзакции завершения операции: при её откате номер не расходуется.
+// Занятый номер (например, записанный вручную) пропускается, и счётчик продвигается дальше в той же транзакции:
+// иначе при каждом следующем завершении счётчик откатывался бы к тому же занятому номеру.
+func (n *ReceiptNumbering) Next(ctx context.Context, q sqlcgen.Querier, operationType string) (string, error) {
+	local := time.Now().In(n.location)
+	businessDate := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	var reference string
+	for attempt := 1; attempt <= maxReceiptNumberAttempts; attempt++ {
+		seq, err := q.NextReceiptNumber(ctx, sqlcgen.NextReceiptNumberParams{
+			BranchCode:   n.branchCode,
+			BusinessDate: businessDate,
+		})
+		if err != nil {
+			return "", fmt.Errorf("could not allocate receipt number: %w", err)
+		}
+
+		reference = n.format(businessDate, seq, operationType)
+		taken, err := q.ReceiptReferenceExists(ctx, &reference)
+		if err != nil {
+			return "", fmt.Errorf("could not check receipt number: %w", err)
+		}
+		if !taken {
+			return reference, nil
+		}
+		log.Printf("Receipt number %s is already taken, skipping it", reference)
+	}
+	return "", fmt.Errorf("%w: %d numbers in a row up to %s", ErrReceiptNumberTaken, maxReceiptNumberAttempts, reference)
+}
+
+// format подставляет значения в шаблон номера чека
+func (n *ReceiptNumbering) format(businessDate time.Time, seq int64, operationType string) string {
+	date := businessDate.Format("20060102")
+	seqText := fmt.Sprintf("%0*d", receiptSeqWidth, seq)
+	typeCode := receiptTypeCodes[operationType]
+	if typeCode == "" {
+		typeCode = operationType
+	}
+
+	reference := strings.NewReplacer(
+		receiptPlaceholderBranch, n.branchCode,
+		receiptPlaceholderDate, date,
+		receiptPlaceholderSeq, seqText,
+		receiptPlaceholderType, typeCode,
+	).Replace(n.template)
+	// Контрольная цифра считается по отделению, дате и порядковому номеру
+	return strings.ReplaceAll(reference, receiptPlaceholderCheck, luhnCheckDigit(receiptCheckInput(n.branchCode+date+seqText)))
+}
+
+// receiptCheckInput переводит значимую часть номера в цифры для контрольной цифры:
+// буква заменяется двумя цифрами (A — 10, ..., Z — 35, как в IBAN), остальные символы пропускаются
+func receiptCheckInput(value string) string {
+	var digits strings.Builder
+	for _, r := range strings.ToUpper(value) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		}
+	}
+	return digits.String()
+}
+
+// luhnCheckDigit вычисляет контрольную цифру по алгоритму Луна.
+// Ловит ошибку в одной цифре и большинство перестановок соседних цифр при ручном вводе номера.
+func luhnCheckDigit(digits string) string {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return fmt.Sprint((10 - sum%10) % 10)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/postgresql/pgtest"
+	"exchange_point/backend/internal/repository/sqlcgen"
+)
+
+func TestLuhnCheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   string
+	}{
+		{"7992739871", "3"}, // Пример из описания алгоритма
+		{"12345", "5"},
+		{"0", "0"},
+		{"", "0"},
+		{"2228200120261018000042", "7"},
+	}
+	for _, tt := range tests {
+		if got := luhnCheckDigit(tt.digits); got != tt.want {
+			t.Errorf("luhnCheckDigit(%q) = %s, want %s", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestLuhnCheckDigitDetectsSingleDigitErrors(t *testing.T) {
+	const number = "20261018000042"
+	check := luhnCheckDigit(number)
+	for i := range number {
+		for d := byte('0'); d <= '9'; d++ {
+			if d == number[i] {
+				continue
+			}
+			changed := number[:i] + string(d) + number[i+1:]
+			if luhnCheckDigit(changed) == check {
+				t.Errorf("check digit of %s matches %s", changed, number)
+			}
+		}
+	}
+}
+
+func TestReceiptCheckInput(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"20261018000042", "20261018000042"},
+		{"MSK01", "22282001"},
+		{"msk-01", "22282001"}, // Регистр не важен, разделители пропускаются
+		{"AZ", "1035"},
+	}
+	for _, tt := range tests {
+		if got := receiptCheckInput(tt.value); got != tt.want {
+			t.Errorf("receiptCheckInput(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestReceiptNumberingFormat(t *testing.T) {
+	date := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		branch        string
+		template      string
+		seq           int64
+		operationType string
+		want          string
+	}{
+		{"default template", "MSK01", DefaultReceiptNumberTemplate, 42, OperationClientSells, "MSK01-20261018-000042-7"},
+		{"branch changes check digit", "MSK02", DefaultReceiptNumberTemplate, 42, OperationClientSells, "MSK02-20261018-000042-5"},
+		{"another branch", "SPB01", DefaultReceiptNumberTemplate, 42, OperationClientBuys, "SPB01-20261018-000042-9"},
+		{"sequence wider than padding", "MSK01", DefaultReceiptNumberTemplate, 1000000, OperationClientSells, "MSK01-20261018-1000000-9"},
+		{"type code", "MSK01", "{BRANCH}/{TYPE}/{DATE}/{SEQ}", 42, OperationCrossExchange, "MSK01/EXC/20261018/000042"},
+		{"reversal type code", "MSK01", "{TYPE}-{BRANCH}-{DATE}-{SEQ}{CHECK}", 42, OperationStatusReversal, "REV-MSK01-20261018-0000427"},
+		{"unknown type is kept", "MSK01", "{BRANCH}-{DATE}-{SEQ}-{TYPE}", 1, "OTHER", "MSK01-20261018-000001-OTHER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateReceiptNumberTemplate(tt.template); err != nil {
+				t.Fatalf("template %q: %v", tt.template, err)
+			}
+			n := NewReceiptNumbering(tt.branch, tt.template, time.UTC)
+			if got := n.format(date, tt.seq, tt.operationType); got != tt.want {
+				t.Errorf("format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Занятый номер чека пропускается: выдаётся следующий, а счётчик остаётся за ним
+func TestReceiptNumberingSkipsTakenNumber(t *testing.T) {
+	db := pgtest.NewDB(t)
+	store := postgresql.NewStore(db)
+	ctx := context.Background()
+	n := NewReceiptNumbering("MSK01", DefaultReceiptNumberTemplate, time.UTC)
+	local := time.Now().UTC()
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	client, err := store.CreateClient(ctx, sqlcgen.CreateClientParams{PassportNumber: "4500 123456", FullName: "Иванов Иван"})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	usd, err := store.GetCurrencyByCode(ctx, "USD")
+	if err != nil {
+		t.Fatalf("load USD: %v", err)
+	}
+	// Номер, записанный вручную, совпадает с первым номером дня
+	taken := n.format(today, 1, OperationClientSells)
+	_, err = db.ExecContext(ctx, `INSERT INTO operations (client_id, operation_type, currency_id, amount_currency, amount_rub, effective_rate, receipt_reference)
+		VALUES ($1, $2, $3, 100, 9050, 90.5, $4)`, client.ID, OperationClientSells, usd.ID, taken)
+	if err != nil {
+		t.Fatalf("insert operation with receipt %s: %v", taken, err)
+	}
+
+	for _, seq := range []int64{2, 3} {
+		var reference string
+		err := store.RunInTx(ctx, func(q sqlcgen.Querier) error {
+			var err error
+			reference, err = n.Next(ctx, q, OperationClientSells)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if want := n.format(today, seq, OperationClientSells); reference != want {
+			t.Errorf("Next() = %s, want %s", reference, want)
+		}
+	}
+}
+
+// takenReceiptsQuerier выдаёт номера по порядку и считает занятыми все номера из taken
+type takenReceiptsQuerier struct {
+	sqlcgen.Querier
+	last  int64
+	taken func(reference string) bool
+}
+
+func (q *takenReceiptsQuerier) NextReceiptNumber(ctx context.Context, arg sqlcgen.NextReceiptNumberParams) (int64, error) {
+	q.last++
+	return q.last, nil
+}
+
+func (q *takenReceiptsQuerier) ReceiptReferenceExists(ctx context.Context, reference *string) (bool, error) {
+	return q.taken(*reference), nil
+}
+
+func TestReceiptNumberingNext(t *testing.T) {
+	n := NewReceiptNumbering("MSK01", "{BRANCH}-{DATE}-{SEQ}", time.UTC)
+	tests := []struct {
+		name    string
+		taken   int64 // Заняты номера 1..taken
+		wantSeq int64
+		wantErr bool
+	}{
+		{"free number", 0, 1, false},
+		{"one taken number is skipped", 1, 2, false},
+		{"several taken numbers are skipped", maxReceiptNumberAttempts - 1, maxReceiptNumberAttempts, false},
+		{"too many taken numbers", maxReceiptNumberAttempts, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &takenReceiptsQuerier{}
+			q.taken = func(reference string) bool { return q.last <= tt.taken }
+			reference, err := n.Next(context.Background(), q, OperationClientSells)
+			if tt.wantErr {
+				if !errors.Is(err, ErrReceiptNumberTaken) {
+					t.Fatalf("error %v, want ErrReceiptNumberTaken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Next: %v", err)
+			}
+			local := time.Now().UTC()
+			today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+			if want := n.format(today, tt.wantSeq, OperationClientSells); reference != want {
+				t.Errorf("Next() = %s, want %s", reference, want)
+			}
+		})
+	}
+}
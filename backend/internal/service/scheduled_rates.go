@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"exchange_point/backend/internal/repository/postgresql"
+	"exchange_point/backend/internal/repository/sqlcgen"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Статусы плановых изменений курса (scheduled_rate_changes.status)
+const (
+	RateScheduleStatusPending   = "PENDING"
+	RateScheduleStatusApplied   = "APPLIED"
+	RateScheduleStatusCancelled = "CANCELLED"
+	RateScheduleStatusFailed    = "FAILED"
+)
+
+// RateScheduler применяет наступившие плановые изменения курсов по тем же правилам, что и UpdateCurrency.
+// Изменения применяются и в фоне, и перед расчётом операции по валюте, поэтому операция всегда
+// проводится по курсу, действующему на её время, даже если фоновая задача ещё не сработала.
+type RateScheduler struct {
+	rateChange  RateChangePolicy
+	reference   ReferenceRatePolicy
+	location    *time.Location   // Часовой пояс пункта обмена: по нему выбирается официальный курс на дату
+	broadcaster *RateBroadcaster // Подписчики на изменения курсов
+}
+
+func NewRateScheduler(rateChange RateChangePolicy, reference ReferenceRatePolicy, location *time.Location, broadcaster *RateBroadcaster) *RateScheduler {
+	return &RateScheduler{rateChange: rateChange, reference: reference, location: location, broadcaster: broadcaster}
+}
+
+// ApplyDue применяет в транзакции q наступившие изменения курса валюты currencyID (все валюты, если не задан)
+// и возвращает валюты с применёнными курсами. Отклонённые изменения помечаются FAILED и в результат не входят.
+// Рассылать изменения подписчикам вызывающий должен сам, после фиксации транзакции.
+func (s *RateScheduler) ApplyDue(ctx context.Context, q sqlcgen.Querier, currencyID sql.NullInt32) ([]sqlcgen.Currency, error) {
+	due, err := q.ListDueScheduledRateChanges(ctx, currencyID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load scheduled rate changes: %w", err)
+	}
+	var applied []sqlcgen.Currency
+	for _, change := range due {
+		currency, ok, err := s.apply(ctx, q, change)
+		if err != nil {
+			return nil, fmt.Errorf("could not apply scheduled rate change %d: %w", change.ID, err)
+		}
+		if ok {
+			applied = append(applied, currency)
+		}
+	}
+	return applied, nil
+}
+
+// Publish рассылает подписчикам валюты с изменёнными курсами
+func (s *RateScheduler) Publish(currencies ...sqlcgen.Currency) {
+	if s.broadcaster != nil && len(currencies) > 0 {
+		s.broadcaster.Publish(currencies...)
+	}
+}
+
+// Refresh применяет наступившие изменения курсов валют отдельной транзакцией и рассылает их подписчикам
+func (s *RateScheduler) Refresh(ctx context.Context, store postgresql.Store, currencyIDs ...int32) error {
+	var applied []sqlcgen.Currency
+	err := store.RunInTx(ctx, func(q sqlcgen.Querier) error {
+		applied = nil
+		for _, id := range currencyIDs {
+			currencies, err := s.ApplyDue(ctx, q, sql.NullInt32{Int32: id, Valid: true})
+			if err != nil {
+				return err
+			}
+			applied = append(applied, currencies...)
+		}
+		return nil
+	})
+	if err == nil {
+		s.Publish(applied...)
+	}
+	return err
+}
+
+// Start периодически применяет наступившие изменения курсов всех валют
+func (s *RateScheduler) Start(ctx context.Context, store postgresql.Store, interval time.Duration) {
+	runPeriodically(ctx, interval, "scheduled rate changes", func(ctx context.Context) (int64, error) {
+		var applied []sqlcgen.Currency
+		err := store.RunInTx(ctx, func(q sqlcgen.Querier) error {
+			var err error
+			applied, err = s.ApplyDue(ctx, q, sql.NullInt32{})
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		s.Publish(applied...)
+		return int64(len(applied)), nil
+	})
+}
+
+// apply проверяет изменение курса и применяет его; изменение, не прошедшее проверку, помечается FAILED
+// (ok = false). Скачок курса сравнивается с курсом на момент применения: подтверждение override снимает это ограничение.
+func (s *RateScheduler) apply(ctx context.Context, q sqlcgen.Querier, change sqlcgen.ScheduledRateChange) (sqlcgen.Currency, bool, error) {
+	before, err := q.GetCurrency(ctx, change.CurrencyID)
+	if err != nil {
+		return sqlcgen.Currency{}, false, err
+	}
+
+	violations := ValidateRates(change.BuyRate, change.SellRate)
+	if !change.Override {
+		violations = append(violations, s.rateChange.CheckJump(before.BuyRate, before.SellRate, change.BuyRate, change.SellRate)...)
+	}
+	var problems []string
+	if before.DeletedAt.Valid {
+		problems = append(problems, "currency has been deleted")
+	}
+	for _, v := range violations {
+		problems = append(problems, v.Message)
+	}
+	deviations, err := CheckReferenceRate(ctx, q, s.reference, before.Code, change.EffectiveAt.In(s.location), change.BuyRate, change.SellRate)
+	if err != nil {
+		return sqlcgen.Currency{}, false, err
+	}
+	for _, d := range deviations {
+		if s.reference.Reject {
+			problems = append(problems, fmt.Sprintf("%s rate deviates from the CBR reference rate by %s%%", d.Side, d.DeviationPercent))
+		} else {
+			log.Printf("Scheduled rate change %d: %s %s rate deviates from the CBR reference rate by %s%%", change.ID, before.Code, d.Side, d.DeviationPercent)
+		}
+	}
+
+	if len(problems) > 0 {
+		failed, err := q.MarkScheduledRateChangeFailed(ctx, sqlcgen.MarkScheduledRateChangeFailedParams{
+			ID:            change.ID,
+			FailureReason: sql.NullString{String: strings.Join(problems, "; "), Valid: true},
+		})
+		if err != nil {
+			return sqlcgen.Currency{}, false, err
+		}
+		log.Printf("Scheduled rate change %d for %s rejected: %s", change.ID, before.Code, failed.FailureReason.String)
+		_, err = AppendAuditEvent(ctx, q, AuditRecord{
+			Action:     AuditRateScheduleFail,
+			EntityType: AuditEntityRateSchedule,
+			EntityID:   fmt.Sprint(change.ID),
+			Before:     change,
+			After:      failed,
+		})
+		return sqlcgen.Currency{}, false, err
+	}
+
+	after, err := q.UpdateCurrency(ctx, sqlcgen.UpdateCurrencyParams{
+		Code:     before.Code,
+		BuyRate:  change.BuyRate,
+		SellRate: change.SellRate,
+	})
+	if err != nil {
+		return sqlcgen.Currency{}, false, err
+	}
+	_, err = q.CreateCurrencyRateHistory(ctx, sqlcgen.CreateCurrencyRateHistoryParams{
+		CurrencyID:    after.ID,
+		BuyRate:       after.BuyRate,
+		SellRate:      after.SellRate,
+		Source:        RateSourceScheduled,
+		ChangedBy:     change.CreatedBy,
+		Reason:        change.Reason,
+		EffectiveFrom: sql.NullTime{Time: change.EffectiveAt, Valid: true},
+	})
+	if err != nil {
+		return sqlcgen.Currency{}, false, fmt.Errorf("could not record rate history: %w", err)
+	}
+	if _, err := q.MarkScheduledRateChangeApplied(ctx, change.ID); err != nil {
+		return sqlcgen.Currency{}, false, err
+	}
+	_, err = AppendAuditEvent(ctx, q, AuditRecord{
+		Action:     AuditRateScheduleApply,
+		EntityType: AuditEntityRateSchedule,
+		EntityID:   fmt.Sprint(change.ID),
+		Before:     before,
+		After:      after,
+	})
+	return after, err == nil, err
+}
@@ -0,0 +1,19 @@
+-- Таблица лимитов операций.
+-- Область действия задаётся в имени: "<лимит>[:<код валюты>][:<тип операции>]",
+-- например "daily_currency_volume:USD:CLIENT_BUYS_FROM_EXCHANGE".
+CREATE TABLE IF NOT EXISTS operation_limits (
+    id SERIAL PRIMARY KEY,
+    limit_name VARCHAR(100) NOT NULL UNIQUE,
+    limit_value DECIMAL(15,4) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE operation_limits ALTER COLUMN limit_name TYPE VARCHAR(100);
+
+-- Общие лимиты по умолчанию (в единицах валюты операции)
+INSERT INTO operation_limits (limit_name, limit_value, description) VALUES
+('single_operation_amount', 5000.0000, 'Максимальная сумма одной операции в валюте'),
+('daily_currency_volume', 10000.0000, 'Максимальный дневной объём операций клиента по одной валюте')
+ON CONFLICT (limit_name) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Индекс для подсчёта дневного объёма клиента по валюте (GetDailyClientForeignCurrencyVolume)
+CREATE INDEX IF NOT EXISTS idx_operations_client_currency_timestamp
+    ON operations(client_id, currency_id, operation_timestamp);
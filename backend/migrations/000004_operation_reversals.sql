@@ -0,0 +1,16 @@
+-- Статус операции и сторнирование.
+-- COMPLETED — проведённая операция, REVERSED — сторнированная (исходная запись не меняется),
+-- REVERSAL — компенсирующая операция, ссылающаяся на сторнированную через reversal_of_id.
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'COMPLETED';
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS reversal_of_id BIGINT REFERENCES operations(id);
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS reversal_reason VARCHAR(50);
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS reversal_comment TEXT;
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS reversed_at TIMESTAMPTZ;
+
+ALTER TABLE operations DROP CONSTRAINT IF EXISTS operations_status_check;
+ALTER TABLE operations ADD CONSTRAINT operations_status_check
+    CHECK (status IN ('COMPLETED', 'REVERSED', 'REVERSAL'));
+
+-- Операцию можно сторнировать только один раз
+CREATE UNIQUE INDEX IF NOT EXISTS idx_operations_reversal_of_id
+    ON operations(reversal_of_id) WHERE reversal_of_id IS NOT NULL;
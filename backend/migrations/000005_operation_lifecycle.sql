@@ -0,0 +1,14 @@
+-- Жизненный цикл операции: DRAFT -> CONFIRMED -> COMPLETED, либо CANCELLED / EXPIRED.
+-- В лимиты и аналитику входят только CONFIRMED и COMPLETED.
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS confirmed_at TIMESTAMPTZ;
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMPTZ;
+
+ALTER TABLE operations DROP CONSTRAINT IF EXISTS operations_status_check;
+ALTER TABLE operations ADD CONSTRAINT operations_status_check
+    CHECK (status IN ('DRAFT', 'CONFIRMED', 'COMPLETED', 'CANCELLED', 'EXPIRED', 'REVERSED', 'REVERSAL'));
+
+-- Поиск просроченных черновиков
+CREATE INDEX IF NOT EXISTS idx_operations_draft_expires_at
+    ON operations(expires_at) WHERE status = 'DRAFT';
@@ -0,0 +1,16 @@
+-- Котировки: зафиксированный курс и суммы, действующие до expires_at.
+-- Котировку можно использовать для одной операции.
+CREATE TABLE IF NOT EXISTS rate_quotes (
+    id VARCHAR(64) PRIMARY KEY,
+    client_id INTEGER REFERENCES clients(id), -- NULL: котировка не привязана к клиенту
+    operation_type VARCHAR(50) NOT NULL,
+    currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    amount VARCHAR(64) NOT NULL, -- Сумма из запроса (в валюте или в рублях, в зависимости от типа операции)
+    amount_currency DECIMAL(19, 4) NOT NULL,
+    amount_rub DECIMAL(19, 4) NOT NULL,
+    effective_rate DECIMAL(19, 8) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    operation_id BIGINT REFERENCES operations(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
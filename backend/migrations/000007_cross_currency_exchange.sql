@@ -0,0 +1,6 @@
+-- Кросс-конвертация (например, USD -> EUR) записывается двумя операциями через рубли:
+-- клиент продаёт исходную валюту и покупает целевую. Обе записи связаны общим exchange_group.
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS exchange_group VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_operations_exchange_group
+    ON operations(exchange_group) WHERE exchange_group IS NOT NULL;
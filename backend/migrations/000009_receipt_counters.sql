@@ -0,0 +1,9 @@
+-- Счётчики номеров чеков по отделению и бизнес-дню.
+-- Счётчик увеличивается в транзакции операции: при откате номер не расходуется, поэтому нумерация без пропусков.
+CREATE TABLE IF NOT EXISTS receipt_counters (
+    branch_code VARCHAR(20) NOT NULL,
+    business_date DATE NOT NULL,
+    last_number BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (branch_code, business_date)
+);
@@ -0,0 +1,30 @@
+-- Правила комиссий за операции.
+-- Правило действует для валюты и типа операции (NULL — для всех); ступени задаются порогом min_amount_rub.
+-- Комиссия = fixed_fee_rub + percent_fee% от суммы операции в рублях.
+CREATE TABLE IF NOT EXISTS operation_fees (
+    id SERIAL PRIMARY KEY,
+    currency_id INTEGER REFERENCES currencies(id),
+    operation_type VARCHAR(50),
+    min_amount_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    fixed_fee_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    percent_fee DECIMAL(7, 4) NOT NULL DEFAULT 0,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT operation_fees_amounts_check CHECK (
+        min_amount_rub >= 0 AND fixed_fee_rub >= 0 AND percent_fee >= 0 AND percent_fee <= 100
+    )
+);
+
+-- Одна ступень на порог в пределах области действия
+CREATE UNIQUE INDEX IF NOT EXISTS idx_operation_fees_scope_tier
+    ON operation_fees (COALESCE(currency_id, 0), COALESCE(operation_type, ''), min_amount_rub);
+
+-- Комиссия и доход от спреда сохраняются в операции отдельно от сумм обмена.
+-- Клиент, продающий валюту, получает amount_rub - fee_rub; покупающий платит amount_rub + fee_rub.
+ALTER TABLE operations
+    ADD COLUMN IF NOT EXISTS fee_fixed_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS fee_percent_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS fee_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS fee_rule_id INTEGER REFERENCES operation_fees(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS spread_rub DECIMAL(19, 4) NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+-- Точность и округление сумм валюты: сколько знаков после запятой и как округлять,
+-- чтобы записанная сумма совпадала с наличными, которые можно выдать.
+ALTER TABLE currencies
+    ADD COLUMN IF NOT EXISTS minor_units SMALLINT NOT NULL DEFAULT 2,
+    ADD COLUMN IF NOT EXISTS rounding_mode VARCHAR(20) NOT NULL DEFAULT 'HALF_EVEN',
+    ADD COLUMN IF NOT EXISTS cash_increment DECIMAL(19, 4);
+
+ALTER TABLE currencies DROP CONSTRAINT IF EXISTS currencies_precision_check;
+ALTER TABLE currencies ADD CONSTRAINT currencies_precision_check CHECK (
+    minor_units BETWEEN 0 AND 4
+    AND rounding_mode IN ('HALF_EVEN', 'DOWN', 'CASH')
+    AND (rounding_mode <> 'CASH' OR cash_increment > 0)
+);
@@ -0,0 +1,25 @@
+-- Остатки наличных в кассе по валютам, включая рубли.
+-- Операция, после которой остаток станет меньше резерва, отклоняется; инкассация может снять кассу до нуля.
+CREATE TABLE IF NOT EXISTS cash_balances (
+    currency_code VARCHAR(10) PRIMARY KEY, -- RUB не хранится в currencies, поэтому ключ — код валюты
+    balance DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    reserve DECIMAL(19, 4) NOT NULL DEFAULT 0, -- Неснижаемый остаток для операций с клиентами
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT cash_balances_reserve_check CHECK (reserve >= 0)
+);
+
+-- Движения наличных: операции с клиентами, сторно, подкрепление и инкассация
+CREATE TABLE IF NOT EXISTS cash_movements (
+    id BIGSERIAL PRIMARY KEY,
+    currency_code VARCHAR(10) NOT NULL REFERENCES cash_balances(currency_code),
+    movement_type VARCHAR(20) NOT NULL CHECK (movement_type IN ('OPERATION', 'REVERSAL', 'CASH_IN', 'CASH_OUT')),
+    amount DECIMAL(19, 4) NOT NULL, -- Положительная — приход в кассу, отрицательная — расход
+    balance_after DECIMAL(19, 4) NOT NULL,
+    operation_id BIGINT REFERENCES operations(id),
+    reason VARCHAR(50), -- Причина подкрепления или инкассации
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_movements_currency_created_at ON cash_movements(currency_code, created_at);
+CREATE INDEX IF NOT EXISTS idx_cash_movements_operation_id ON cash_movements(operation_id);
@@ -0,0 +1,32 @@
+-- Смены кассира. Касса одна, поэтому одновременно открыта не более одной смены.
+CREATE TABLE IF NOT EXISTS shifts (
+    id SERIAL PRIMARY KEY,
+    cashier_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'CLOSED')),
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMPTZ,
+    close_comment TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_single_open ON shifts ((TRUE)) WHERE status = 'OPEN';
+
+-- Сверка кассы по смене: остаток на открытие, движения за смену, ожидаемый и пересчитанный остаток на закрытие
+CREATE TABLE IF NOT EXISTS shift_balances (
+    shift_id INTEGER NOT NULL REFERENCES shifts(id),
+    currency_code VARCHAR(10) NOT NULL,
+    opening_balance DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    operations_amount DECIMAL(19, 4) NOT NULL DEFAULT 0, -- Операции с клиентами и их сторно
+    cash_in_amount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    cash_out_amount DECIMAL(19, 4) NOT NULL DEFAULT 0, -- Положительная сумма изъятий
+    expected_balance DECIMAL(19, 4),
+    counted_balance DECIMAL(19, 4),
+    discrepancy DECIMAL(19, 4), -- counted_balance - expected_balance: излишек положительный, недостача отрицательная
+    PRIMARY KEY (shift_id, currency_code)
+);
+
+-- Операция относится к смене, в которой проведена; движение наличных — к смене, в которой совершено
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES shifts(id);
+ALTER TABLE cash_movements ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES shifts(id);
+
+CREATE INDEX IF NOT EXISTS idx_operations_shift_id ON operations(shift_id);
+CREATE INDEX IF NOT EXISTS idx_cash_movements_shift_id ON cash_movements(shift_id);
@@ -0,0 +1,19 @@
+-- Пользователи: кассиры, старшие кассиры, администраторы и аудиторы.
+-- Пароль хранится только в виде bcrypt-хеша.
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(100) UNIQUE NOT NULL,
+    password_hash VARCHAR(100) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('CASHIER', 'SUPERVISOR', 'ADMIN', 'AUDITOR')),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_login_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Кассир, проводивший операцию, и кассир смены
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS cashier_id INTEGER REFERENCES users(id);
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS cashier_id INTEGER REFERENCES users(id);
+
+CREATE INDEX IF NOT EXISTS idx_operations_cashier_id ON operations(cashier_id);
@@ -0,0 +1,38 @@
+-- Журнал аудита: кто, когда и что изменил. Записи только добавляются.
+-- Каждая запись содержит хеш предыдущей (prev_hash) и собственный хеш (hash) —
+-- цепочка, по которой можно обнаружить изменение или удаление записей.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    actor_id INTEGER REFERENCES users(id),
+    actor_username VARCHAR(100),
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    before_data JSONB,
+    after_data JSONB,
+    ip_address VARCHAR(45),
+    request_id VARCHAR(100),
+    -- UNIQUE не даёт двум записям продолжить цепочку от одной и той же предыдущей
+    prev_hash CHAR(64) NOT NULL UNIQUE,
+    hash CHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON audit_events(occurred_at);
+
+-- Изменение и удаление записей журнала запрещены на уровне БД
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,19 @@
+-- История курсов валют: каждое изменение курса добавляет запись, действующую с effective_from
+-- до следующей записи той же валюты
+CREATE TABLE IF NOT EXISTS currency_rate_history (
+    id BIGSERIAL PRIMARY KEY,
+    currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    buy_rate DECIMAL(19, 8) NOT NULL,
+    sell_rate DECIMAL(19, 8) NOT NULL,
+    effective_from TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    source VARCHAR(20) NOT NULL, -- INITIAL, CREATE, MANUAL
+    changed_by INTEGER REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_currency_rate_history_currency_effective
+    ON currency_rate_history(currency_id, effective_from);
+
+-- Текущие курсы становятся началом истории
+INSERT INTO currency_rate_history (currency_id, buy_rate, sell_rate, effective_from, source)
+SELECT id, buy_rate, sell_rate, COALESCE(last_rate_update_at, created_at, CURRENT_TIMESTAMP), 'INITIAL'
+FROM currencies;
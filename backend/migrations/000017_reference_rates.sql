@@ -0,0 +1,13 @@
+-- Официальные курсы ЦБ РФ (XML_daily.asp): коридор, в котором должны оставаться курсы пункта обмена.
+-- rate — курс за одну единицу валюты (value / nominal)
+CREATE TABLE IF NOT EXISTS reference_rates (
+    id SERIAL PRIMARY KEY,
+    currency_code VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    nominal INTEGER NOT NULL,
+    value DECIMAL(19, 8) NOT NULL,
+    rate DECIMAL(19, 8) NOT NULL,
+    source VARCHAR(255) NOT NULL, -- URL или имя файла, из которого загружен курс
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (currency_code, rate_date)
+);
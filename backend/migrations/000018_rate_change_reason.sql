@@ -0,0 +1,2 @@
+-- Причина изменения курса: обязательна, когда старший кассир подтверждает скачок курса больше допустимого
+ALTER TABLE currency_rate_history ADD COLUMN IF NOT EXISTS reason VARCHAR(255);
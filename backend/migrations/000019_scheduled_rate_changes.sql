@@ -0,0 +1,22 @@
+-- Плановые изменения курсов: применяются планировщиком, когда наступает effective_at
+CREATE TABLE IF NOT EXISTS scheduled_rate_changes (
+    id SERIAL PRIMARY KEY,
+    currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    buy_rate DECIMAL(19, 8) NOT NULL,
+    sell_rate DECIMAL(19, 8) NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    override BOOLEAN NOT NULL DEFAULT FALSE, -- Скачок курса подтверждён старшим кассиром
+    reason VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, APPLIED, CANCELLED, FAILED
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    applied_at TIMESTAMPTZ,
+    cancelled_by INTEGER REFERENCES users(id),
+    cancelled_at TIMESTAMPTZ,
+    failure_reason TEXT -- Почему изменение не применено (статус FAILED)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_rate_changes_pending
+    ON scheduled_rate_changes(effective_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_scheduled_rate_changes_currency
+    ON scheduled_rate_changes(currency_id, effective_at);
@@ -0,0 +1,6 @@
+-- Приостановка торговли валютой: валюту нельзя удалить, пока на неё ссылаются операции,
+-- поэтому она деактивируется. Новые операции и котировки по неактивной валюте не проводятся.
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS inactive_reason VARCHAR(255); -- Причина приостановки, например техническое обслуживание
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ;
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS status_changed_by INTEGER REFERENCES users(id);
@@ -0,0 +1,7 @@
+-- Аренда ключа идемпотентности: если процесс упал до сохранения ответа, ключ после locked_until
+-- можно занять повторно. Обработчик в своей транзакции привязывает к ключу созданную сущность,
+-- поэтому повтор после сбоя узнаёт о ней и не выполняет запрос второй раз.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS claim_number INTEGER NOT NULL DEFAULT 1; -- Растёт при каждом повторном занятии ключа
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS entity_type VARCHAR(50); -- Сущность, изменённая запросом (как в audit_events)
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS entity_id VARCHAR(255);
@@ -0,0 +1,3 @@
+-- Номер чека выдаётся при завершении операции: у черновика и подтверждённой операции его ещё нет,
+-- и отменённый черновик не оставляет пропуска в нумерации
+ALTER TABLE operations ALTER COLUMN receipt_reference DROP NOT NULL;
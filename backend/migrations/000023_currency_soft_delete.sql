@@ -0,0 +1,4 @@
+-- Удаление валюты: строка остаётся, потому что на неё ссылаются операции и история курсов.
+-- Удалённая валюта не торгуется, скрыта из списков валют и не может быть снова активирована.
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE currencies ADD COLUMN IF NOT EXISTS deleted_by INTEGER REFERENCES users(id);
@@ -0,0 +1,8 @@
+-- Ключ идемпотентности уникален в пределах пользователя: одинаковые ключи разных пользователей
+-- не мешают друг другу и не дают получить чужой сохранённый ответ.
+-- У прежних ключей пользователь не записан; они живут недолго и удаляются.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+DELETE FROM idempotency_keys WHERE user_id IS NULL;
+ALTER TABLE idempotency_keys ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (user_id, idempotency_key);
@@ -0,0 +1,33 @@
+-- name: GetLastAuditEventHash :one
+-- Хеш последней записи журнала — начало следующего звена цепочки
+SELECT hash FROM audit_events
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+    occurred_at, actor_id, actor_username, action, entity_type, entity_id,
+    before_data, after_data, ip_address, request_id, prev_hash, hash
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+)
+RETURNING *;
+
+-- name: ListAuditEvents :many
+-- Получить записи журнала, новые первыми; пустые строковые фильтры и NULL не ограничивают выборку
+SELECT * FROM audit_events
+WHERE (sqlc.narg(actor_id)::int IS NULL OR actor_id = sqlc.narg(actor_id)::int)
+  AND (sqlc.arg(action)::text = '' OR action = sqlc.arg(action)::text)
+  AND (sqlc.arg(entity_type)::text = '' OR entity_type = sqlc.arg(entity_type)::text)
+  AND (sqlc.arg(entity_id)::text = '' OR entity_id = sqlc.arg(entity_id)::text)
+  AND (sqlc.narg(occurred_from)::timestamptz IS NULL OR occurred_at >= sqlc.narg(occurred_from)::timestamptz)
+  AND (sqlc.narg(occurred_to)::timestamptz IS NULL OR occurred_at < sqlc.narg(occurred_to)::timestamptz)
+ORDER BY id DESC
+LIMIT $1 OFFSET $2;
+
+-- name: ListAuditEventsAfter :many
+-- Получить записи журнала по порядку, начиная после after_id: для проверки цепочки хешей
+SELECT * FROM audit_events
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
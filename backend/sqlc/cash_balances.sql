@@ -0,0 +1,46 @@
+-- name: ListCashBalances :many
+-- Получить остатки наличных по всем валютам
+SELECT * FROM cash_balances
+ORDER BY currency_code;
+
+-- name: GetCashBalance :one
+-- Получить остаток наличных по валюте
+SELECT * FROM cash_balances
+WHERE currency_code = $1;
+
+-- name: ChangeCashBalance :one
+-- Изменить остаток наличных на amount (со знаком).
+-- Строка остатка блокируется до конца транзакции, поэтому изменения по валюте выполняются последовательно.
+INSERT INTO cash_balances (currency_code, balance)
+VALUES (sqlc.arg(currency_code), sqlc.arg(amount))
+ON CONFLICT (currency_code) DO UPDATE
+SET
+    balance = cash_balances.balance + EXCLUDED.balance,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: SetCashReserve :one
+-- Установить неснижаемый остаток по валюте
+INSERT INTO cash_balances (currency_code, reserve)
+VALUES ($1, $2)
+ON CONFLICT (currency_code) DO UPDATE
+SET
+    reserve = EXCLUDED.reserve,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: CreateCashMovement :one
+-- Записать движение наличных
+INSERT INTO cash_movements (
+    currency_code, movement_type, amount, balance_after, operation_id, reason, comment, shift_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: ListCashMovements :many
+-- Получить движения наличных, новые первыми; пустой код валюты — по всем валютам
+SELECT * FROM cash_movements
+WHERE sqlc.arg(currency_code)::text = '' OR currency_code = sqlc.arg(currency_code)::text
+ORDER BY created_at DESC, id DESC
+LIMIT $1 OFFSET $2;
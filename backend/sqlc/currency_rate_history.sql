@@ -0,0 +1,28 @@
+-- name: CreateCurrencyRateHistory :one
+-- Записать новый курс валюты; effective_from по умолчанию — время транзакции, как и currencies.last_rate_update_at,
+-- для планового изменения — время, на которое оно было назначено
+INSERT INTO currency_rate_history (
+    currency_id, buy_rate, sell_rate, source, changed_by, reason, effective_from
+) VALUES (
+    sqlc.arg(currency_id), sqlc.arg(buy_rate), sqlc.arg(sell_rate), sqlc.arg(source), sqlc.arg(changed_by), sqlc.arg(reason),
+    COALESCE(sqlc.narg(effective_from)::timestamptz, CURRENT_TIMESTAMP)
+)
+RETURNING *;
+
+-- name: ListCurrencyRateHistory :many
+-- Получить курсы валюты, действовавшие в периоде [from_time, to_time): включая курс, действовавший на его начало
+SELECT r.* FROM currency_rate_history r
+WHERE r.currency_id = sqlc.arg(currency_id)::int
+  AND r.effective_from < sqlc.arg(to_time)::timestamptz
+  AND r.effective_from >= COALESCE((
+      SELECT MAX(h.effective_from) FROM currency_rate_history h
+      WHERE h.currency_id = sqlc.arg(currency_id)::int AND h.effective_from <= sqlc.arg(from_time)::timestamptz
+  ), sqlc.arg(from_time)::timestamptz)
+ORDER BY r.effective_from, r.id;
+
+-- name: GetCurrencyRateAt :one
+-- Получить курс валюты, действовавший в момент at
+SELECT * FROM currency_rate_history
+WHERE currency_id = sqlc.arg(currency_id) AND effective_from <= sqlc.arg(at)::timestamptz
+ORDER BY effective_from DESC, id DESC
+LIMIT 1;
@@ -0,0 +1,64 @@
+-- name: CreateIdempotencyKey :one
+-- Занять ключ идемпотентности пользователя на lease_seconds секунд; если ключ уже есть, строка не возвращается
+INSERT INTO idempotency_keys (user_id, idempotency_key, request_method, request_path, request_hash, locked_until)
+VALUES (
+    sqlc.arg(user_id), sqlc.arg(idempotency_key), sqlc.arg(request_method), sqlc.arg(request_path), sqlc.arg(request_hash),
+    NOW() + sqlc.arg(lease_seconds)::int * INTERVAL '1 second'
+)
+ON CONFLICT (user_id, idempotency_key) DO NOTHING
+RETURNING *;
+
+-- name: ReclaimIdempotencyKey :one
+-- Повторно занять ключ, аренда которого истекла без сохранённого ответа и без изменённой сущности
+UPDATE idempotency_keys
+SET
+    locked_until = NOW() + sqlc.arg(lease_seconds)::int * INTERVAL '1 second',
+    claim_number = claim_number + 1
+WHERE user_id = sqlc.arg(user_id)
+  AND idempotency_key = sqlc.arg(idempotency_key)
+  AND request_hash = sqlc.arg(request_hash)
+  AND response_status IS NULL
+  AND entity_id IS NULL
+  AND locked_until < NOW()
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+-- Получить ключ идемпотентности пользователя и сохранённый ответ
+SELECT * FROM idempotency_keys
+WHERE user_id = sqlc.arg(user_id)
+  AND idempotency_key = sqlc.arg(idempotency_key);
+
+-- name: BindIdempotencyKey :execrows
+-- Привязать к ключу сущность, изменённую запросом. Выполняется в транзакции обработчика;
+-- 0 строк — ключ уже занят повторным запросом, и транзакция должна откатиться
+UPDATE idempotency_keys
+SET
+    entity_type = COALESCE(entity_type, sqlc.arg(entity_type)),
+    entity_id = COALESCE(entity_id, sqlc.arg(entity_id))
+WHERE user_id = sqlc.arg(user_id)
+  AND idempotency_key = sqlc.arg(idempotency_key)
+  AND claim_number = sqlc.arg(claim_number);
+
+-- name: CompleteIdempotencyKey :exec
+-- Сохранить ответ на запрос с ключом идемпотентности
+UPDATE idempotency_keys
+SET
+    response_status = sqlc.arg(response_status),
+    response_content_type = sqlc.arg(response_content_type),
+    response_body = sqlc.arg(response_body),
+    completed_at = NOW()
+WHERE user_id = sqlc.arg(user_id)
+  AND idempotency_key = sqlc.arg(idempotency_key)
+  AND claim_number = sqlc.arg(claim_number);
+
+-- name: DeleteIdempotencyKey :exec
+-- Освободить ключ, чтобы запрос можно было повторить
+DELETE FROM idempotency_keys
+WHERE user_id = sqlc.arg(user_id)
+  AND idempotency_key = sqlc.arg(idempotency_key)
+  AND claim_number = sqlc.arg(claim_number);
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+-- Удалить ключи старше заданного момента
+DELETE FROM idempotency_keys
+WHERE created_at < $1;
@@ -0,0 +1,34 @@
+-- name: ListOperationFees :many
+-- Получить все правила комиссий
+SELECT * FROM operation_fees
+ORDER BY currency_id NULLS FIRST, operation_type NULLS FIRST, min_amount_rub;
+
+-- name: GetOperationFee :one
+-- Получить правило комиссии по идентификатору
+SELECT * FROM operation_fees
+WHERE id = $1;
+
+-- name: CreateOperationFee :one
+-- Создать правило комиссии
+INSERT INTO operation_fees (
+    currency_id, operation_type, min_amount_rub, fixed_fee_rub, percent_fee, description
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: UpdateOperationFee :one
+-- Изменить размер комиссии; область действия и порог ступени не меняются
+UPDATE operation_fees
+SET
+    fixed_fee_rub = sqlc.arg(fixed_fee_rub),
+    percent_fee = sqlc.arg(percent_fee),
+    description = COALESCE(sqlc.narg(description), description),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteOperationFee :execrows
+-- Удалить правило комиссии
+DELETE FROM operation_fees
+WHERE id = $1;
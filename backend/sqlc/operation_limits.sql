@@ -0,0 +1,30 @@
+-- name: CreateOperationLimit :one
+-- Создать новое ограничение операции
+INSERT INTO operation_limits (limit_name, limit_value, description)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DeleteOperationLimit :execrows
+-- Удалить ограничение операции
+DELETE FROM operation_limits
+WHERE limit_name = $1;
+
+-- name: GetOperationLimit :one
+-- Получить ограничение операции по имени
+SELECT * FROM operation_limits
+WHERE limit_name = $1;
+
+-- name: ListOperationLimits :many
+-- Получить список всех ограничений операций
+SELECT * FROM operation_limits
+ORDER BY limit_name;
+
+-- name: UpdateOperationLimit :one
+-- Обновить значение ограничения операции
+UPDATE operation_limits
+SET
+    limit_value = sqlc.arg(limit_value),
+    description = COALESCE(sqlc.narg(description), description),
+    updated_at = NOW()
+WHERE limit_name = sqlc.arg(limit_name)
+RETURNING *;
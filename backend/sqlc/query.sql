@@ -27,14 +27,46 @@ SELECT * FROM currencies
 WHERE code = $1 LIMIT 1;
 
 -- name: ListCurrencies :many
+-- Валюты, кроме удалённых
 SELECT * FROM currencies
+WHERE deleted_at IS NULL
 ORDER BY code;
 
+-- name: ListCurrenciesByStatus :many
+-- Валюты с признаком is_active и признаком удаления deleted; незаданный признак не проверяется
+SELECT * FROM currencies
+WHERE (sqlc.narg(is_active)::boolean IS NULL OR is_active = sqlc.narg(is_active)::boolean)
+  AND (sqlc.narg(deleted)::boolean IS NULL OR (deleted_at IS NOT NULL) = sqlc.narg(deleted)::boolean)
+ORDER BY code;
+
+-- name: SetCurrencyActive :one
+-- Возобновить или приостановить торговлю валютой
+UPDATE currencies
+SET
+    is_active = sqlc.arg(is_active),
+    inactive_reason = sqlc.narg(inactive_reason),
+    status_changed_at = CURRENT_TIMESTAMP,
+    status_changed_by = sqlc.narg(changed_by)
+WHERE code = sqlc.arg(code)
+RETURNING *;
+
+-- name: DeleteCurrency :one
+-- Удалить валюту: торговля прекращается, строка остаётся для операций и истории курсов
+UPDATE currencies
+SET
+    is_active = FALSE,
+    deleted_at = CURRENT_TIMESTAMP,
+    deleted_by = sqlc.narg(deleted_by),
+    status_changed_at = CURRENT_TIMESTAMP,
+    status_changed_by = sqlc.narg(deleted_by)
+WHERE code = sqlc.arg(code) AND deleted_at IS NULL
+RETURNING *;
+
 -- name: CreateCurrency :one
 INSERT INTO currencies (
-    code, name, buy_rate, sell_rate
+    code, name, buy_rate, sell_rate, minor_units, rounding_mode, cash_increment
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6, $7
 )
 RETURNING *;
 
@@ -47,16 +79,73 @@ SET
 WHERE code = $1
 RETURNING *;
 
+-- name: UpdateCurrencyRounding :one
+-- Изменить точность и способ округления сумм валюты
+UPDATE currencies
+SET
+    minor_units = $2,
+    rounding_mode = $3,
+    cash_increment = $4
+WHERE code = $1
+RETURNING *;
+
 -- name: CreateOperation :one
 INSERT INTO operations (
   client_id, operation_type, currency_id, amount_currency,
-  amount_rub, effective_rate, receipt_reference
+  amount_rub, effective_rate, receipt_reference,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+RETURNING *;
+
+-- name: CreateDraftOperation :one
+-- Черновик фиксирует рассчитанные суммы и курс до подтверждения кассиром; номер чека выдаётся при завершении
+INSERT INTO operations (
+  client_id, operation_type, currency_id, amount_currency,
+  amount_rub, effective_rate, status, expires_at,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, 'DRAFT', $7, $8, $9, $10, $11, $12, $13, $14
 )
 RETURNING *;
 
+-- name: ConfirmOperation :one
+-- Время операции — момент подтверждения: по нему считается дневной лимит
+UPDATE operations
+SET
+    status = 'CONFIRMED',
+    confirmed_at = NOW(),
+    operation_timestamp = NOW()
+WHERE id = $1 AND status = 'DRAFT'
+RETURNING *;
+
+-- name: CompleteOperation :one
+-- Операция из черновика относится к смене, в которой завершена: тогда же проходят наличные и выдаётся номер чека
+UPDATE operations
+SET
+    status = 'COMPLETED',
+    completed_at = NOW(),
+    shift_id = sqlc.arg(shift_id),
+    receipt_reference = sqlc.arg(receipt_reference)
+WHERE id = sqlc.arg(id) AND status = 'CONFIRMED'
+RETURNING *;
+
+-- name: CancelOperation :one
+UPDATE operations
+SET
+    status = 'CANCELLED',
+    cancelled_at = NOW()
+WHERE id = $1 AND status IN ('DRAFT', 'CONFIRMED')
+RETURNING *;
+
+-- name: ExpireDraftOperations :execrows
+UPDATE operations
+SET status = 'EXPIRED'
+WHERE status = 'DRAFT' AND expires_at < NOW();
+
 -- name: GetOperationsForAnalytics :many
+-- posted_buy_rate и posted_sell_rate — курсы, объявленные на момент операции; NULL, если операция старше истории курсов
 SELECT 
     o.id,
     o.client_id,
@@ -69,16 +158,30 @@ SELECT
     o.amount_rub,
     o.effective_rate,
     o.operation_timestamp,
-    o.receipt_reference
+    o.receipt_reference,
+    o.fee_rub,
+    o.spread_rub,
+    posted.buy_rate AS posted_buy_rate,
+    posted.sell_rate AS posted_sell_rate
 FROM 
     operations o
 JOIN 
     clients c ON o.client_id = c.id
 JOIN                 
     currencies cur ON o.currency_id = cur.id
+LEFT JOIN currency_rate_history posted ON posted.id = (
+    SELECT h.id
+    FROM currency_rate_history h
+    WHERE h.currency_id = o.currency_id AND h.effective_from <= o.operation_timestamp
+    ORDER BY h.effective_from DESC, h.id DESC
+    LIMIT 1
+)
 WHERE 
     o.operation_timestamp >= sqlc.arg(start_date)::timestamptz 
     AND o.operation_timestamp <= sqlc.arg(end_date)::timestamptz
+    -- В доход входят только завершённые операции: подтверждённую ещё можно отменить.
+    -- Черновики, отменённые, сторнированные операции и сторно в итоги не входят
+    AND o.status = 'COMPLETED'
 ORDER BY 
     o.operation_timestamp ASC;
 
@@ -90,17 +193,131 @@ SELECT
     c.passport_number AS client_passport_number,
     o.operation_type,
     cur.code AS currency_code,
+    cur.minor_units AS currency_minor_units,
     o.amount_currency,
     o.amount_rub,
     o.effective_rate,
     o.operation_timestamp,
-    o.receipt_reference
+    o.receipt_reference,
+    o.status,
+    o.reversal_of_id,
+    o.reversal_reason,
+    orig.receipt_reference AS original_receipt_reference,
+    o.exchange_group,
+    o.fee_fixed_rub,
+    o.fee_percent_rub,
+    o.fee_rub
 FROM operations o
 JOIN clients c ON o.client_id = c.id
 JOIN currencies cur ON o.currency_id = cur.id
+LEFT JOIN operations orig ON o.reversal_of_id = orig.id
 ORDER BY o.operation_timestamp DESC
 LIMIT $1 OFFSET $2;
 
+-- name: GetOperationByReceiptReference :one
+SELECT
+    o.id,
+    o.client_id,
+    c.full_name AS client_name,
+    c.passport_number AS client_passport_number,
+    o.operation_type,
+    cur.code AS currency_code,
+    cur.minor_units AS currency_minor_units,
+    o.amount_currency,
+    o.amount_rub,
+    o.effective_rate,
+    o.operation_timestamp,
+    o.receipt_reference,
+    o.status,
+    o.reversal_of_id,
+    o.reversal_reason,
+    orig.receipt_reference AS original_receipt_reference,
+    o.exchange_group,
+    o.fee_fixed_rub,
+    o.fee_percent_rub,
+    o.fee_rub
+FROM operations o
+JOIN clients c ON o.client_id = c.id
+JOIN currencies cur ON o.currency_id = cur.id
+LEFT JOIN operations orig ON o.reversal_of_id = orig.id
+WHERE o.receipt_reference = $1
+LIMIT 1;
+
+-- name: ListOperationsByExchangeGroup :many
+SELECT
+    o.id,
+    o.client_id,
+    c.full_name AS client_name,
+    c.passport_number AS client_passport_number,
+    o.operation_type,
+    cur.code AS currency_code,
+    cur.minor_units AS currency_minor_units,
+    o.amount_currency,
+    o.amount_rub,
+    o.effective_rate,
+    o.operation_timestamp,
+    o.receipt_reference,
+    o.status,
+    o.reversal_of_id,
+    o.reversal_reason,
+    orig.receipt_reference AS original_receipt_reference,
+    o.exchange_group,
+    o.fee_fixed_rub,
+    o.fee_percent_rub,
+    o.fee_rub
+FROM operations o
+JOIN clients c ON o.client_id = c.id
+JOIN currencies cur ON o.currency_id = cur.id
+LEFT JOIN operations orig ON o.reversal_of_id = orig.id
+WHERE o.exchange_group = $1
+ORDER BY o.id;
+
+-- name: ListExchangeGroupOperationsForUpdate :many
+SELECT * FROM operations
+WHERE exchange_group = $1
+ORDER BY id
+FOR UPDATE;
+
+-- name: CreateCrossExchangeLeg :one
+-- Одна из двух операций кросс-конвертации
+INSERT INTO operations (
+  client_id, operation_type, currency_id, amount_currency,
+  amount_rub, effective_rate, receipt_reference, exchange_group,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+)
+RETURNING *;
+
+-- name: GetOperation :one
+SELECT * FROM operations
+WHERE id = $1;
+
+-- name: GetOperationForUpdate :one
+SELECT * FROM operations
+WHERE id = $1
+FOR UPDATE;
+
+-- name: MarkOperationReversed :one
+UPDATE operations
+SET
+    status = 'REVERSED',
+    reversed_at = NOW()
+WHERE id = $1 AND status = 'COMPLETED'
+RETURNING *;
+
+-- name: CreateReversalOperation :one
+-- Компенсирующая операция: обратное направление с теми же суммами, курсом и комиссией
+INSERT INTO operations (
+  client_id, operation_type, currency_id, amount_currency,
+  amount_rub, effective_rate, receipt_reference,
+  status, reversal_of_id, reversal_reason, reversal_comment,
+  fee_fixed_rub, fee_percent_rub, fee_rub, fee_rule_id, spread_rub, shift_id, cashier_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, 'REVERSAL', $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+)
+RETURNING *;
+
 -- name: ListOperationsByClientAndDateRange :many
 SELECT * FROM operations
 WHERE client_id = $1
@@ -109,12 +326,24 @@ AND operation_timestamp <= $3 -- date_to
 ORDER BY operation_timestamp DESC;
 
 -- name: GetDailyClientForeignCurrencyVolume :one
-SELECT COALESCE(SUM(CASE
-                WHEN o.operation_type = 'CLIENT_SELLS_TO_EXCHANGE' THEN o.amount_currency
-                WHEN o.operation_type = 'CLIENT_BUYS_FROM_EXCHANGE' THEN o.amount_currency
-                ELSE 0
-              END), 0.00)::DECIMAL(19,4) AS total_volume -- Приведение типа для sqlc
+-- Объём операций клиента по валюте за бизнес-день [day_start, day_end)
+SELECT COALESCE(SUM(o.amount_currency), 0.00)::DECIMAL(19,4) AS total_volume -- Приведение типа для sqlc
 FROM operations o
 WHERE o.client_id = sqlc.arg(client_id)
-  AND DATE(o.operation_timestamp) = DATE(sqlc.arg(operation_date)::timestamptz)
-  AND o.currency_id = sqlc.arg(foreign_currency_id);
\ No newline at end of file
+  AND o.currency_id = sqlc.arg(foreign_currency_id)
+  AND o.operation_timestamp >= sqlc.arg(day_start)::timestamptz
+  AND o.operation_timestamp < sqlc.arg(day_end)::timestamptz
+  AND o.status IN ('CONFIRMED', 'COMPLETED');
+
+-- name: AcquireAdvisoryLock :exec
+-- Сеансовая блокировка: берётся до начала транзакции, чтобы её снимок был сделан уже после ожидания
+SELECT pg_advisory_lock(sqlc.arg(key1)::int, sqlc.arg(key2)::int);
+
+-- name: AcquireAdvisoryXactLock :exec
+-- Блокировка до конца транзакции. Снимок сериализуемой транзакции к этому моменту уже сделан:
+-- блокировка упорядочивает запись, но не показывает изменений, зафиксированных во время ожидания
+SELECT pg_advisory_xact_lock(sqlc.arg(key1)::int, sqlc.arg(key2)::int);
+
+-- name: ReleaseAdvisoryLocks :exec
+-- Снять все сеансовые блокировки соединения
+SELECT pg_advisory_unlock_all();
@@ -0,0 +1,25 @@
+-- name: CreateRateQuote :one
+-- Создать котировку с зафиксированным курсом
+INSERT INTO rate_quotes (
+    id, client_id, operation_type, currency_id, amount,
+    amount_currency, amount_rub, effective_rate, expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetRateQuote :one
+-- Получить котировку по идентификатору
+SELECT * FROM rate_quotes
+WHERE id = $1;
+
+-- name: UseRateQuote :one
+-- Отметить котировку использованной; неиспользованная и непросроченная котировка обновляется только один раз
+UPDATE rate_quotes
+SET
+    used_at = NOW(),
+    operation_id = $2
+WHERE id = $1
+  AND used_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
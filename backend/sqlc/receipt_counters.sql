@@ -0,0 +1,16 @@
+-- name: NextReceiptNumber :one
+-- Выдать следующий номер чека отделения за бизнес-день.
+-- Строка счётчика блокируется до конца транзакции, поэтому номера выдаются строго по порядку.
+INSERT INTO receipt_counters (branch_code, business_date, last_number)
+VALUES ($1, $2, 1)
+ON CONFLICT (branch_code, business_date) DO UPDATE
+SET
+    last_number = receipt_counters.last_number + 1,
+    updated_at = NOW()
+RETURNING last_number;
+
+-- name: ReceiptReferenceExists :one
+-- Проверить, занят ли номер чека
+SELECT EXISTS (
+    SELECT 1 FROM operations WHERE receipt_reference = $1
+);
@@ -0,0 +1,27 @@
+-- name: UpsertReferenceRate :one
+-- Сохранить официальный курс на дату; повторная загрузка той же даты заменяет курс
+INSERT INTO reference_rates (
+    currency_code, rate_date, nominal, value, rate, source
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (currency_code, rate_date) DO UPDATE
+SET nominal = EXCLUDED.nominal,
+    value = EXCLUDED.value,
+    rate = EXCLUDED.rate,
+    source = EXCLUDED.source,
+    imported_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetReferenceRate :one
+-- Получить официальный курс валюты, действующий на дату: последний установленный не позже on_date
+SELECT * FROM reference_rates
+WHERE currency_code = sqlc.arg(currency_code) AND rate_date <= sqlc.arg(on_date)::date
+ORDER BY rate_date DESC
+LIMIT 1;
+
+-- name: ListReferenceRates :many
+-- Получить официальные курсы всех валют, действующие на дату
+SELECT DISTINCT ON (currency_code) * FROM reference_rates
+WHERE rate_date <= sqlc.arg(on_date)::date
+ORDER BY currency_code, rate_date DESC;
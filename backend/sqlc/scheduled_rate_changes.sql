@@ -0,0 +1,47 @@
+-- name: CreateScheduledRateChange :one
+INSERT INTO scheduled_rate_changes (
+    currency_id, buy_rate, sell_rate, effective_at, override, reason, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetScheduledRateChange :one
+SELECT * FROM scheduled_rate_changes
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledRateChanges :many
+-- Получить плановые изменения курса валюты в порядке вступления в силу; пустой status — все статусы
+SELECT * FROM scheduled_rate_changes
+WHERE currency_id = sqlc.arg(currency_id)
+  AND (sqlc.arg(status)::text = '' OR status = sqlc.arg(status)::text)
+ORDER BY effective_at, id;
+
+-- name: ListDueScheduledRateChanges :many
+-- Получить и заблокировать наступившие плановые изменения: все или одной валюты (currency_id).
+-- Наступившие — не позже начала текущей транзакции, то есть времени операции, которая в ней записывается.
+SELECT * FROM scheduled_rate_changes
+WHERE status = 'PENDING'
+  AND effective_at <= CURRENT_TIMESTAMP
+  AND (sqlc.narg(currency_id)::int IS NULL OR currency_id = sqlc.narg(currency_id)::int)
+ORDER BY effective_at, id
+FOR UPDATE;
+
+-- name: MarkScheduledRateChangeApplied :one
+UPDATE scheduled_rate_changes
+SET status = 'APPLIED', applied_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'PENDING'
+RETURNING *;
+
+-- name: MarkScheduledRateChangeFailed :one
+UPDATE scheduled_rate_changes
+SET status = 'FAILED', failure_reason = $2
+WHERE id = $1 AND status = 'PENDING'
+RETURNING *;
+
+-- name: CancelScheduledRateChange :one
+-- Отменить плановое изменение курса валюты, если оно ещё не применено
+UPDATE scheduled_rate_changes
+SET status = 'CANCELLED', cancelled_by = $3, cancelled_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND currency_id = $2 AND status = 'PENDING'
+RETURNING *;
@@ -16,10 +16,53 @@ CREATE TABLE currencies (
     sell_rate DECIMAL(19, 8) NOT NULL, -- Курс продажи валюты за рубли
     last_rate_update_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
     created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    minor_units SMALLINT NOT NULL DEFAULT 2, -- Знаков после запятой (JPY — 0, KWD — 3)
+    rounding_mode VARCHAR(20) NOT NULL DEFAULT 'HALF_EVEN', -- HALF_EVEN, DOWN, CASH
+    cash_increment DECIMAL(19, 4), -- Наименьшая купюра или монета для CASH
+    is_active BOOLEAN NOT NULL DEFAULT TRUE, -- Неактивной валютой не торгуют
+    inactive_reason VARCHAR(255), -- Причина приостановки торговли
+    status_changed_at TIMESTAMPTZ,
+    status_changed_by INTEGER REFERENCES users(id), -- Кто последним приостановил или возобновил торговлю
+    deleted_at TIMESTAMPTZ, -- Валюта удалена: не торгуется и скрыта из списков
+    deleted_by INTEGER REFERENCES users(id)
 );
 
 -- Таблица операций обмена
+CREATE TABLE operation_fees (
+    id SERIAL PRIMARY KEY,
+    currency_id INTEGER REFERENCES currencies(id), -- NULL: для всех валют
+    operation_type VARCHAR(50), -- NULL: для всех типов операций
+    min_amount_rub DECIMAL(19, 4) NOT NULL DEFAULT 0, -- Нижняя граница ступени по сумме операции в рублях
+    fixed_fee_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    percent_fee DECIMAL(7, 4) NOT NULL DEFAULT 0,
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(100) UNIQUE NOT NULL,
+    password_hash VARCHAR(100) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL, -- CASHIER, SUPERVISOR, ADMIN, AUDITOR
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_login_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE shifts (
+    id SERIAL PRIMARY KEY,
+    cashier_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'OPEN',
+    opened_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMPTZ,
+    close_comment TEXT,
+    cashier_id INTEGER REFERENCES users(id)
+);
+
 CREATE TABLE operations (
     id BIGSERIAL PRIMARY KEY,
     client_id INTEGER NOT NULL REFERENCES clients(id),
@@ -29,15 +72,167 @@ CREATE TABLE operations (
     amount_rub DECIMAL(19, 4) NOT NULL, -- Сумма в рублях
     effective_rate DECIMAL(19, 8) NOT NULL,
     operation_timestamp TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
-    receipt_reference VARCHAR(255) UNIQUE NOT NULL,
-    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+    receipt_reference VARCHAR(255) UNIQUE, -- NULL, пока операция не завершена
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'COMPLETED', -- DRAFT, CONFIRMED, COMPLETED, CANCELLED, EXPIRED, REVERSED, REVERSAL
+    reversal_of_id BIGINT REFERENCES operations(id), -- Для компенсирующей операции: сторнированная операция
+    reversal_reason VARCHAR(50),
+    reversal_comment TEXT,
+    reversed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ, -- Срок действия черновика
+    confirmed_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    exchange_group VARCHAR(255), -- Общий номер для двух операций кросс-конвертации
+    fee_fixed_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    fee_percent_rub DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    fee_rub DECIMAL(19, 4) NOT NULL DEFAULT 0, -- Итоговая комиссия
+    fee_rule_id INTEGER REFERENCES operation_fees(id),
+    spread_rub DECIMAL(19, 4) NOT NULL DEFAULT 0, -- Доход от разницы курса операции и среднего курса
+    shift_id INTEGER REFERENCES shifts(id), -- Смена, в которой проведена операция
+    cashier_id INTEGER REFERENCES users(id) -- Кассир, проводивший операцию
 );
 
 CREATE TABLE IF NOT EXISTS operation_limits (
     id SERIAL PRIMARY KEY,
-    limit_name VARCHAR(50) NOT NULL UNIQUE,
+    limit_name VARCHAR(100) NOT NULL UNIQUE, -- "<лимит>[:<код валюты>][:<тип операции>]"
     limit_value DECIMAL(15,4) NOT NULL,
     description TEXT,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-);
\ No newline at end of file
+);
+
+CREATE TABLE rate_quotes (
+    id VARCHAR(64) PRIMARY KEY,
+    client_id INTEGER REFERENCES clients(id), -- NULL: котировка не привязана к клиенту
+    operation_type VARCHAR(50) NOT NULL,
+    currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    amount VARCHAR(64) NOT NULL,
+    amount_currency DECIMAL(19, 4) NOT NULL,
+    amount_rub DECIMAL(19, 4) NOT NULL,
+    effective_rate DECIMAL(19, 8) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    operation_id BIGINT REFERENCES operations(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE idempotency_keys (
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_method VARCHAR(10) NOT NULL,
+    request_path TEXT NOT NULL,
+    request_hash CHAR(64) NOT NULL, -- SHA-256 метода, пути и тела запроса
+    response_status INTEGER, -- NULL, пока запрос обрабатывается
+    response_content_type VARCHAR(255),
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, -- До этого момента ключ занят запросом; потом его можно занять повторно
+    claim_number INTEGER NOT NULL DEFAULT 1, -- Растёт при каждом повторном занятии ключа
+    entity_type VARCHAR(50), -- Сущность, изменённая запросом; записывается в транзакции обработчика
+    entity_id VARCHAR(255),
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE, -- Ключ уникален в пределах пользователя
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE TABLE receipt_counters (
+    branch_code VARCHAR(20) NOT NULL,
+    business_date DATE NOT NULL,
+    last_number BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (branch_code, business_date)
+);
+
+CREATE TABLE cash_balances (
+    currency_code VARCHAR(10) PRIMARY KEY,
+    balance DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    reserve DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT cash_balances_reserve_check CHECK (reserve >= 0)
+);
+
+CREATE TABLE cash_movements (
+    id BIGSERIAL PRIMARY KEY,
+    currency_code VARCHAR(10) NOT NULL REFERENCES cash_balances(currency_code),
+    movement_type VARCHAR(20) NOT NULL CHECK (movement_type IN ('OPERATION', 'REVERSAL', 'CASH_IN', 'CASH_OUT')),
+    amount DECIMAL(19, 4) NOT NULL,
+    balance_after DECIMAL(19, 4) NOT NULL,
+    operation_id BIGINT REFERENCES operations(id),
+    reason VARCHAR(50),
+    comment TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    shift_id INTEGER REFERENCES shifts(id)
+);
+
+CREATE TABLE shift_balances (
+    shift_id INTEGER NOT NULL REFERENCES shifts(id),
+    currency_code VARCHAR(10) NOT NULL,
+    opening_balance DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    operations_amount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    cash_in_amount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    cash_out_amount DECIMAL(19, 4) NOT NULL DEFAULT 0,
+    expected_balance DECIMAL(19, 4),
+    counted_balance DECIMAL(19, 4),
+    discrepancy DECIMAL(19, 4),
+    PRIMARY KEY (shift_id, currency_code)
+);
+
+-- Журнал аудита: записи только добавляются, hash связывает каждую запись с предыдущей
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    actor_id INTEGER REFERENCES users(id),
+    actor_username VARCHAR(100),
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    before_data JSONB,
+    after_data JSONB,
+    ip_address VARCHAR(45),
+    request_id VARCHAR(100),
+    prev_hash CHAR(64) NOT NULL UNIQUE,
+    hash CHAR(64) NOT NULL UNIQUE
+);
+
+-- История курсов валют
+CREATE TABLE currency_rate_history (
+    id BIGSERIAL PRIMARY KEY,
+    currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    buy_rate DECIMAL(19, 8) NOT NULL,
+    sell_rate DECIMAL(19, 8) NOT NULL,
+    effective_from TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    source VARCHAR(20) NOT NULL,
+    changed_by INTEGER REFERENCES users(id),
+    reason VARCHAR(255)
+);
+
+-- Официальные курсы ЦБ РФ
+CREATE TABLE reference_rates (
+    id SERIAL PRIMARY KEY,
+    currency_code VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    nominal INTEGER NOT NULL,
+    value DECIMAL(19, 8) NOT NULL,
+    rate DECIMAL(19, 8) NOT NULL,
+    source VARCHAR(255) NOT NULL,
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (currency_code, rate_date)
+);
+
+-- Плановые изменения курсов
+CREATE TABLE scheduled_rate_changes (
+    id SERIAL PRIMARY KEY,
+    currency_id INTEGER NOT NULL REFERENCES currencies(id),
+    buy_rate DECIMAL(19, 8) NOT NULL,
+    sell_rate DECIMAL(19, 8) NOT NULL,
+    effective_at TIMESTAMPTZ NOT NULL,
+    override BOOLEAN NOT NULL DEFAULT FALSE,
+    reason VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    applied_at TIMESTAMPTZ,
+    cancelled_by INTEGER REFERENCES users(id),
+    cancelled_at TIMESTAMPTZ,
+    failure_reason TEXT
+);
@@ -0,0 +1,102 @@
+-- name: OpenShift :one
+-- Открыть смену кассира
+INSERT INTO shifts (cashier_id, cashier_name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetOpenShift :one
+-- Получить открытую смену
+SELECT * FROM shifts
+WHERE status = 'OPEN';
+
+-- name: LockOpenShift :one
+-- Получить открытую смену для проведения операции.
+-- Блокировка FOR SHARE не даёт закрыть смену, пока операция не завершится.
+SELECT * FROM shifts
+WHERE status = 'OPEN'
+FOR SHARE;
+
+-- name: GetOpenShiftForUpdate :one
+-- Получить открытую смену для закрытия: ожидает завершения проводимых в ней операций
+SELECT * FROM shifts
+WHERE status = 'OPEN'
+FOR UPDATE;
+
+-- name: GetShift :one
+-- Получить смену по идентификатору
+SELECT * FROM shifts
+WHERE id = $1;
+
+-- name: ListShifts :many
+-- Получить смены, новые первыми
+SELECT * FROM shifts
+ORDER BY opened_at DESC, id DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CloseShift :one
+-- Закрыть смену
+UPDATE shifts
+SET
+    status = 'CLOSED',
+    closed_at = NOW(),
+    close_comment = sqlc.narg(close_comment)
+WHERE id = sqlc.arg(id) AND status = 'OPEN'
+RETURNING *;
+
+-- name: SnapshotShiftOpeningBalances :exec
+-- Зафиксировать остатки кассы на открытие смены
+INSERT INTO shift_balances (shift_id, currency_code, opening_balance)
+SELECT sqlc.arg(shift_id), currency_code, balance
+FROM cash_balances;
+
+-- name: GetShiftCashTotals :many
+-- Суммы движений наличных за смену по валютам и типам движений
+SELECT
+    currency_code,
+    movement_type,
+    SUM(amount)::DECIMAL(19,4) AS total_amount -- Приведение типа для sqlc
+FROM cash_movements
+WHERE shift_id = $1
+GROUP BY currency_code, movement_type
+ORDER BY currency_code, movement_type;
+
+-- name: SaveShiftBalance :one
+-- Записать сверку смены по валюте
+INSERT INTO shift_balances (
+    shift_id, currency_code, opening_balance, operations_amount, cash_in_amount, cash_out_amount,
+    expected_balance, counted_balance, discrepancy
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+ON CONFLICT (shift_id, currency_code) DO UPDATE
+SET
+    operations_amount = EXCLUDED.operations_amount,
+    cash_in_amount = EXCLUDED.cash_in_amount,
+    cash_out_amount = EXCLUDED.cash_out_amount,
+    expected_balance = EXCLUDED.expected_balance,
+    counted_balance = EXCLUDED.counted_balance,
+    discrepancy = EXCLUDED.discrepancy
+RETURNING *;
+
+-- name: ListShiftBalances :many
+-- Получить сверку смены по валютам
+SELECT * FROM shift_balances
+WHERE shift_id = $1
+ORDER BY currency_code;
+
+-- name: GetShiftOperationTotals :many
+-- Итоги операций смены по валютам, типам и статусам для Z-отчёта
+SELECT
+    cur.code AS currency_code,
+    o.operation_type,
+    o.status,
+    COUNT(*) AS operations_count,
+    SUM(o.amount_currency)::DECIMAL(19,4) AS amount_currency, -- Приведение типа для sqlc
+    SUM(o.amount_rub)::DECIMAL(19,4) AS amount_rub,
+    SUM(o.fee_rub)::DECIMAL(19,4) AS fee_rub
+FROM operations o
+JOIN currencies cur ON o.currency_id = cur.id
+WHERE o.shift_id = $1
+  AND o.status IN ('COMPLETED', 'REVERSED', 'REVERSAL')
+GROUP BY cur.code, o.operation_type, o.status
+ORDER BY cur.code, o.operation_type, o.status;
@@ -0,0 +1,49 @@
+-- name: GetUser :one
+-- Получить пользователя по идентификатору
+SELECT * FROM users
+WHERE id = $1;
+
+-- name: GetUserByUsername :one
+-- Получить пользователя по имени для входа
+SELECT * FROM users
+WHERE username = $1;
+
+-- name: ListUsers :many
+-- Получить всех пользователей
+SELECT * FROM users
+ORDER BY username;
+
+-- name: CountUsers :one
+-- Количество пользователей: при пустой таблице создаётся первый администратор
+SELECT COUNT(*) FROM users;
+
+-- name: CreateUser :one
+-- Создать пользователя
+INSERT INTO users (username, password_hash, full_name, role)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpdateUser :one
+-- Изменить имя, роль или активность пользователя; не переданные поля не меняются
+UPDATE users
+SET
+    full_name = COALESCE(sqlc.narg(full_name), full_name),
+    role = COALESCE(sqlc.narg(role), role),
+    is_active = COALESCE(sqlc.narg(is_active), is_active),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateUserPassword :exec
+-- Сменить пароль пользователя
+UPDATE users
+SET
+    password_hash = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: RecordUserLogin :exec
+-- Отметить успешный вход
+UPDATE users
+SET last_login_at = NOW()
+WHERE id = $1;
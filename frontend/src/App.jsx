@@ -1,11 +1,13 @@
-import React from 'react';
-import { Routes, Route } from 'react-router-dom';
+import React, { useEffect } from 'react';
+import { Routes, Route, Navigate, useLocation, useNavigate } from 'react-router-dom';
 import Layout from './components/Layout';
 import HomePage from './pages/Homepage';
 import ClientsPage from './pages/ClientsPage';
 import CurrenciesPage from './pages/CurrenciesPage';
 import OperationsPage from './pages/OperationsPage';
 import AnalyticsPage from './pages/AnalyticsPage';
+import LoginPage from './pages/LoginPage';
+import { getSession, SESSION_EXPIRED_EVENT } from './api';
 import './App.css';
 
 const Home = () => (
@@ -47,17 +49,34 @@ const Home = () => (
   </div>
 );
 
+// Страницы приложения доступны только после входа
+const RequireAuth = ({ children }) => {
+  const location = useLocation();
+  if (!getSession()) {
+    return <Navigate to="/login" replace state={{ from: location }} />;
+  }
+  return <Layout>{children}</Layout>;
+};
+
 const App = () => {
+  const navigate = useNavigate();
+
+  // Выход или истёкший токен (ответ 401) возвращают на страницу входа
+  useEffect(() => {
+    const handleSessionExpired = () => navigate('/login', { replace: true });
+    window.addEventListener(SESSION_EXPIRED_EVENT, handleSessionExpired);
+    return () => window.removeEventListener(SESSION_EXPIRED_EVENT, handleSessionExpired);
+  }, [navigate]);
+
   return (
-    <Layout>
-      <Routes>
-        <Route path="/" element={<Home />} />
-        <Route path="/clients" element={<ClientsPage />} />
-        <Route path="/currencies" element={<CurrenciesPage />} />
-        <Route path="/operations" element={<OperationsPage />} />
-        <Route path="/analytics" element={<AnalyticsPage />} />
-      </Routes>
-    </Layout>
+    <Routes>
+      <Route path="/login" element={<LoginPage />} />
+      <Route path="/" element={<RequireAuth><Home /></RequireAuth>} />
+      <Route path="/clients" element={<RequireAuth><ClientsPage /></RequireAuth>} />
+      <Route path="/currencies" element={<RequireAuth><CurrenciesPage /></RequireAuth>} />
+      <Route path="/operations" element={<RequireAuth><OperationsPage /></RequireAuth>} />
+      <Route path="/analytics" element={<RequireAuth><AnalyticsPage /></RequireAuth>} />
+    </Routes>
   );
 };
 
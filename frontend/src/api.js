@@ -0,0 +1,80 @@
+// Запросы к API с токеном аутентификации.
+// Токен выдаётся при входе (POST /auth/login) и хранится в localStorage до выхода или истечения срока.
+export const API_BASE_URL = process.env.REACT_APP_API_URL || 'http://localhost:8080/api/v1';
+
+const SESSION_KEY = 'exchangePointSession';
+
+// Событие, по которому приложение возвращается на страницу входа
+export const SESSION_EXPIRED_EVENT = 'exchange-point:session-expired';
+
+export const getSession = () => {
+  try {
+    const session = JSON.parse(localStorage.getItem(SESSION_KEY));
+    if (!session?.token) return null;
+    if (session.expires_at && new Date(session.expires_at) <= new Date()) {
+      localStorage.removeItem(SESSION_KEY);
+      return null;
+    }
+    return session;
+  } catch {
+    return null;
+  }
+};
+
+export const clearSession = () => {
+  localStorage.removeItem(SESSION_KEY);
+};
+
+// Вход: сохраняет токен и пользователя, возвращает сессию
+export const login = async (username, password) => {
+  const response = await fetch(`${API_BASE_URL}/auth/login`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ username, password }),
+  });
+  const data = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(data.message || `Login failed (${response.status})`);
+  }
+  const session = {
+    token: data.data.token,
+    expires_at: data.data.expires_at,
+    user: data.data.user,
+  };
+  localStorage.setItem(SESSION_KEY, JSON.stringify(session));
+  return session;
+};
+
+export const logout = () => {
+  clearSession();
+  window.dispatchEvent(new Event(SESSION_EXPIRED_EVENT));
+};
+
+// apiFetch — fetch к API с заголовком Authorization. path — путь относительно API_BASE_URL.
+// На 401 сессия сбрасывается, и приложение показывает страницу входа.
+export const apiFetch = async (path, options = {}) => {
+  const headers = new Headers(options.headers || {});
+  const session = getSession();
+  if (session) {
+    headers.set('Authorization', `Bearer ${session.token}`);
+  }
+  const response = await fetch(`${API_BASE_URL}${path}`, { ...options, headers });
+  if (response.status === 401) {
+    logout();
+  }
+  return response;
+};
+
+// openPdf загружает PDF с токеном и открывает его в новой вкладке:
+// по обычной ссылке браузер не передал бы заголовок Authorization
+export const openPdf = async (path) => {
+  const response = await apiFetch(path);
+  if (!response.ok) {
+    const data = await response.json().catch(() => ({}));
+    throw new Error(data.message || `Failed to load document (${response.status})`);
+  }
+  const blob = await response.blob();
+  const url = window.URL.createObjectURL(blob);
+  window.open(url, '_blank');
+  setTimeout(() => window.URL.revokeObjectURL(url), 60000);
+};
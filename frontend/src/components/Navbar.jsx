@@ -1,8 +1,10 @@
 import React, { useState } from 'react';
 import { Link } from 'react-router-dom';
+import { getSession, logout } from '../api';
 
 const Navbar = () => {
   const [isOpen, setIsOpen] = useState(false);
+  const user = getSession()?.user;
 
   return (
     <nav className="bg-white shadow-lg">
@@ -27,7 +29,11 @@ const Navbar = () => {
               </Link>
             </div>
           </div>
-          <div className="hidden sm:ml-6 sm:flex sm:items-center">
+          <div className="hidden sm:ml-6 sm:flex sm:items-center sm:space-x-4">
+            {user && <span className="text-sm text-gray-600">{user.full_name || user.username}</span>}
+            <button onClick={logout} className="text-gray-900 hover:text-primary px-3 py-2 rounded-md text-sm font-medium">
+              Выйти
+            </button>
           </div>
           <div className="flex items-center sm:hidden">
             <button
@@ -64,7 +70,9 @@ const Navbar = () => {
               Аналитика
             </Link>
             <div className="px-3 py-2">
-
+              <button onClick={logout} className="text-base font-medium text-gray-900 hover:text-primary">
+                Выйти
+              </button>
             </div>
           </div>
         </div>
@@ -1,5 +1,5 @@
 // API configuration
-const API_BASE_URL = process.env.REACT_APP_API_URL || 'http://backend:8080/api/v1';
+import { API_BASE_URL } from './api';
 
 export const API_ENDPOINTS = {
   clients: `${API_BASE_URL}/clients`,
@@ -2,6 +2,7 @@ import React, { useState, useEffect, useCallback } from 'react';
 import { format } from 'date-fns';
 import { ru } from 'date-fns/locale';
 import './AnalyticsPage.css';
+import { apiFetch } from '../api';
 
 const AnalyticsPage = () => {
   const [loading, setLoading] = useState(false);
@@ -21,7 +22,7 @@ const AnalyticsPage = () => {
 
   const fetchCurrencies = useCallback(async () => {
     try {
-      const response = await fetch('http://localhost:8080/api/v1/currencies');
+      const response = await apiFetch('/currencies');
       if (!response.ok) throw new Error('Failed to fetch currencies');
       const data = await response.json();
       setCurrencies(data.data || []);
@@ -35,13 +36,13 @@ const AnalyticsPage = () => {
     setLoading(true);
     setError('');
     try {
-      const url = new URL('http://localhost:8080/api/v1/analytics/operations');
-      url.searchParams.append('start_date', filters.startDate);
-      url.searchParams.append('end_date', filters.endDate);
-      if (filters.currency !== 'all' && filters.currency) url.searchParams.append('currency_code', filters.currency);
-      if (filters.operationType !== 'all' && filters.operationType) url.searchParams.append('operation_type', filters.operationType);
+      const params = new URLSearchParams();
+      params.append('start_date', filters.startDate);
+      params.append('end_date', filters.endDate);
+      if (filters.currency !== 'all' && filters.currency) params.append('currency_code', filters.currency);
+      if (filters.operationType !== 'all' && filters.operationType) params.append('operation_type', filters.operationType);
 
-      const response = await fetch(url.toString());
+      const response = await apiFetch(`/analytics/operations?${params.toString()}`);
       if (!response.ok) {
         const errData = await response.json();
         throw new Error(errData.message || `Failed to fetch analytics data (${response.status})`);
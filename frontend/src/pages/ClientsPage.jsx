@@ -1,5 +1,6 @@
 import React, { useState, useEffect, useCallback } from 'react';
 import './ClientsPage.css';
+import { apiFetch } from '../api';
 
 const ClientsPage = () => {
   const [clients, setClients] = useState([]);
@@ -12,7 +13,7 @@ const ClientsPage = () => {
     setLoading(true);
     setError('');
     try {
-      const response = await fetch('http://localhost:8080/api/v1/clients');
+      const response = await apiFetch('/clients');
       if (!response.ok) {
         const errData = await response.json();
         throw new Error(errData.message || `Network response was not ok (${response.status})`);
@@ -45,7 +46,7 @@ const ClientsPage = () => {
     }
     setLoading(true);
     try {
-      const response = await fetch('http://localhost:8080/api/v1/clients', {
+      const response = await apiFetch('/clients', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(newClient)
@@ -2,6 +2,7 @@ import React, { useState, useEffect, useCallback } from 'react';
 import { format, parseISO, isValid } from 'date-fns';
 import { ru } from 'date-fns/locale';
 import './CurrenciesPage.css';
+import { apiFetch } from '../api';
 
 const CurrenciesPage = () => {
   const [currencies, setCurrencies] = useState([]);
@@ -34,7 +35,7 @@ const CurrenciesPage = () => {
     setLoading(true);
     setError('');
     try {
-      const response = await fetch('http://localhost:8080/api/v1/currencies');
+      const response = await apiFetch('/currencies');
       if (!response.ok) {
         const errData = await response.json();
         throw new Error(errData.message || `Network response was not ok (${response.status})`);
@@ -73,7 +74,7 @@ const CurrenciesPage = () => {
         sell_rate: Number(sellRate.toFixed(8)),
       };
 
-      const response = await fetch('http://localhost:8080/api/v1/currencies', {
+      const response = await apiFetch('/currencies', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(payload),
@@ -116,7 +117,7 @@ const CurrenciesPage = () => {
         sell_rate: Number(sellRate.toFixed(8)),
       };
 
-      const response = await fetch('http://localhost:8080/api/v1/currencies', {
+      const response = await apiFetch('/currencies', {
         method: 'PUT',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(payload),
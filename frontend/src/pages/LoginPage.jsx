@@ -0,0 +1,84 @@
+import React, { useState } from 'react';
+import { Navigate, useLocation, useNavigate } from 'react-router-dom';
+import { getSession, login } from '../api';
+
+const LoginPage = () => {
+  const navigate = useNavigate();
+  const location = useLocation();
+  const [credentials, setCredentials] = useState({ username: '', password: '' });
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  // После входа возвращаемся на страницу, с которой перенаправили на вход
+  const from = location.state?.from?.pathname || '/';
+
+  if (getSession()) {
+    return <Navigate to={from} replace />;
+  }
+
+  const handleInputChange = (e) => {
+    const { name, value } = e.target;
+    setCredentials(prev => ({ ...prev, [name]: value }));
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setError('');
+    if (!credentials.username.trim() || !credentials.password) {
+      setError('Введите имя пользователя и пароль.');
+      return;
+    }
+    setLoading(true);
+    try {
+      await login(credentials.username.trim(), credentials.password);
+      navigate(from, { replace: true });
+    } catch (err) {
+      setError('Ошибка входа: ' + err.message);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="min-h-screen bg-background flex items-center justify-center px-4">
+      <form onSubmit={handleSubmit} className="bg-white shadow-lg rounded-lg p-8 w-full max-w-sm space-y-4">
+        <h1 className="text-2xl font-bold text-gray-900">Exchange Point</h1>
+        <p className="text-gray-600">Войдите, чтобы продолжить</p>
+        {error && <div className="text-red-600 text-sm">{error}</div>}
+        <div>
+          <label htmlFor="username" className="block text-sm font-medium text-gray-700 mb-1">Имя пользователя</label>
+          <input
+            id="username"
+            name="username"
+            type="text"
+            autoComplete="username"
+            value={credentials.username}
+            onChange={handleInputChange}
+            className="w-full border border-gray-300 rounded-lg px-3 py-2"
+          />
+        </div>
+        <div>
+          <label htmlFor="password" className="block text-sm font-medium text-gray-700 mb-1">Пароль</label>
+          <input
+            id="password"
+            name="password"
+            type="password"
+            autoComplete="current-password"
+            value={credentials.password}
+            onChange={handleInputChange}
+            className="w-full border border-gray-300 rounded-lg px-3 py-2"
+          />
+        </div>
+        <button
+          type="submit"
+          disabled={loading}
+          className="w-full bg-blue-600 text-white px-4 py-2 rounded-lg hover:bg-blue-700 disabled:opacity-50"
+        >
+          {loading ? 'Вход...' : 'Войти'}
+        </button>
+      </form>
+    </div>
+  );
+};
+
+export default LoginPage;
@@ -1,5 +1,6 @@
 import React, { useState, useEffect, useCallback } from 'react';
 import './OperationsPage.css';
+import { apiFetch, openPdf } from '../api';
 
 const OperationsPage = () => {
   const [operations, setOperations] = useState([]);
@@ -42,7 +43,7 @@ const OperationsPage = () => {
     setLoading(true);
     setError(null);
     try {
-      const response = await fetch(`http://localhost:8080/api/v1/operations?page=${page}&pageSize=${pageSize}`);
+      const response = await apiFetch(`/operations?page=${page}&pageSize=${pageSize}`);
       if (!response.ok) {
         const errData = await response.json();
         throw new Error(errData.message || `Failed to fetch operations (${response.status})`);
@@ -58,37 +59,20 @@ const OperationsPage = () => {
     }
   }, [pageSize]);
 
-  const downloadReceipt = async (operationId) => {
+  const openReceipt = async (reference) => {
     try {
-      const response = await fetch(`http://localhost:8080/api/v1/operations/${operationId}/receipt`);
-      if (!response.ok) {
-        throw new Error(`Failed to download receipt (${response.status})`);
-      }
-      const blob = await response.blob();
-      const url = window.URL.createObjectURL(blob);
-      const link = document.createElement('a');
-      link.href = url;
-      link.download = `receipt_${operationId}.pdf`;
-      document.body.appendChild(link);
-      link.click();
-      document.body.removeChild(link);
-      window.URL.revokeObjectURL(url);
+      await openPdf(`/receipts/${encodeURIComponent(reference)}`);
     } catch (err) {
-      console.error('Error downloading receipt:', err);
-      setError(prev => prev ? `${prev}; Ошибка скачивания чека: ${err.message}` : `Ошибка скачивания чека: ${err.message}`);
+      console.error('Error opening receipt:', err);
+      setError(prev => prev ? `${prev}; Ошибка открытия чека: ${err.message}` : `Ошибка открытия чека: ${err.message}`);
     }
   };
 
-  const openReceipt = (reference) => {
-    const receiptUrl = `http://localhost:8080/api/v1/receipts/${reference}`;
-    window.open(receiptUrl, '_blank');
-  };
-
   const fetchDropdownData = useCallback(async () => {
     try {
       const [clientsRes, currenciesRes] = await Promise.all([
-        fetch('http://localhost:8080/api/v1/clients'),
-        fetch('http://localhost:8080/api/v1/currencies')
+        apiFetch('/clients'),
+        apiFetch('/currencies')
       ]);
 
       if (!clientsRes.ok) throw new Error('Failed to fetch clients for dropdown');
@@ -151,11 +135,8 @@ const OperationsPage = () => {
         operation_type: newOperation.operation_type,
         currency_id: parseInt(newOperation.currency_id),
         amount: newOperation.amount.toString(), // Отправляем как строку
-        daily_currency_volume: operationLimits.daily_currency_volume,
-        single_operation_amount: operationLimits.single_operation_amount,
       };
-      console.log('Sending operation payload:', payload);
-      const response = await fetch('http://localhost:8080/api/v1/operations', {
+      const response = await apiFetch('/operations', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(payload),
@@ -239,16 +220,14 @@ const OperationsPage = () => {
 
   const handleViewOperation = (operationId) => {
     // Функция просмотра операции
-    console.log('Просмотр операции:', operationId);
     // Здесь можно добавить логику для открытия модального окна с деталями операции
   };
 
   const handleCancelOperation = async (operationId) => {
     // Функция отмены операции
     try {
-      console.log('Отмена операции:', operationId);
-      const response = await fetch(`http://localhost:8080/api/v1/operations/${operationId}/cancel`, {
-        method: 'PATCH',
+      const response = await apiFetch(`/operations/${operationId}/cancel`, {
+        method: 'POST',
         headers: { 'Content-Type': 'application/json' },
       });
       if (!response.ok) {
@@ -489,14 +468,16 @@ const OperationsPage = () => {
                         </span>
                       </td>
                       <td className="actions-cell">
-                        <button
-                          onClick={() => openReceipt(op.receipt_reference)}
-                          className="btn-receipt"
-                          title="Просмотр чека"
-                        >
-                          <span>👁️</span>
-                          Чек
-                        </button>
+                        {op.receipt_reference && (
+                          <button
+                            onClick={() => openReceipt(op.receipt_reference)}
+                            className="btn-receipt"
+                            title="Просмотр чека"
+                          >
+                            <span>👁️</span>
+                            Чек
+                          </button>
+                        )}
                       </td>
                     </tr>
                   ))}